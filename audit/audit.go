@@ -0,0 +1,385 @@
+// Package audit keeps an append-only trail of every change made to projects and achievements
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Store returns a Store that appends an entry to the audit log for every change made through s.
+// Failing to record an entry is logged but doesn't fail the change, which has already been made.
+func Store(s storage.Store, logger logrus.FieldLogger) storage.Store {
+	return store{Store: s, logger: logger, now: time.Now}
+}
+
+type store struct {
+	storage.Store
+	logger logrus.FieldLogger
+	now    func() time.Time
+}
+
+// record appends an entry to the log of entityID, with the JSON encodings of before and after.
+// A nil before or after means the entity didn't exist before or after the operation.
+func (s store) record(ctx context.Context, actor, operation, entityType, entityID string, before, after interface{}) {
+	e := model.AuditEntry{
+		Actor:      actor,
+		Operation:  operation,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     encode(before),
+		After:      encode(after),
+		Timestamp:  int(s.now().Unix()),
+	}
+	if err := s.Store.AppendAuditEntry(ctx, e); err != nil {
+		logging.WithContext(ctx, s.logger).WithError(err).
+			WithField("operation", operation).WithField("entityID", entityID).
+			Error("Unable to record audit entry")
+	}
+}
+
+func encode(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	str := string(b)
+	return &str
+}
+
+func (s store) CreateProject(ctx context.Context, p model.Project) error {
+	if err := s.Store.CreateProject(ctx, p); err != nil {
+		return err
+	}
+	s.record(ctx, p.UserID, "CreateProject", storage.EntityProject, p.ID, nil, p)
+	return nil
+}
+
+func (s store) UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error) {
+	before, err := s.Store.GetProject(ctx, pID)
+	if err != nil {
+		return model.Project{}, err
+	}
+	p, err := s.Store.UpdateProject(ctx, pID, np)
+	if err != nil {
+		return p, err
+	}
+	s.record(ctx, p.UserID, "UpdateProject", storage.EntityProject, pID, before, p)
+	return p, nil
+}
+
+func (s store) ArchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	return s.setArchived(ctx, "ArchiveProject", s.Store.ArchiveProject, pID, uID)
+}
+
+func (s store) UnarchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	return s.setArchived(ctx, "UnarchiveProject", s.Store.UnarchiveProject, pID, uID)
+}
+
+// setArchived records an entry for the project and another for each of its descendants, which are
+// archived or unarchived along with it
+func (s store) setArchived(ctx context.Context, operation string,
+	set func(ctx context.Context, pID, uID string) (model.Project, error), pID, uID string) (model.Project, error) {
+	before, err := s.Store.GetProject(ctx, pID)
+	if err != nil {
+		return model.Project{}, err
+	}
+	descendants, err := s.descendants(ctx, pID)
+	if err != nil {
+		return model.Project{}, err
+	}
+	p, err := set(ctx, pID, uID)
+	if err != nil {
+		return p, err
+	}
+	s.record(ctx, uID, operation, storage.EntityProject, pID, before, p)
+	for _, d := range descendants {
+		after := d
+		after.Archived = p.Archived
+		s.record(ctx, uID, operation, storage.EntityProject, d.ID, d, after)
+	}
+	return p, nil
+}
+
+// DeleteProject records an entry for the project and another for each of its descendants, which are
+// deleted along with it
+func (s store) DeleteProject(ctx context.Context, pID, uID string) error {
+	before, err := s.Store.GetProject(ctx, pID)
+	if err != nil {
+		return err
+	}
+	descendants, err := s.descendants(ctx, pID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.DeleteProject(ctx, pID, uID); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "DeleteProject", storage.EntityProject, pID, before, nil)
+	for _, d := range descendants {
+		s.record(ctx, uID, "DeleteProject", storage.EntityProject, d.ID, d, nil)
+	}
+	return nil
+}
+
+// descendants returns the children of a project, the children of its children and so on, parents first
+func (s store) descendants(ctx context.Context, pID string) ([]model.Project, error) {
+	var ds []model.Project
+	for pending := []string{pID}; len(pending) > 0; pending = pending[1:] {
+		children, err := s.Store.GetChildProjects(ctx, pending[0])
+		if err != nil {
+			return ds, err
+		}
+		for _, c := range children {
+			ds = append(ds, c)
+			pending = append(pending, c.ID)
+		}
+	}
+	return ds, nil
+}
+
+func (s store) CreateCategory(ctx context.Context, c model.Category) error {
+	if err := s.Store.CreateCategory(ctx, c); err != nil {
+		return err
+	}
+	s.record(ctx, c.UserID, "CreateCategory", storage.EntityCategory, c.ID, nil, c)
+	return nil
+}
+
+func (s store) UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error) {
+	before, err := s.Store.GetCategory(ctx, cID)
+	if err != nil {
+		return model.Category{}, err
+	}
+	c, err := s.Store.UpdateCategory(ctx, cID, nc)
+	if err != nil {
+		return c, err
+	}
+	s.record(ctx, c.UserID, "UpdateCategory", storage.EntityCategory, cID, before, c)
+	return c, nil
+}
+
+func (s store) DeleteCategory(ctx context.Context, cID, uID string) error {
+	before, err := s.Store.GetCategory(ctx, cID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.DeleteCategory(ctx, cID, uID); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "DeleteCategory", storage.EntityCategory, cID, before, nil)
+	return nil
+}
+
+func (s store) CreateTag(ctx context.Context, t model.Tag) error {
+	if err := s.Store.CreateTag(ctx, t); err != nil {
+		return err
+	}
+	s.record(ctx, t.UserID, "CreateTag", storage.EntityTag, t.ID, nil, t)
+	return nil
+}
+
+func (s store) UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error) {
+	before, err := s.Store.GetTag(ctx, tID)
+	if err != nil {
+		return model.Tag{}, err
+	}
+	t, err := s.Store.UpdateTag(ctx, tID, nt)
+	if err != nil {
+		return t, err
+	}
+	s.record(ctx, t.UserID, "UpdateTag", storage.EntityTag, tID, before, t)
+	return t, nil
+}
+
+func (s store) DeleteTag(ctx context.Context, tID, uID string) error {
+	before, err := s.Store.GetTag(ctx, tID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.DeleteTag(ctx, tID, uID); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "DeleteTag", storage.EntityTag, tID, before, nil)
+	return nil
+}
+
+func (s store) TagProject(ctx context.Context, pID, tID string) error {
+	return s.setTags(ctx, "TagProject", storage.EntityProject, s.Store.GetProjectTags, s.Store.TagProject, pID, tID)
+}
+
+func (s store) UntagProject(ctx context.Context, pID, tID string) error {
+	return s.setTags(ctx, "UntagProject", storage.EntityProject, s.Store.GetProjectTags, s.Store.UntagProject, pID, tID)
+}
+
+func (s store) TagAchievement(ctx context.Context, aID, tID string) error {
+	return s.setTags(ctx, "TagAchievement", storage.EntityAchievement,
+		s.Store.GetAchievementTags, s.Store.TagAchievement, aID, tID)
+}
+
+func (s store) UntagAchievement(ctx context.Context, aID, tID string) error {
+	return s.setTags(ctx, "UntagAchievement", storage.EntityAchievement,
+		s.Store.GetAchievementTags, s.Store.UntagAchievement, aID, tID)
+}
+
+// setTags records the tags of entity id before and after adding or removing the tag tID
+func (s store) setTags(ctx context.Context, operation, entityType string,
+	get func(ctx context.Context, id string) ([]model.Tag, error), change func(ctx context.Context, id, tID string) error,
+	id, tID string) error {
+	t, err := s.Store.GetTag(ctx, tID)
+	if err != nil {
+		return err
+	}
+	before, err := get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := change(ctx, id, tID); err != nil {
+		return err
+	}
+	after, err := get(ctx, id)
+	if err != nil {
+		logging.WithContext(ctx, s.logger).WithError(err).
+			WithField("operation", operation).WithField("entityID", id).
+			Error("Unable to record audit entry")
+		return nil
+	}
+	s.record(ctx, t.UserID, operation, entityType, id, before, after)
+	return nil
+}
+
+func (s store) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	if err := s.Store.CreateAchievement(ctx, a); err != nil {
+		return err
+	}
+	s.record(ctx, a.UserID, "CreateAchievement", storage.EntityAchievement, a.ID, nil, a)
+	return nil
+}
+
+func (s store) UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error) {
+	before, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return model.Achievement{}, err
+	}
+	a, err := s.Store.UpdateAchievement(ctx, aID, newData)
+	if err != nil {
+		return a, err
+	}
+	s.record(ctx, a.UserID, "UpdateAchievement", storage.EntityAchievement, aID, before, a)
+	return a, nil
+}
+
+func (s store) SetAchievementNote(ctx context.Context, aID, note string) error {
+	before, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.SetAchievementNote(ctx, aID, note); err != nil {
+		return err
+	}
+	after := before
+	after.Note = note
+	s.record(ctx, before.UserID, "SetAchievementNote", storage.EntityAchievement, aID, before, after)
+	return nil
+}
+
+func (s store) SetAchievementBillable(ctx context.Context, aID string, billable *bool) error {
+	before, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.SetAchievementBillable(ctx, aID, billable); err != nil {
+		return err
+	}
+	after := before
+	after.Billable = billable
+	s.record(ctx, before.UserID, "SetAchievementBillable", storage.EntityAchievement, aID, before, after)
+	return nil
+}
+
+func (s store) DeleteAchievement(ctx context.Context, aID, pID string) error {
+	before, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.DeleteAchievement(ctx, aID, pID); err != nil {
+		return err
+	}
+	s.record(ctx, before.UserID, "DeleteAchievement", storage.EntityAchievement, aID, before, nil)
+	return nil
+}
+
+func (s store) RestoreProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	p, err := s.Store.RestoreProject(ctx, pID, uID)
+	if err != nil {
+		return p, err
+	}
+	s.record(ctx, uID, "RestoreProject", storage.EntityProject, pID, nil, p)
+	return p, nil
+}
+
+func (s store) RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error) {
+	a, err := s.Store.RestoreAchievement(ctx, aID, uID)
+	if err != nil {
+		return a, err
+	}
+	s.record(ctx, uID, "RestoreAchievement", storage.EntityAchievement, aID, nil, a)
+	return a, nil
+}
+
+func (s store) PurgeProject(ctx context.Context, pID, uID string) error {
+	if err := s.Store.PurgeProject(ctx, pID, uID); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "PurgeProject", storage.EntityProject, pID, nil, nil)
+	return nil
+}
+
+func (s store) PurgeAchievement(ctx context.Context, aID, uID string) error {
+	if err := s.Store.PurgeAchievement(ctx, aID, uID); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "PurgeAchievement", storage.EntityAchievement, aID, nil, nil)
+	return nil
+}
+
+func (s store) SetUserRate(ctx context.Context, uID string, rate *model.Money) error {
+	before, err := s.Store.GetUserRate(ctx, uID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.SetUserRate(ctx, uID, rate); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "SetUserRate", storage.EntityUser, uID, before, rate)
+	return nil
+}
+
+func (s store) SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error {
+	before, err := s.Store.GetUserRounding(ctx, uID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.SetUserRounding(ctx, uID, r); err != nil {
+		return err
+	}
+	s.record(ctx, uID, "SetUserRounding", storage.EntityUser, uID, before, r)
+	return nil
+}
+
+func (s store) CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error) {
+	inv, err := s.Store.CreateInvoice(ctx, inv)
+	if err != nil {
+		return inv, err
+	}
+	s.record(ctx, inv.UserID, "CreateInvoice", storage.EntityInvoice, inv.ID, nil, inv)
+	return inv, nil
+}
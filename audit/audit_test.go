@@ -0,0 +1,219 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	uID  = "e0b4e4b5-6d5f-4a3c-9b3a-1f6d3c2e9a10"
+	pID  = "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	aID  = "9a6f1c1e-4b1e-4d0e-8d9b-2a3c4f5e6d7c"
+	cID  = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	cID2 = "16fd2706-8baf-433b-82eb-8c7fada847da"
+)
+
+func newTestStore(s *mocks.Store) (store, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	return store{
+		Store:  s,
+		logger: logger,
+		now:    func() time.Time { return time.Unix(1600000000, 0) },
+	}, hook
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func TestCreateProject(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID, Name: "glow", CategoryID: cID}
+	s.On("CreateProject", mock.Anything, p).Return(nil)
+	s.On("AppendAuditEntry", mock.Anything, model.AuditEntry{
+		Actor:      uID,
+		Operation:  "CreateProject",
+		EntityType: storage.EntityProject,
+		EntityID:   pID,
+		After:      str(`{"id":"` + pID + `","userID":"` + uID + `","name":"glow","categoryID":"` + cID + `","parentID":null,"archived":false,"rate":null,"billable":false,"rounding":null}`),
+		Timestamp:  1600000000,
+	}).Return(nil)
+
+	assert.NoError(t, store.CreateProject(context.Background(), p))
+	s.AssertExpectations(t)
+}
+
+func TestUpdateProject(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	before := model.Project{ID: pID, UserID: uID, Name: "glow", CategoryID: cID}
+	after := model.Project{ID: pID, UserID: uID, Name: "glow", CategoryID: cID2}
+	np := model.NewProject{Name: "glow", CategoryID: str(cID2)}
+	s.On("GetProject", mock.Anything, pID).Return(before, nil)
+	s.On("UpdateProject", mock.Anything, pID, np).Return(after, nil)
+	s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Operation == "UpdateProject" && e.Actor == uID &&
+			*e.Before == `{"id":"`+pID+`","userID":"`+uID+`","name":"glow","categoryID":"`+cID+`","parentID":null,"archived":false,"rate":null,"billable":false,"rounding":null}` &&
+			*e.After == `{"id":"`+pID+`","userID":"`+uID+`","name":"glow","categoryID":"`+cID2+`","parentID":null,"archived":false,"rate":null,"billable":false,"rounding":null}`
+	})).Return(nil)
+
+	p, err := store.UpdateProject(context.Background(), pID, np)
+	assert.NoError(t, err)
+	assert.Equal(t, after, p)
+	s.AssertExpectations(t)
+}
+
+func TestDeleteProjectCascades(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID, Name: "Client X", CategoryID: cID}
+	child := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website", CategoryID: cID, ParentID: str(pID)}
+	s.On("GetProject", mock.Anything, pID).Return(p, nil)
+	s.On("GetChildProjects", mock.Anything, pID).Return([]model.Project{child}, nil)
+	s.On("GetChildProjects", mock.Anything, child.ID).Return([]model.Project{}, nil)
+	s.On("DeleteProject", mock.Anything, pID, uID).Return(nil)
+	for _, id := range []string{pID, child.ID} {
+		id := id
+		s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+			return e.Operation == "DeleteProject" && e.EntityID == id && e.Before != nil && e.After == nil
+		})).Return(nil).Once()
+	}
+
+	assert.NoError(t, store.DeleteProject(context.Background(), pID, uID))
+	s.AssertExpectations(t)
+}
+
+func TestArchiveProjectCascades(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID, Name: "Client X", CategoryID: cID}
+	child := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website", CategoryID: cID, ParentID: str(pID)}
+	archived := p
+	archived.Archived = true
+	s.On("GetProject", mock.Anything, pID).Return(p, nil)
+	s.On("GetChildProjects", mock.Anything, pID).Return([]model.Project{child}, nil)
+	s.On("GetChildProjects", mock.Anything, child.ID).Return([]model.Project{}, nil)
+	s.On("ArchiveProject", mock.Anything, pID, uID).Return(archived, nil)
+	s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Operation == "ArchiveProject" && e.EntityID == pID
+	})).Return(nil).Once()
+	s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Operation == "ArchiveProject" && e.EntityID == child.ID &&
+			strings.Contains(*e.Before, `"archived":false`) && strings.Contains(*e.After, `"archived":true`)
+	})).Return(nil).Once()
+
+	_, err := store.ArchiveProject(context.Background(), pID, uID)
+	assert.NoError(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestDeleteAchievement(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	a := model.Achievement{ID: aID, UserID: uID, ProjectID: pID, Start: 1600000000, End: 1600003600}
+	s.On("GetAchievement", mock.Anything, aID).Return(a, nil)
+	s.On("DeleteAchievement", mock.Anything, aID, pID).Return(nil)
+	s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Operation == "DeleteAchievement" && e.EntityType == storage.EntityAchievement &&
+			e.Actor == uID && e.Before != nil && e.After == nil
+	})).Return(nil)
+
+	assert.NoError(t, store.DeleteAchievement(context.Background(), aID, pID))
+	s.AssertExpectations(t)
+}
+
+func TestFailedChangeNotRecorded(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID}
+	s.On("CreateProject", mock.Anything, p).Return(errors.New("database error"))
+
+	assert.Error(t, store.CreateProject(context.Background(), p))
+	s.AssertNotCalled(t, "AppendAuditEntry", mock.Anything, mock.Anything)
+}
+
+func TestRecordFailureLogged(t *testing.T) {
+	var s mocks.Store
+	store, hook := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID}
+	s.On("CreateProject", mock.Anything, p).Return(nil)
+	s.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(errors.New("database error"))
+
+	assert.NoError(t, store.CreateProject(context.Background(), p))
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, "Unable to record audit entry", hook.LastEntry().Message)
+	}
+}
+
+func TestRestoreProject(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+
+	p := model.Project{ID: pID, UserID: uID, Name: "glow", CategoryID: cID}
+	s.On("RestoreProject", mock.Anything, pID, uID).Return(p, nil)
+	s.On("AppendAuditEntry", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Operation == "RestoreProject" && e.Before == nil && e.After != nil
+	})).Return(nil)
+
+	_, err := store.RestoreProject(context.Background(), pID, uID)
+	assert.NoError(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestTagAchievement(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+	ctx := context.Background()
+
+	tID := "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5"
+	tag := model.Tag{ID: tID, UserID: uID, Name: "meeting"}
+	s.On("GetTag", ctx, tID).Return(tag, nil)
+	s.On("GetAchievementTags", ctx, aID).Return([]model.Tag{}, nil).Once()
+	s.On("TagAchievement", ctx, aID, tID).Return(nil)
+	s.On("GetAchievementTags", ctx, aID).Return([]model.Tag{tag}, nil).Once()
+	s.On("AppendAuditEntry", ctx, model.AuditEntry{
+		Actor:      uID,
+		Operation:  "TagAchievement",
+		EntityType: storage.EntityAchievement,
+		EntityID:   aID,
+		Before:     str(`[]`),
+		After:      str(`[{"id":"` + tID + `","userID":"` + uID + `","name":"meeting","color":""}]`),
+		Timestamp:  1600000000,
+	}).Return(nil)
+
+	assert.NoError(t, store.TagAchievement(ctx, aID, tID))
+	s.AssertExpectations(t)
+}
+
+func TestTagAchievementFails(t *testing.T) {
+	var s mocks.Store
+	store, _ := newTestStore(&s)
+	ctx := context.Background()
+
+	tID := "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5"
+	s.On("GetTag", ctx, tID).Return(model.Tag{ID: tID, UserID: uID}, nil)
+	s.On("GetAchievementTags", ctx, aID).Return([]model.Tag{}, nil)
+	s.On("TagAchievement", ctx, aID, tID).Return(errors.New("database error"))
+
+	assert.Error(t, store.TagAchievement(ctx, aID, tID))
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "AppendAuditEntry", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,468 @@
+// Package backup dumps the data of some or all users of a store into a versioned JSON archive, and restores it
+// into any store, like to move a user between instances or to snapshot an instance independently of its database.
+//
+// Archives have every user's settings, categories, tags, projects, archived ones included, achievements,
+// with their notes and tags, and invoices. Deleted items, audit logs and export tokens are left out.
+// Goals aren't stored yet, so archives don't have them.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Version is the version of the archives written by Dump. It's increased whenever their format changes,
+// and older versions can still be restored.
+const Version = 1
+
+// Archive is a dump of the data of some users
+type Archive struct {
+	Version int `json:"version"`
+	// CreatedAt is the Unix timestamp of the dump
+	CreatedAt int    `json:"createdAt"`
+	Users     []User `json:"users"`
+}
+
+// User is the data of a user
+type User struct {
+	ID           string           `json:"id"`
+	Settings     Settings         `json:"settings"`
+	Categories   []model.Category `json:"categories"`
+	Tags         []model.Tag      `json:"tags"`
+	Projects     []Project        `json:"projects"`
+	Achievements []Achievement    `json:"achievements"`
+	// Invoices are sorted by number, which is given again to them when they're restored
+	Invoices []model.Invoice `json:"invoices"`
+}
+
+// Settings are the settings of a user, which apply to the projects that have none
+type Settings struct {
+	Rate     *model.Money    `json:"rate"`
+	Rounding *model.Rounding `json:"rounding"`
+}
+
+// Project is a project and its tags
+type Project struct {
+	model.Project
+	TagIDs []string `json:"tagIDs"`
+}
+
+// Achievement is an achievement and its tags
+type Achievement struct {
+	model.Achievement
+	TagIDs []string `json:"tagIDs"`
+}
+
+// Dump returns the archive of the users uIDs, or of every user of s if there are none
+func Dump(ctx context.Context, s storage.Store, uIDs []string, now time.Time) (*Archive, error) {
+	if len(uIDs) == 0 {
+		var err error
+		if uIDs, err = s.GetUsers(ctx); err != nil {
+			return nil, err
+		}
+	}
+	a := &Archive{Version: Version, CreatedAt: int(now.Unix()), Users: make([]User, 0, len(uIDs))}
+	for _, uID := range uIDs {
+		u, err := dumpUser(ctx, s, uID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to dump user %s: %v", uID, err)
+		}
+		a.Users = append(a.Users, u)
+	}
+	return a, nil
+}
+
+func dumpUser(ctx context.Context, s storage.Store, uID string) (User, error) {
+	u := User{ID: uID}
+	var err error
+	if u.Settings.Rate, err = s.GetUserRate(ctx, uID); err != nil {
+		return u, err
+	}
+	if u.Settings.Rounding, err = s.GetUserRounding(ctx, uID); err != nil {
+		return u, err
+	}
+	if u.Categories, err = s.GetUserCategories(ctx, uID); err != nil {
+		return u, err
+	}
+	if u.Tags, err = s.GetUserTags(ctx, uID); err != nil {
+		return u, err
+	}
+
+	ps, err := s.GetUserProjects(ctx, uID)
+	if err != nil {
+		return u, err
+	}
+	archived, err := s.GetArchivedProjects(ctx, uID)
+	if err != nil {
+		return u, err
+	}
+	ps = append(ps, archived...)
+	sort.Slice(ps, func(i, j int) bool { return ps[i].ID < ps[j].ID })
+	u.Projects = make([]Project, 0, len(ps))
+	u.Achievements = []Achievement{}
+	for _, p := range ps {
+		ts, err := s.GetProjectTags(ctx, p.ID)
+		if err != nil {
+			return u, err
+		}
+		u.Projects = append(u.Projects, Project{Project: p, TagIDs: tagIDs(ts)})
+
+		as, err := s.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return u, err
+		}
+		sort.Slice(as, func(i, j int) bool {
+			if as[i].Start != as[j].Start {
+				return as[i].Start < as[j].Start
+			}
+			return as[i].ID < as[j].ID
+		})
+		for _, a := range as {
+			ts, err := s.GetAchievementTags(ctx, a.ID)
+			if err != nil {
+				return u, err
+			}
+			u.Achievements = append(u.Achievements, Achievement{Achievement: a, TagIDs: tagIDs(ts)})
+		}
+	}
+
+	if u.Invoices, err = s.GetUserInvoices(ctx, uID); err != nil {
+		return u, err
+	}
+	return u, nil
+}
+
+func tagIDs(ts []model.Tag) []string {
+	ids := make([]string, len(ts))
+	for i, t := range ts {
+		ids[i] = t.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Write writes archive a to w as indented JSON
+func Write(w io.Writer, a *Archive) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// Read reads an archive from r. It fails if the archive is newer than this version of glow.
+func Read(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d, this version of glow restores up to %d", a.Version, Version)
+	}
+	return &a, nil
+}
+
+// Restore restores the users uIDs of archive a into s, or every user of a if there are none.
+// It fails if any of them already has data in s, so that nothing is overwritten nor duplicated,
+// and if the archive isn't consistent, before anything is restored. Only a failure of s itself
+// can leave users partially restored.
+//
+// Data is written to s as it is: restoring through the store of the server would record it
+// in the audit logs as if it were new, so the command writes to the database directly and
+// nothing is audited. Servers without RediSearch have to be restarted to search restored notes.
+func Restore(ctx context.Context, s storage.Store, a *Archive, uIDs []string) error {
+	users := a.Users
+	if len(uIDs) > 0 {
+		byID := make(map[string]User, len(a.Users))
+		for _, u := range a.Users {
+			byID[u.ID] = u
+		}
+		users = make([]User, 0, len(uIDs))
+		for _, uID := range uIDs {
+			u, ok := byID[uID]
+			if !ok {
+				return fmt.Errorf("user %s isn't in the archive", uID)
+			}
+			users = append(users, u)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, u := range users {
+		if err := validate(u, seen); err != nil {
+			return fmt.Errorf("invalid archive, user %s: %v", u.ID, err)
+		}
+	}
+	for _, u := range users {
+		if err := checkEmpty(ctx, s, u.ID); err != nil {
+			return err
+		}
+	}
+	for _, u := range users {
+		if err := restoreUser(ctx, s, u); err != nil {
+			return fmt.Errorf("unable to restore user %s: %v", u.ID, err)
+		}
+	}
+	return nil
+}
+
+// checkEmpty fails if user uID has any data in s
+func checkEmpty(ctx context.Context, s storage.Store, uID string) error {
+	ps, err := s.GetUserProjects(ctx, uID)
+	if err != nil {
+		return err
+	}
+	archived, err := s.GetArchivedProjects(ctx, uID)
+	if err != nil {
+		return err
+	}
+	cs, err := s.GetUserCategories(ctx, uID)
+	if err != nil {
+		return err
+	}
+	ts, err := s.GetUserTags(ctx, uID)
+	if err != nil {
+		return err
+	}
+	invs, err := s.GetUserInvoices(ctx, uID)
+	if err != nil {
+		return err
+	}
+	if len(ps)+len(archived)+len(cs)+len(ts)+len(invs) > 0 {
+		return fmt.Errorf("user %s already has data", uID)
+	}
+	return nil
+}
+
+// validate checks that the data of user u is consistent, so that it can be restored without errors.
+// seen has the IDs of the users validated before, which can't be repeated either.
+func validate(u User, seen map[string]bool) error {
+	add := func(kind, id, uID string) error {
+		if seen[id] {
+			return fmt.Errorf("%s %s is repeated", kind, id)
+		}
+		seen[id] = true
+		if uID != u.ID {
+			return fmt.Errorf("%s %s belongs to user %s", kind, id, uID)
+		}
+		return nil
+	}
+
+	categories := make(map[string]bool, len(u.Categories))
+	for _, c := range u.Categories {
+		if err := add("category", c.ID, c.UserID); err != nil {
+			return err
+		}
+		categories[c.ID] = true
+	}
+	tags := make(map[string]bool, len(u.Tags))
+	for _, t := range u.Tags {
+		if err := add("tag", t.ID, t.UserID); err != nil {
+			return err
+		}
+		tags[t.ID] = true
+	}
+	checkTags := func(kind, id string, tIDs []string) error {
+		for _, tID := range tIDs {
+			if !tags[tID] {
+				return fmt.Errorf("%s %s has unknown tag %s", kind, id, tID)
+			}
+		}
+		return nil
+	}
+
+	projects := make(map[string]Project, len(u.Projects))
+	for _, p := range u.Projects {
+		if err := add("project", p.ID, p.UserID); err != nil {
+			return err
+		}
+		projects[p.ID] = p
+	}
+	for _, p := range u.Projects {
+		if !categories[p.CategoryID] {
+			return fmt.Errorf("project %s has unknown category %s", p.ID, p.CategoryID)
+		}
+		if p.ParentID != nil {
+			parent, ok := projects[*p.ParentID]
+			if !ok {
+				return fmt.Errorf("project %s has unknown parent %s", p.ID, *p.ParentID)
+			}
+			// Archiving a project archives its subprojects too
+			if parent.Archived && !p.Archived {
+				return fmt.Errorf("project %s is active but its parent %s is archived", p.ID, parent.ID)
+			}
+		}
+		if err := checkTags("project", p.ID, p.TagIDs); err != nil {
+			return err
+		}
+	}
+	if len(parentsFirst(u.Projects)) != len(u.Projects) {
+		return fmt.Errorf("projects are their own ancestors")
+	}
+
+	// Achievements are locked by the invoices that list them
+	locked := make(map[string]string)
+	numbers := make(map[int]bool, len(u.Invoices))
+	for _, inv := range u.Invoices {
+		if err := add("invoice", inv.ID, inv.UserID); err != nil {
+			return err
+		}
+		numbers[inv.Number] = true
+		for _, aID := range inv.AchievementIDs {
+			if iID, ok := locked[aID]; ok {
+				return fmt.Errorf("achievement %s is listed by invoices %s and %s", aID, iID, inv.ID)
+			}
+			locked[aID] = inv.ID
+		}
+	}
+	// Invoices are numbered again when they're restored
+	for n := 1; n <= len(u.Invoices); n++ {
+		if !numbers[n] {
+			return fmt.Errorf("invoice number %d is missing", n)
+		}
+	}
+
+	for _, a := range u.Achievements {
+		if err := add("achievement", a.ID, a.UserID); err != nil {
+			return err
+		}
+		if _, ok := projects[a.ProjectID]; !ok {
+			return fmt.Errorf("achievement %s has unknown project %s", a.ID, a.ProjectID)
+		}
+		if err := checkTags("achievement", a.ID, a.TagIDs); err != nil {
+			return err
+		}
+		iID, ok := locked[a.ID]
+		if a.InvoiceID == nil && ok {
+			return fmt.Errorf("achievement %s is listed by invoice %s but it isn't locked", a.ID, iID)
+		}
+		if a.InvoiceID != nil && *a.InvoiceID != iID {
+			return fmt.Errorf("achievement %s isn't listed by its invoice %s", a.ID, *a.InvoiceID)
+		}
+		delete(locked, a.ID)
+	}
+	for _, inv := range u.Invoices {
+		for _, aID := range inv.AchievementIDs {
+			if _, ok := locked[aID]; ok {
+				return fmt.Errorf("invoice %s has unknown achievement %s", inv.ID, aID)
+			}
+		}
+	}
+	return nil
+}
+
+func restoreUser(ctx context.Context, s storage.Store, u User) error {
+	if u.Settings.Rate != nil {
+		if err := s.SetUserRate(ctx, u.ID, u.Settings.Rate); err != nil {
+			return err
+		}
+	}
+	if u.Settings.Rounding != nil {
+		if err := s.SetUserRounding(ctx, u.ID, u.Settings.Rounding); err != nil {
+			return err
+		}
+	}
+	for _, c := range u.Categories {
+		if err := s.CreateCategory(ctx, c); err != nil {
+			return err
+		}
+	}
+	for _, t := range u.Tags {
+		if err := s.CreateTag(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	// Projects are created active and parents first, and then archived
+	ps := parentsFirst(u.Projects)
+	for _, p := range ps {
+		np := p.Project
+		np.Archived = false
+		if err := s.CreateProject(ctx, np); err != nil {
+			return err
+		}
+		for _, tID := range p.TagIDs {
+			if err := s.TagProject(ctx, p.ID, tID); err != nil {
+				return err
+			}
+		}
+	}
+	archived := make(map[string]bool)
+	for _, p := range ps {
+		if !p.Archived {
+			continue
+		}
+		archived[p.ID] = true
+		// Archiving a project archives its subprojects too
+		if p.ParentID != nil && archived[*p.ParentID] {
+			continue
+		}
+		if _, err := s.ArchiveProject(ctx, p.ID, u.ID); err != nil {
+			return err
+		}
+	}
+
+	// Achievements are locked by their invoices when these are restored
+	for _, a := range u.Achievements {
+		na := a.Achievement
+		na.Note, na.Billable, na.InvoiceID = "", nil, nil
+		if err := s.CreateAchievement(ctx, na); err != nil {
+			return err
+		}
+		if a.Note != "" {
+			if err := s.SetAchievementNote(ctx, a.ID, a.Note); err != nil {
+				return err
+			}
+		}
+		if a.Billable != nil {
+			if err := s.SetAchievementBillable(ctx, a.ID, a.Billable); err != nil {
+				return err
+			}
+		}
+		for _, tID := range a.TagIDs {
+			if err := s.TagAchievement(ctx, a.ID, tID); err != nil {
+				return err
+			}
+		}
+	}
+
+	invs := append([]model.Invoice(nil), u.Invoices...)
+	sort.Slice(invs, func(i, j int) bool { return invs[i].Number < invs[j].Number })
+	for _, inv := range invs {
+		created, err := s.CreateInvoice(ctx, inv)
+		if err != nil {
+			return err
+		}
+		if created.Number != inv.Number {
+			return fmt.Errorf("invoice %s was numbered %d instead of %d", inv.ID, created.Number, inv.Number)
+		}
+	}
+	return nil
+}
+
+// parentsFirst sorts projects so that every project comes after its parent
+func parentsFirst(projects []Project) []Project {
+	children := make(map[string][]Project)
+	byID := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		byID[p.ID] = true
+	}
+	var sorted []Project
+	for _, p := range projects {
+		// Projects whose parent isn't among them go first, and the ones in a cycle are left out
+		if p.ParentID == nil || !byID[*p.ParentID] {
+			sorted = append(sorted, p)
+		} else {
+			children[*p.ParentID] = append(children[*p.ParentID], p)
+		}
+	}
+	for i := 0; i < len(sorted); i++ {
+		sorted = append(sorted, children[sorted[i].ID]...)
+	}
+	return sorted
+}
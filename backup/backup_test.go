@@ -0,0 +1,225 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Unix(1601510400, 0)
+
+func newTestStore(t *testing.T) storage.Store {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	pool := storage.NewPool(mr.Addr(), "", 0, 10)
+	t.Cleanup(func() { pool.Close() })
+
+	logger, _ := test.NewNullLogger()
+	return storage.NewRedisStore(pool, logger)
+}
+
+// fill creates the data of user uID: settings, a category, two tags, the project Client X with
+// the archived subproject Website, tagged achievements with notes, and an invoice
+func fill(t *testing.T, s storage.Store, uID string) {
+	ctx := context.Background()
+	prefix := "3b054f50-9d3d-4114-bfc4-00000000000" + uID
+	require.NoError(t, s.SetUserRate(ctx, uID, &model.Money{Amount: 6000, Currency: "EUR"}))
+	require.NoError(t, s.SetUserRounding(ctx, uID,
+		&model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry}))
+
+	work := model.Category{ID: prefix + "c", UserID: uID, Name: "Work", Color: "#ff0000", Icon: "briefcase", Order: 2}
+	require.NoError(t, s.CreateCategory(ctx, work))
+	urgent := model.Tag{ID: prefix + "t1", UserID: uID, Name: "urgent", Color: "#00ff00"}
+	meeting := model.Tag{ID: prefix + "t2", UserID: uID, Name: "meeting"}
+	require.NoError(t, s.CreateTag(ctx, urgent))
+	require.NoError(t, s.CreateTag(ctx, meeting))
+
+	x := model.Project{ID: prefix + "p1", UserID: uID, Name: "Client X", CategoryID: work.ID, Billable: true,
+		Rate: &model.Money{Amount: 9000, Currency: "EUR"}}
+	website := model.Project{ID: prefix + "p2", UserID: uID, Name: "Website", CategoryID: work.ID, ParentID: &x.ID,
+		Rounding: &model.Rounding{Increment: 30, Direction: model.RoundingDirectionNearest, Scope: model.RoundingScopeDay}}
+	require.NoError(t, s.CreateProject(ctx, x))
+	require.NoError(t, s.CreateProject(ctx, website))
+	require.NoError(t, s.TagProject(ctx, x.ID, urgent.ID))
+
+	as := []model.Achievement{
+		{ID: prefix + "a1", UserID: uID, ProjectID: x.ID, Start: 1598918400, End: 1598922000},
+		{ID: prefix + "a2", UserID: uID, ProjectID: x.ID, Start: 1598925600, End: 1598929200},
+		{ID: prefix + "a3", UserID: uID, ProjectID: website.ID, Start: 1599004800, End: 1599006600},
+		// Still running
+		{ID: prefix + "a4", UserID: uID, ProjectID: x.ID, Start: 1601506800},
+	}
+	for _, a := range as {
+		require.NoError(t, s.CreateAchievement(ctx, a))
+	}
+	require.NoError(t, s.SetAchievementNote(ctx, as[0].ID, "# Standup\n\nWith *everyone*"))
+	no := false
+	require.NoError(t, s.SetAchievementBillable(ctx, as[1].ID, &no))
+	require.NoError(t, s.TagAchievement(ctx, as[0].ID, urgent.ID))
+	require.NoError(t, s.TagAchievement(ctx, as[0].ID, meeting.ID))
+
+	_, err := s.CreateInvoice(ctx, model.Invoice{ID: prefix + "i1", UserID: uID, From: 1598918400, To: 1601510400,
+		CreatedAt: 1601510400, Currency: "EUR", Total: 9000,
+		Lines:          []*model.InvoiceLine{{Description: "Client X", Seconds: 3600, Amount: 9000}},
+		AchievementIDs: []string{as[0].ID}})
+	require.NoError(t, err)
+	_, err = s.ArchiveProject(ctx, website.ID, uID)
+	require.NoError(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newTestStore(t)
+	fill(t, src, "1")
+	fill(t, src, "2")
+
+	a, err := Dump(ctx, src, nil, now)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, a))
+
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	dst := newTestStore(t)
+	require.NoError(t, Restore(ctx, dst, read, nil))
+
+	restored, err := Dump(ctx, dst, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, a, restored)
+
+	// Everything was dumped
+	require.Len(t, a.Users, 2)
+	u := a.Users[0]
+	assert.Equal(t, "1", u.ID)
+	assert.Equal(t, &model.Money{Amount: 6000, Currency: "EUR"}, u.Settings.Rate)
+	assert.NotNil(t, u.Settings.Rounding)
+	assert.Len(t, u.Categories, 1)
+	assert.Len(t, u.Tags, 2)
+	require.Len(t, u.Projects, 2)
+	assert.Equal(t, []string{u.Tags[1].ID}, u.Projects[0].TagIDs)
+	assert.True(t, u.Projects[1].Archived)
+	require.Len(t, u.Achievements, 4)
+	assert.Equal(t, "# Standup\n\nWith *everyone*", u.Achievements[0].Note)
+	assert.Len(t, u.Achievements[0].TagIDs, 2)
+	assert.Equal(t, &u.Invoices[0].ID, u.Achievements[0].InvoiceID)
+	assert.NotNil(t, u.Achievements[1].Billable)
+	assert.Equal(t, 0, u.Achievements[2].End)
+	require.Len(t, u.Invoices, 1)
+	assert.Equal(t, 1, u.Invoices[0].Number)
+}
+
+func TestRestoreUser(t *testing.T) {
+	ctx := context.Background()
+	src := newTestStore(t)
+	fill(t, src, "1")
+	fill(t, src, "2")
+	a, err := Dump(ctx, src, nil, now)
+	require.NoError(t, err)
+
+	dst := newTestStore(t)
+	require.NoError(t, Restore(ctx, dst, a, []string{"2"}))
+
+	users, err := dst.GetUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, users)
+	restored, err := Dump(ctx, dst, []string{"2"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, a.Users[1:], restored.Users)
+
+	assert.EqualError(t, Restore(ctx, dst, a, []string{"3"}), "user 3 isn't in the archive")
+	// Nothing is overwritten
+	assert.EqualError(t, Restore(ctx, dst, a, nil), "user 2 already has data")
+	users, err = dst.GetUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, users)
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	ctx := context.Background()
+	src := newTestStore(t)
+	fill(t, src, "1")
+	fill(t, src, "2")
+
+	tests := []struct {
+		name   string
+		modify func(u *User)
+		err    string
+	}{
+		{"unknown category", func(u *User) { u.Projects[0].CategoryID = "c" },
+			"project 3b054f50-9d3d-4114-bfc4-000000000002p1 has unknown category c"},
+		{"unknown parent", func(u *User) { p := "p"; u.Projects[1].ParentID = &p },
+			"project 3b054f50-9d3d-4114-bfc4-000000000002p2 has unknown parent p"},
+		{"cycle", func(u *User) { u.Projects[0].ParentID, u.Projects[1].Archived = &u.Projects[1].ID, false },
+			"projects are their own ancestors"},
+		{"active subproject", func(u *User) { u.Projects[0].Archived = true; u.Projects[1].Archived = false },
+			"project 3b054f50-9d3d-4114-bfc4-000000000002p2 is active but its parent 3b054f50-9d3d-4114-bfc4-000000000002p1 is archived"},
+		{"unknown tag", func(u *User) { u.Achievements[0].TagIDs = []string{"t"} },
+			"achievement 3b054f50-9d3d-4114-bfc4-000000000002a1 has unknown tag t"},
+		{"unknown project", func(u *User) { u.Achievements[0].ProjectID = "p" },
+			"achievement 3b054f50-9d3d-4114-bfc4-000000000002a1 has unknown project p"},
+		{"invoice numbers", func(u *User) { u.Invoices[0].Number = 2 }, "invoice number 1 is missing"},
+		{"unlocked achievement", func(u *User) { u.Achievements[0].InvoiceID = nil },
+			"achievement 3b054f50-9d3d-4114-bfc4-000000000002a1 is listed by invoice 3b054f50-9d3d-4114-bfc4-000000000002i1 but it isn't locked"},
+		{"unknown achievement", func(u *User) { u.Invoices[0].AchievementIDs = append(u.Invoices[0].AchievementIDs, "a") },
+			"invoice 3b054f50-9d3d-4114-bfc4-000000000002i1 has unknown achievement a"},
+		{"other user", func(u *User) { u.Tags[0].UserID = "1" },
+			"tag 3b054f50-9d3d-4114-bfc4-000000000002t2 belongs to user 1"},
+		{"repeated", func(u *User) { u.Categories[0].ID = "3b054f50-9d3d-4114-bfc4-000000000001c" },
+			"category 3b054f50-9d3d-4114-bfc4-000000000001c is repeated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Dump(ctx, src, nil, now)
+			require.NoError(t, err)
+			tt.modify(&a.Users[1])
+
+			dst := newTestStore(t)
+			assert.EqualError(t, Restore(ctx, dst, a, nil), "invalid archive, user 2: "+tt.err)
+
+			// Nothing was restored, not even the valid user
+			users, err := dst.GetUsers(ctx)
+			assert.NoError(t, err)
+			assert.Empty(t, users)
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	a, err := Read(strings.NewReader(`{"version":1,"createdAt":1601510400,"users":[{"id":"0"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Archive{Version: 1, CreatedAt: 1601510400, Users: []User{{ID: "0"}}}, a)
+
+	_, err = Read(strings.NewReader(`{"version":2,"users":[]}`))
+	assert.EqualError(t, err, "unsupported archive version 2, this version of glow restores up to 1")
+	_, err = Read(strings.NewReader(`{"users":[]}`))
+	assert.EqualError(t, err, "unsupported archive version 0, this version of glow restores up to 1")
+	_, err = Read(strings.NewReader(`users`))
+	assert.Error(t, err)
+}
+
+func TestParentsFirst(t *testing.T) {
+	a, b, c := "a", "b", "c"
+	ps := []Project{
+		{Project: model.Project{ID: c, ParentID: &b}},
+		{Project: model.Project{ID: b, ParentID: &a}},
+		{Project: model.Project{ID: a}},
+		{Project: model.Project{ID: "d", ParentID: &c}},
+	}
+
+	var ids []string
+	for _, p := range parentsFirst(ps) {
+		ids = append(ids, p.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+}
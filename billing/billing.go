@@ -0,0 +1,170 @@
+// Package billing computes what the time spent on projects is worth.
+// Money is handled in integer minor units of its currency, e.g. cents, and never as floats.
+package billing
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/smeruelo/glow/graph/model"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidateRate checks that rate is a non-negative amount of an ISO 4217 currency code, like EUR
+func ValidateRate(rate model.Money) error {
+	if rate.Amount < 0 {
+		return fmt.Errorf("rate can't be negative")
+	}
+	if !currencyCode.MatchString(rate.Currency) {
+		return fmt.Errorf("%q is not a currency code", rate.Currency)
+	}
+	return nil
+}
+
+// Earnings returns what seconds of work are worth at an hourly rate, both amounts in minor units,
+// rounded half up to the nearest minor unit
+func Earnings(seconds, hourly int) int {
+	return (seconds*hourly + 1800) / 3600
+}
+
+// Ledger adds up billable seconds per hourly rate. They are converted to money all at once,
+// so that rounding happens once per rate instead of once per achievement.
+type Ledger map[model.Money]int
+
+// Add books seconds of work at rate. Work without a rate isn't worth anything.
+func (l Ledger) Add(rate *model.Money, seconds int) {
+	if rate == nil || seconds <= 0 {
+		return
+	}
+	l[*rate] += seconds
+}
+
+// Earnings returns the total earned in each currency, sorted by currency
+func (l Ledger) Earnings() []*model.Money {
+	totals := map[string]int{}
+	for rate, seconds := range l {
+		totals[rate.Currency] += Earnings(seconds, rate.Amount)
+	}
+
+	earnings := make([]*model.Money, 0, len(totals))
+	for currency, amount := range totals {
+		earnings = append(earnings, &model.Money{Amount: amount, Currency: currency})
+	}
+	sort.Slice(earnings, func(i, j int) bool { return earnings[i].Currency < earnings[j].Currency })
+	return earnings
+}
+
+// RateFromData returns the rate set by d, nil if there's none
+func RateFromData(d *model.MoneyData) *model.Money {
+	if d == nil {
+		return nil
+	}
+	return &model.Money{Amount: d.Amount, Currency: d.Currency}
+}
+
+// Billable returns whether achievement a of project p is billable
+func Billable(a model.Achievement, p model.Project) bool {
+	if a.Billable != nil {
+		return *a.Billable
+	}
+	return p.Billable
+}
+
+// Projects is where terms are looked up, usually a storage.Store
+type Projects interface {
+	GetProject(ctx context.Context, pID string) (model.Project, error)
+	GetUserRate(ctx context.Context, uID string) (*model.Money, error)
+	GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error)
+}
+
+// Terms finds the hourly rates and rounding rules of a user's projects. Projects without their own
+// use their closest ancestor's, or else the user's.
+type Terms struct {
+	projects Projects
+	uID      string
+	parents  map[string]model.Project
+
+	// The user's terms are loaded on demand
+	userRate       *model.Money
+	rateLoaded     bool
+	userRounding   *model.Rounding
+	roundingLoaded bool
+}
+
+// NewTerms returns the Terms of the projects of user uID, looked up in ps
+func NewTerms(ps Projects, uID string) *Terms {
+	return &Terms{projects: ps, uID: uID, parents: map[string]model.Project{}}
+}
+
+// closest returns the closest of p and its ancestors that has, and whether there's any
+func (t *Terms) closest(ctx context.Context, p model.Project, has func(model.Project) bool) (model.Project, bool, error) {
+	for !has(p) {
+		if p.ParentID == nil {
+			return p, false, nil
+		}
+		parent, ok := t.parents[*p.ParentID]
+		if !ok {
+			var err error
+			if parent, err = t.projects.GetProject(ctx, *p.ParentID); err != nil {
+				return p, false, err
+			}
+			t.parents[parent.ID] = parent
+		}
+		p = parent
+	}
+	return p, true, nil
+}
+
+// RateOf returns the hourly rate of project p, nil if it has none
+func (t *Terms) RateOf(ctx context.Context, p model.Project) (*model.Money, error) {
+	a, ok, err := t.closest(ctx, p, func(p model.Project) bool { return p.Rate != nil })
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return a.Rate, nil
+	}
+	if !t.rateLoaded {
+		if t.userRate, err = t.projects.GetUserRate(ctx, t.uID); err != nil {
+			return nil, err
+		}
+		t.rateLoaded = true
+	}
+	return t.userRate, nil
+}
+
+// RoundingOf returns the rounding rule of project p, nil if its time isn't rounded
+func (t *Terms) RoundingOf(ctx context.Context, p model.Project) (*model.Rounding, error) {
+	a, ok, err := t.closest(ctx, p, func(p model.Project) bool { return p.Rounding != nil })
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return a.Rounding, nil
+	}
+	if !t.roundingLoaded {
+		if t.userRounding, err = t.projects.GetUserRounding(ctx, t.uID); err != nil {
+			return nil, err
+		}
+		t.roundingLoaded = true
+	}
+	return t.userRounding, nil
+}
+
+// zeroDecimal are the currencies without minor units
+var zeroDecimal = map[string]bool{"CLP": true, "ISK": true, "JPY": true, "KRW": true, "VND": true}
+
+// Format returns m in major units of its currency, like 1234.50 EUR
+func Format(m model.Money) string {
+	if zeroDecimal[m.Currency] {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.Currency)
+}
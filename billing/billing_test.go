@@ -0,0 +1,76 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		seconds  int
+		hourly   int
+		expected int
+	}{
+		{"one hour", 3600, 5000, 5000},
+		{"nothing", 0, 5000, 0},
+		{"free", 3600, 0, 0},
+		{"exact cents", 90, 4000, 100},
+		// 1 second at 50.00/h is 1.3888... cents
+		{"rounds down", 1, 5000, 1},
+		// 1 second at 54.00/h is exactly 1.5 cents
+		{"rounds half up", 1, 5400, 2},
+		// 1 second at 17.99/h is 0.4997... cents
+		{"rounds to zero", 1, 1799, 0},
+		{"big amounts don't overflow", 1000 * 3600, 100000000, 100000000000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Earnings(test.seconds, test.hourly))
+		})
+	}
+}
+
+func TestLedgerRoundsOncePerRate(t *testing.T) {
+	eur := &model.Money{Amount: 5400, Currency: "EUR"}
+	l := Ledger{}
+
+	// Rounding each second on its own would add up to 3 cents instead of 4.5
+	l.Add(eur, 1)
+	l.Add(eur, 1)
+	l.Add(eur, 1)
+
+	assert.Equal(t, []*model.Money{{Amount: 5, Currency: "EUR"}}, l.Earnings())
+}
+
+func TestLedgerEarningsPerCurrency(t *testing.T) {
+	l := Ledger{}
+	l.Add(&model.Money{Amount: 6000, Currency: "USD"}, 1800)
+	l.Add(&model.Money{Amount: 5000, Currency: "EUR"}, 3600)
+	l.Add(&model.Money{Amount: 3000, Currency: "EUR"}, 1800)
+	l.Add(nil, 3600)
+	l.Add(&model.Money{Amount: 3000, Currency: "GBP"}, 0)
+
+	assert.Equal(t, []*model.Money{
+		{Amount: 6500, Currency: "EUR"},
+		{Amount: 3000, Currency: "USD"},
+	}, l.Earnings())
+}
+
+func TestValidateRate(t *testing.T) {
+	assert.NoError(t, ValidateRate(model.Money{Amount: 5000, Currency: "EUR"}))
+	assert.NoError(t, ValidateRate(model.Money{Amount: 0, Currency: "JPY"}))
+	assert.EqualError(t, ValidateRate(model.Money{Amount: -1, Currency: "EUR"}), "rate can't be negative")
+	assert.EqualError(t, ValidateRate(model.Money{Amount: 5000, Currency: "eur"}), `"eur" is not a currency code`)
+	assert.Error(t, ValidateRate(model.Money{Amount: 5000}))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1234.50 EUR", Format(model.Money{Amount: 123450, Currency: "EUR"}))
+	assert.Equal(t, "0.05 USD", Format(model.Money{Amount: 5, Currency: "USD"}))
+	assert.Equal(t, "-0.05 USD", Format(model.Money{Amount: -5, Currency: "USD"}))
+	assert.Equal(t, "1500 JPY", Format(model.Money{Amount: 1500, Currency: "JPY"}))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/smeruelo/glow/backup"
+	"github.com/smeruelo/glow/config"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/storage"
+)
+
+// commands are run by glow instead of the server when their name is the first argument,
+// followed by their flags and the server's
+var commands = map[string]func(args []string) error{
+	"dump":    dump,
+	"restore": restore,
+}
+
+// dump writes the archive of some or all users
+func dump(args []string) error {
+	fs := flag.NewFlagSet("glow dump", flag.ContinueOnError)
+	users := fs.String("users", "", "comma separated IDs of the users to dump, all of them if empty")
+	file := fs.String("file", "-", "archive to write, - for the standard output")
+	cfg, err := config.LoadFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	store, closeStore, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	a, err := backup.Dump(context.Background(), store, splitIDs(*users), time.Now())
+	if err != nil {
+		return err
+	}
+	if *file == "-" {
+		return backup.Write(os.Stdout, a)
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err := backup.Write(f, a); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// restore restores some or all users of an archive.
+// The archive is written to the database directly, so it isn't recorded in the audit logs.
+func restore(args []string) error {
+	fs := flag.NewFlagSet("glow restore", flag.ContinueOnError)
+	users := fs.String("users", "", "comma separated IDs of the users to restore, all of them if empty")
+	file := fs.String("file", "-", "archive to read, - for the standard input")
+	cfg, err := config.LoadFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		if in, err = os.Open(*file); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	a, err := backup.Read(in)
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	return backup.Restore(context.Background(), store, a, splitIDs(*users))
+}
+
+// openStore connects to the database of cfg. Logs go to the standard error, which leaves the standard output
+// for archives.
+func openStore(cfg config.Config) (storage.Store, func(), error) {
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid log settings: %s", err)
+	}
+	r := cfg.Storage.Redis
+	db := storage.NewPool(r.Address(), r.Password, r.DB, r.PoolSize)
+	if err := storage.Migrate(context.Background(), db, logger); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("unable to migrate database: %s", err)
+	}
+	return storage.NewRedisStore(db, logger), func() { db.Close() }, nil
+}
+
+// splitIDs splits a comma separated list of IDs
+func splitIDs(list string) []string {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,629 @@
+// Package config loads the server configuration.
+//
+// Every setting can be given, from lowest to highest precedence, by its default value,
+// an optional YAML file (-config flag or CONFIG_FILE environment variable),
+// an environment variable or a command line flag.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smeruelo/glow/logging"
+	"gopkg.in/yaml.v2"
+)
+
+// Config contains the settings needed to run the server
+type Config struct {
+	Listen        string     `yaml:"listen"`
+	DrainTimeout  Duration   `yaml:"drainTimeout"`
+	ShutdownDelay Duration   `yaml:"shutdownDelay"`
+	TLS           TLS        `yaml:"tls"`
+	Playground    Playground `yaml:"playground"`
+	Storage       Storage    `yaml:"storage"`
+	Query         Query      `yaml:"query"`
+	Log           Log        `yaml:"log"`
+	Tracing       Tracing    `yaml:"tracing"`
+	CORS          CORS       `yaml:"cors"`
+	RateLimit     RateLimit  `yaml:"rateLimit"`
+	Trash         Trash      `yaml:"trash"`
+}
+
+// TLS contains the certificate and key used to serve HTTPS.
+// When both are empty the server uses plain HTTP.
+type TLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// Enabled reports whether the server must use HTTPS
+func (t TLS) Enabled() bool {
+	return t.Cert != "" || t.Key != ""
+}
+
+// Playground contains the settings of the GraphQL playground
+type Playground struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// Storage contains the settings of the storage backend
+type Storage struct {
+	Backend string `yaml:"backend"`
+	Redis   Redis  `yaml:"redis"`
+}
+
+// Redis contains the settings needed to connect to a Redis server
+type Redis struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	// PoolSize is the maximum number of connections open at once
+	PoolSize int `yaml:"poolSize"`
+}
+
+// Address returns the host:port address of the Redis server
+func (r Redis) Address() string {
+	return fmt.Sprintf("%s:%d", r.Host, r.Port)
+}
+
+// Query contains the limits applied to incoming GraphQL operations
+type Query struct {
+	MaxDepth      int      `yaml:"maxDepth"`
+	MaxComplexity int      `yaml:"maxComplexity"`
+	APQTTL        Duration `yaml:"apqTTL"`
+	Strict        bool     `yaml:"strict"`
+}
+
+// Log contains the settings of the logger
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Tracing contains the settings of the OpenTelemetry traces exporter
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// CORS contains the policy applied to cross-origin requests to /query
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allowedOrigins"`
+	AllowedMethods   []string `yaml:"allowedMethods"`
+	AllowedHeaders   []string `yaml:"allowedHeaders"`
+	ExposedHeaders   []string `yaml:"exposedHeaders"`
+	AllowCredentials bool     `yaml:"allowCredentials"`
+	MaxAge           Duration `yaml:"maxAge"`
+}
+
+// RateLimit contains the limits applied to the operations of every client.
+// Operations selecting a field listed in Fields are limited by the field's rate too.
+type RateLimit struct {
+	Enabled bool       `yaml:"enabled"`
+	Backend string     `yaml:"backend"`
+	Default Rate       `yaml:"default"`
+	Fields  FieldRates `yaml:"fields"`
+	// TrustedProxies is the number of proxies in front of the server, which add clients' addresses
+	// to the X-Forwarded-For header
+	TrustedProxies int `yaml:"trustedProxies"`
+}
+
+// Trash contains how long deleted projects and achievements are kept before being purged
+type Trash struct {
+	Retention     Duration `yaml:"retention"`
+	PurgeInterval Duration `yaml:"purgeInterval"`
+}
+
+// FieldRates are the rates of root fields, indexed by field name.
+// When given, they replace the default ones instead of being merged with them.
+type FieldRates map[string]Rate
+
+// UnmarshalYAML parses the rates into a new map
+func (f *FieldRates) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	m := map[string]Rate{}
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	*f = m
+	return nil
+}
+
+// Rate is a number of requests allowed per period, written as "30/1m" in the configuration file
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// UnmarshalYAML parses a rate string
+func (r *Rate) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := parseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+func (r Rate) valid() bool {
+	return r.Limit > 0 && r.Period > 0
+}
+
+func parseRate(s string) (Rate, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+	return Rate{Limit: limit, Period: period}, nil
+}
+
+// Duration is a time.Duration that can be written as "24h" in the configuration file
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Supported storage backends
+const (
+	BackendRedis = "redis"
+)
+
+// Supported rate limiter backends
+const (
+	LimiterMemory = "memory"
+	LimiterRedis  = "redis"
+)
+
+// Supported traces exporters
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Default returns the configuration used when no setting is given
+func Default() Config {
+	return Config{
+		Listen:       ":80",
+		DrainTimeout: Duration(15 * time.Second),
+		Playground: Playground{
+			Enabled: true,
+			Path:    "/",
+		},
+		Storage: Storage{
+			Backend: BackendRedis,
+			Redis: Redis{
+				Port:     6379,
+				PoolSize: 10,
+			},
+		},
+		Query: Query{
+			MaxDepth:      10,
+			MaxComplexity: 500,
+			APQTTL:        Duration(24 * time.Hour),
+		},
+		Log: Log{
+			Level:  "info",
+			Format: logging.FormatText,
+		},
+		Tracing: Tracing{
+			Exporter:    ExporterNone,
+			Endpoint:    "localhost:55680",
+			SampleRatio: 1,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST"},
+			AllowedHeaders: []string{"Content-Type", "X-Request-Id", "Traceparent"},
+			// So that picard can read the request ID and how long it's throttled
+			ExposedHeaders: []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+			MaxAge:         Duration(10 * time.Minute),
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Backend: LimiterMemory,
+			Default: Rate{Limit: 300, Period: time.Minute},
+			Fields: FieldRates{
+				"createProject":     {Limit: 30, Period: time.Minute},
+				"createAchievement": {Limit: 30, Period: time.Minute},
+			},
+		},
+		Trash: Trash{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+	}
+}
+
+// setting is a configuration value that can be given by an environment variable or a flag
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"listen", "LISTEN_ADDR", "address to listen on", func(c *Config, v string) error {
+		c.Listen = v
+		return nil
+	}},
+	{"drain-timeout", "DRAIN_TIMEOUT", "time given to in-flight requests to complete on shutdown", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.DrainTimeout = Duration(d)
+		return err
+	}},
+	{"shutdown-delay", "SHUTDOWN_DELAY", "time requests are still served on shutdown after readiness fails", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.ShutdownDelay = Duration(d)
+		return err
+	}},
+	{"tls-cert", "TLS_CERT", "TLS certificate file", func(c *Config, v string) error {
+		c.TLS.Cert = v
+		return nil
+	}},
+	{"tls-key", "TLS_KEY", "TLS key file", func(c *Config, v string) error {
+		c.TLS.Key = v
+		return nil
+	}},
+	{"playground", "PLAYGROUND", "serve the GraphQL playground", func(c *Config, v string) error {
+		return setBool(&c.Playground.Enabled, v)
+	}},
+	{"playground-path", "PLAYGROUND_PATH", "path of the GraphQL playground", func(c *Config, v string) error {
+		c.Playground.Path = v
+		return nil
+	}},
+	{"storage", "STORAGE_BACKEND", "storage backend", func(c *Config, v string) error {
+		c.Storage.Backend = v
+		return nil
+	}},
+	{"db-host", "DB_HOST", "Redis host", func(c *Config, v string) error {
+		c.Storage.Redis.Host = v
+		return nil
+	}},
+	{"db-port", "DB_PORT", "Redis port", func(c *Config, v string) error {
+		return setInt(&c.Storage.Redis.Port, v)
+	}},
+	{"db-password", "DB_PASSWORD", "Redis password", func(c *Config, v string) error {
+		c.Storage.Redis.Password = v
+		return nil
+	}},
+	{"db-index", "DB_INDEX", "Redis database index", func(c *Config, v string) error {
+		return setInt(&c.Storage.Redis.DB, v)
+	}},
+	{"db-pool-size", "DB_POOL_SIZE", "maximum number of Redis connections", func(c *Config, v string) error {
+		return setInt(&c.Storage.Redis.PoolSize, v)
+	}},
+	{"max-query-depth", "MAX_QUERY_DEPTH", "maximum depth of a GraphQL operation", func(c *Config, v string) error {
+		return setInt(&c.Query.MaxDepth, v)
+	}},
+	{"max-query-complexity", "MAX_QUERY_COMPLEXITY", "maximum complexity of a GraphQL operation", func(c *Config, v string) error {
+		return setInt(&c.Query.MaxComplexity, v)
+	}},
+	{"apq-ttl", "APQ_TTL", "time to live of automatic persisted queries", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.Query.APQTTL = Duration(d)
+		return err
+	}},
+	{"log-level", "LOG_LEVEL", "log level: trace, debug, info, warning, error, fatal or panic", func(c *Config, v string) error {
+		c.Log.Level = v
+		return nil
+	}},
+	{"log-format", "LOG_FORMAT", "log format: text or json", func(c *Config, v string) error {
+		c.Log.Format = v
+		return nil
+	}},
+	{"strict", "STRICT_OPERATIONS", "only accept operations registered at build time", func(c *Config, v string) error {
+		return setBool(&c.Query.Strict, v)
+	}},
+	{"tracing-exporter", "TRACING_EXPORTER", "traces exporter: none, stdout or otlp", func(c *Config, v string) error {
+		c.Tracing.Exporter = v
+		return nil
+	}},
+	{"otlp-endpoint", "OTLP_ENDPOINT", "address of the OTLP collector", func(c *Config, v string) error {
+		c.Tracing.Endpoint = v
+		return nil
+	}},
+	{"otlp-insecure", "OTLP_INSECURE", "connect to the OTLP collector without TLS", func(c *Config, v string) error {
+		return setBool(&c.Tracing.Insecure, v)
+	}},
+	{"tracing-sample-ratio", "TRACING_SAMPLE_RATIO", "ratio of traces started by the server that are sampled", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		c.Tracing.SampleRatio = f
+		return err
+	}},
+	{"cors-origins", "CORS_ORIGINS", "comma separated origins allowed to send requests", func(c *Config, v string) error {
+		c.CORS.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"cors-methods", "CORS_METHODS", "comma separated methods allowed in cross-origin requests", func(c *Config, v string) error {
+		c.CORS.AllowedMethods = splitList(v)
+		return nil
+	}},
+	{"cors-headers", "CORS_HEADERS", "comma separated headers allowed in cross-origin requests", func(c *Config, v string) error {
+		c.CORS.AllowedHeaders = splitList(v)
+		return nil
+	}},
+	{"cors-exposed-headers", "CORS_EXPOSED_HEADERS", "comma separated response headers readable by cross-origin requests", func(c *Config, v string) error {
+		c.CORS.ExposedHeaders = splitList(v)
+		return nil
+	}},
+	{"cors-credentials", "CORS_CREDENTIALS", "allow cross-origin requests with credentials", func(c *Config, v string) error {
+		return setBool(&c.CORS.AllowCredentials, v)
+	}},
+	{"cors-max-age", "CORS_MAX_AGE", "time browsers can cache preflight responses", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.CORS.MaxAge = Duration(d)
+		return err
+	}},
+	{"rate-limit", "RATE_LIMIT", "limit the rate of operations of every client", func(c *Config, v string) error {
+		return setBool(&c.RateLimit.Enabled, v)
+	}},
+	{"rate-limit-backend", "RATE_LIMIT_BACKEND", "rate limiter backend: memory or redis", func(c *Config, v string) error {
+		c.RateLimit.Backend = v
+		return nil
+	}},
+	{"rate-limit-default", "RATE_LIMIT_DEFAULT", "operations allowed per client, as 300/1m", func(c *Config, v string) error {
+		r, err := parseRate(v)
+		c.RateLimit.Default = r
+		return err
+	}},
+	{"rate-limit-fields", "RATE_LIMIT_FIELDS", "comma separated rates of root fields, as createProject=30/1m", func(c *Config, v string) error {
+		fields := FieldRates{}
+		for _, item := range splitList(v) {
+			parts := strings.SplitN(item, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid field rate %q", item)
+			}
+			r, err := parseRate(parts[1])
+			if err != nil {
+				return err
+			}
+			fields[strings.TrimSpace(parts[0])] = r
+		}
+		c.RateLimit.Fields = fields
+		return nil
+	}},
+	{"rate-limit-trusted-proxies", "RATE_LIMIT_TRUSTED_PROXIES", "number of proxies adding clients to the X-Forwarded-For header", func(c *Config, v string) error {
+		return setInt(&c.RateLimit.TrustedProxies, v)
+	}},
+	{"trash-retention", "TRASH_RETENTION", "time deleted projects and achievements are kept in the trash", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.Trash.Retention = Duration(d)
+		return err
+	}},
+	{"trash-purge-interval", "TRASH_PURGE_INTERVAL", "time between purges of expired items in the trash", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		c.Trash.PurgeInterval = Duration(d)
+		return err
+	}},
+}
+
+func setInt(dst *int, value string) error {
+	n, err := strconv.Atoi(value)
+	*dst = n
+	return err
+}
+
+// splitList splits a comma separated list, ignoring empty items
+func splitList(value string) []string {
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func setBool(dst *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	*dst = b
+	return err
+}
+
+// Load reads the configuration from the command line arguments, the environment and the configuration file,
+// and validates it
+func Load(args []string) (Config, error) {
+	return LoadFlags(flag.NewFlagSet("glow", flag.ContinueOnError), args)
+}
+
+// LoadFlags is like Load, but the arguments can also have the flags defined in fs, like the ones of a subcommand
+func LoadFlags(fs *flag.FlagSet, args []string) (Config, error) {
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file (CONFIG_FILE)")
+	for _, s := range settings {
+		fs.String(s.flag, "", fmt.Sprintf("%s (%s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	c := Default()
+
+	if *path != "" {
+		data, err := ioutil.ReadFile(*path)
+		if err != nil {
+			return c, fmt.Errorf("unable to read configuration file: %s", err)
+		}
+		if err := yaml.UnmarshalStrict(data, &c); err != nil {
+			return c, fmt.Errorf("unable to parse configuration file %s: %s", *path, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&c, value); err != nil {
+				return c, fmt.Errorf("invalid value %q for environment variable %s", value, s.env)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if s.set(&c, f.Value.String()) != nil {
+					err = fmt.Errorf("invalid value %q for flag -%s", f.Value, s.flag)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.Validate()
+}
+
+// Validate checks that the configuration can be used to run the server
+func (c Config) Validate() error {
+	if c.Listen == "" {
+		return fmt.Errorf("listen address must be set")
+	}
+	if c.DrainTimeout <= 0 {
+		return fmt.Errorf("drain timeout must be greater than 0")
+	}
+	if c.ShutdownDelay < 0 {
+		return fmt.Errorf("shutdown delay must not be negative")
+	}
+
+	if c.TLS.Enabled() {
+		if c.TLS.Cert == "" || c.TLS.Key == "" {
+			return fmt.Errorf("both TLS certificate and key must be set")
+		}
+		for _, f := range []string{c.TLS.Cert, c.TLS.Key} {
+			if _, err := os.Stat(f); err != nil {
+				return fmt.Errorf("unable to read TLS file: %s", err)
+			}
+		}
+	}
+
+	if c.Playground.Enabled {
+		if !strings.HasPrefix(c.Playground.Path, "/") {
+			return fmt.Errorf("playground path must start with /")
+		}
+		if c.Playground.Path == "/query" {
+			return fmt.Errorf("playground path can't be /query")
+		}
+	}
+
+	switch c.Storage.Backend {
+	case BackendRedis:
+		r := c.Storage.Redis
+		if r.Host == "" {
+			return fmt.Errorf("Redis host must be set")
+		}
+		if r.Port < 1 || r.Port > 65535 {
+			return fmt.Errorf("invalid Redis port %d", r.Port)
+		}
+		if r.DB < 0 {
+			return fmt.Errorf("invalid Redis database index %d", r.DB)
+		}
+		if r.PoolSize < 1 {
+			return fmt.Errorf("invalid Redis pool size %d", r.PoolSize)
+		}
+	default:
+		return fmt.Errorf("unknown storage backend %q", c.Storage.Backend)
+	}
+
+	if c.Query.MaxDepth < 1 {
+		return fmt.Errorf("maximum query depth must be greater than 0")
+	}
+	if c.Query.MaxComplexity < 1 {
+		return fmt.Errorf("maximum query complexity must be greater than 0")
+	}
+	// Redis expires keys with millisecond precision
+	if c.Query.APQTTL < Duration(time.Millisecond) {
+		return fmt.Errorf("APQ time to live must be at least 1ms")
+	}
+
+	if _, err := logging.New(ioutil.Discard, c.Log.Level, c.Log.Format); err != nil {
+		return fmt.Errorf("invalid log settings: %s", err)
+	}
+
+	switch c.Tracing.Exporter {
+	case ExporterNone, ExporterStdout:
+	case ExporterOTLP:
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("OTLP endpoint must be set")
+		}
+	default:
+		return fmt.Errorf("unknown traces exporter %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+
+	for _, o := range c.CORS.AllowedOrigins {
+		if o == "*" {
+			if c.CORS.AllowCredentials {
+				return fmt.Errorf("CORS credentials can't be allowed for every origin")
+			}
+			continue
+		}
+		if strings.Count(o, "*") > 1 || !strings.Contains(o, "://") {
+			return fmt.Errorf("invalid CORS origin %q", o)
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		return fmt.Errorf("CORS max age can't be negative")
+	}
+
+	if c.RateLimit.Enabled {
+		switch c.RateLimit.Backend {
+		case LimiterMemory, LimiterRedis:
+		default:
+			return fmt.Errorf("unknown rate limiter backend %q", c.RateLimit.Backend)
+		}
+		if !c.RateLimit.Default.valid() {
+			return fmt.Errorf("invalid default rate limit")
+		}
+		for f, r := range c.RateLimit.Fields {
+			if !r.valid() {
+				return fmt.Errorf("invalid rate limit for %s", f)
+			}
+		}
+		if c.RateLimit.TrustedProxies < 0 {
+			return fmt.Errorf("invalid number of trusted proxies %d", c.RateLimit.TrustedProxies)
+		}
+	}
+
+	if c.Trash.Retention <= 0 {
+		return fmt.Errorf("trash retention must be greater than 0")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		return fmt.Errorf("trash purge interval must be greater than 0")
+	}
+
+	return nil
+}
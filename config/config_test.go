@@ -0,0 +1,182 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setenv(t *testing.T, env map[string]string) {
+	for _, s := range settings {
+		os.Unsetenv(s.env)
+	}
+	os.Unsetenv("CONFIG_FILE")
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+func writeFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "glow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "glow.yml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setenv(t, map[string]string{"DB_HOST": "redis"})
+
+	expected := Default()
+	expected.Storage.Redis.Host = "redis"
+
+	actual, err := Load(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+listen: ":8080"
+playground:
+  enabled: false
+storage:
+  redis:
+    host: file
+    port: 6380
+    db: 2
+query:
+  apqTTL: 1h
+rateLimit:
+  default: 100/1m
+  fields:
+    createAchievement: 5/10s
+`)
+	setenv(t, map[string]string{
+		"CONFIG_FILE": path,
+		"LISTEN_ADDR": ":9090",
+		"DB_HOST":     "env",
+	})
+
+	actual, err := Load([]string{"-db-host", "flag", "-cors-origins", "https://picard.example.com, http://localhost:3000"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", actual.Listen)
+	assert.False(t, actual.Playground.Enabled)
+	assert.Equal(t, "flag", actual.Storage.Redis.Host)
+	assert.Equal(t, 6380, actual.Storage.Redis.Port)
+	assert.Equal(t, 2, actual.Storage.Redis.DB)
+	assert.Equal(t, Duration(time.Hour), actual.Query.APQTTL)
+	assert.Equal(t, []string{"https://picard.example.com", "http://localhost:3000"}, actual.CORS.AllowedOrigins)
+	assert.Equal(t, Rate{Limit: 100, Period: time.Minute}, actual.RateLimit.Default)
+	assert.Equal(t, FieldRates{"createAchievement": {Limit: 5, Period: 10 * time.Second}}, actual.RateLimit.Fields)
+}
+
+func TestLoadRateLimitFields(t *testing.T) {
+	setenv(t, map[string]string{"DB_HOST": "redis", "RATE_LIMIT_FIELDS": "createProject=10/1m, login=5/1m"})
+
+	actual, err := Load(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, FieldRates{
+		"createProject": {Limit: 10, Period: time.Minute},
+		"login":         {Limit: 5, Period: time.Minute},
+	}, actual.RateLimit.Fields)
+}
+
+func TestLoadUnknownFileField(t *testing.T) {
+	path := writeFile(t, "listne: \":8080\"\n")
+	setenv(t, map[string]string{"DB_HOST": "redis"})
+
+	_, err := Load([]string{"-config", path})
+
+	assert.Error(t, err)
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	setenv(t, map[string]string{"DB_HOST": "redis", "DB_PORT": "redis"})
+
+	_, err := Load(nil)
+
+	assert.EqualError(t, err, `invalid value "redis" for environment variable DB_PORT`)
+}
+
+func TestLoadFlags(t *testing.T) {
+	setenv(t, map[string]string{"DB_HOST": "redis"})
+	fs := flag.NewFlagSet("glow dump", flag.ContinueOnError)
+	user := fs.String("user", "", "")
+
+	actual, err := LoadFlags(fs, []string{"-user", "0", "-db-port", "6380"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0", *user)
+	assert.Equal(t, 6380, actual.Storage.Redis.Port)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		err    string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"missing host", func(c *Config) { c.Storage.Redis.Host = "" }, "Redis host must be set"},
+		{"invalid port", func(c *Config) { c.Storage.Redis.Port = 0 }, "invalid Redis port 0"},
+		{"shutdown delay", func(c *Config) { c.ShutdownDelay = Duration(-time.Second) }, "shutdown delay must not be negative"},
+		{"invalid pool size", func(c *Config) { c.Storage.Redis.PoolSize = 0 }, "invalid Redis pool size 0"},
+		{"unknown backend", func(c *Config) { c.Storage.Backend = "mysql" }, `unknown storage backend "mysql"`},
+		{"TLS key missing", func(c *Config) { c.TLS.Cert = "cert.pem" }, "both TLS certificate and key must be set"},
+		{"TLS file missing", func(c *Config) { c.TLS.Cert, c.TLS.Key = "nope.pem", "nope.key" }, "unable to read TLS file: stat nope.pem: no such file or directory"},
+		{"playground path", func(c *Config) { c.Playground.Path = "playground" }, "playground path must start with /"},
+		{"playground disabled", func(c *Config) { c.Playground = Playground{} }, ""},
+		{"max depth", func(c *Config) { c.Query.MaxDepth = 0 }, "maximum query depth must be greater than 0"},
+		{"APQ time to live", func(c *Config) { c.Query.APQTTL = Duration(time.Microsecond) }, "APQ time to live must be at least 1ms"},
+		{"log level", func(c *Config) { c.Log.Level = "verbose" }, `invalid log settings: not a valid logrus Level: "verbose"`},
+		{"log format", func(c *Config) { c.Log.Format = "xml" }, `invalid log settings: unknown log format "xml"`},
+		{"unknown exporter", func(c *Config) { c.Tracing.Exporter = "jaeger" }, `unknown traces exporter "jaeger"`},
+		{"OTLP endpoint", func(c *Config) { c.Tracing.Exporter, c.Tracing.Endpoint = ExporterOTLP, "" }, "OTLP endpoint must be set"},
+		{"sample ratio", func(c *Config) { c.Tracing.SampleRatio = 1.5 }, "tracing sample ratio must be between 0 and 1"},
+		{"CORS wildcard origin", func(c *Config) { c.CORS.AllowedOrigins = []string{"*"} }, ""},
+		{"CORS credentials for every origin", func(c *Config) { c.CORS.AllowedOrigins, c.CORS.AllowCredentials = []string{"*"}, true }, "CORS credentials can't be allowed for every origin"},
+		{"rate limiter backend", func(c *Config) { c.RateLimit.Backend = "memcached" }, `unknown rate limiter backend "memcached"`},
+		{"field rate", func(c *Config) { c.RateLimit.Fields["login"] = Rate{} }, "invalid rate limit for login"},
+		{"trusted proxies", func(c *Config) { c.RateLimit.TrustedProxies = -1 }, "invalid number of trusted proxies -1"},
+		{"rate limit disabled", func(c *Config) { c.RateLimit = RateLimit{} }, ""},
+		{"trash retention", func(c *Config) { c.Trash.Retention = 0 }, "trash retention must be greater than 0"},
+		{"trash purge interval", func(c *Config) { c.Trash.PurgeInterval = -1 }, "trash purge interval must be greater than 0"},
+		{"CORS origin without scheme", func(c *Config) { c.CORS.AllowedOrigins = []string{"picard.example.com"} }, `invalid CORS origin "picard.example.com"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.Storage.Redis.Host = "redis"
+			tt.modify(&c)
+
+			err := c.Validate()
+
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CSVHeader are the columns of CSV exports
+var CSVHeader = []string{"id", "project", "category", "tags", "note", "start", "end", "seconds", "roundedSeconds", "billable"}
+
+// tagSeparator separates the tags of an achievement in a CSV column
+const tagSeparator = ";"
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSV(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write(CSVHeader)
+}
+
+func (e *csvEncoder) write(r row) error {
+	rounded := ""
+	if r.Rounded != nil {
+		rounded = strconv.Itoa(*r.Rounded)
+	}
+	return e.w.Write([]string{r.ID, r.Project, r.Category, strings.Join(r.Tags, tagSeparator), r.Note,
+		r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), strconv.Itoa(r.Seconds), rounded,
+		strconv.FormatBool(r.Billable)})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error {
+	return e.flush()
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONL(w io.Writer) *jsonlEncoder {
+	b := bufio.NewWriter(w)
+	return &jsonlEncoder{w: b, enc: json.NewEncoder(b)}
+}
+
+func (e *jsonlEncoder) begin() error {
+	return nil
+}
+
+func (e *jsonlEncoder) write(r row) error {
+	return e.enc.Encode(struct {
+		ID             string   `json:"id"`
+		Project        string   `json:"project"`
+		Category       string   `json:"category"`
+		Tags           []string `json:"tags"`
+		Note           string   `json:"note"`
+		Start          string   `json:"start"`
+		End            string   `json:"end"`
+		Seconds        int      `json:"seconds"`
+		RoundedSeconds *int     `json:"roundedSeconds"`
+		Billable       bool     `json:"billable"`
+	}{r.ID, r.Project, r.Category, r.Tags, r.Note, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339),
+		r.Seconds, r.Rounded, r.Billable})
+}
+
+func (e *jsonlEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonlEncoder) end() error {
+	return e.flush()
+}
+
+// icsLayout is how times are written in iCalendar, always in UTC
+const icsLayout = "20060102T150405Z"
+
+// icsLineLength is the maximum length in bytes of an iCalendar line, longer ones are folded
+const icsLineLength = 75
+
+// icsEncoder writes an iCalendar (RFC 5545) calendar with an event per achievement
+type icsEncoder struct {
+	w     *bufio.Writer
+	stamp string
+}
+
+func newICS(w io.Writer, now time.Time) *icsEncoder {
+	return &icsEncoder{w: bufio.NewWriter(w), stamp: now.UTC().Format(icsLayout)}
+}
+
+// line writes a content line, folded into lines of at most icsLineLength bytes without splitting characters
+func (e *icsEncoder) line(name, value string) {
+	l := name + ":" + value
+	// Continuation lines start with a space
+	max := icsLineLength
+	for len(l) > max {
+		cut := max
+		for !utf8.RuneStart(l[cut]) {
+			cut--
+		}
+		e.w.WriteString(l[:cut])
+		e.w.WriteString("\r\n ")
+		l = l[cut:]
+		max = icsLineLength - 1
+	}
+	e.w.WriteString(l)
+	e.w.WriteString("\r\n")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func (e *icsEncoder) begin() error {
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//glow//export//EN")
+	return nil
+}
+
+func (e *icsEncoder) write(r row) error {
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", r.ID+"@glow")
+	e.line("DTSTAMP", e.stamp)
+	e.line("DTSTART", r.Start.Format(icsLayout))
+	e.line("DTEND", r.End.Format(icsLayout))
+	e.line("SUMMARY", icsEscaper.Replace(r.Project))
+	if r.Note != "" {
+		e.line("DESCRIPTION", icsEscaper.Replace(r.Note))
+	}
+	var categories []string
+	for _, c := range append([]string{r.Category}, r.Tags...) {
+		if c != "" {
+			categories = append(categories, icsEscaper.Replace(c))
+		}
+	}
+	if len(categories) > 0 {
+		e.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	e.line("END", "VEVENT")
+	return nil
+}
+
+func (e *icsEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) end() error {
+	e.line("END", "VCALENDAR")
+	return e.flush()
+}
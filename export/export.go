@@ -0,0 +1,179 @@
+// Package export writes a user's achievements as CSV, JSON Lines or iCalendar, for payroll, backups and
+// calendars. Achievements are read and written one project at a time, so exports of any size are streamed.
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+	"github.com/smeruelo/glow/storage"
+)
+
+// tokenTTL is how long an export can be downloaded after it's requested
+const tokenTTL = 15 * time.Minute
+
+// Validate checks the period and format of an export
+func Validate(from, to int, format model.ExportFormat) error {
+	if from >= to {
+		return fmt.Errorf("from must be before to")
+	}
+	if !format.IsValid() {
+		return fmt.Errorf("invalid export format %q", format)
+	}
+	return nil
+}
+
+// NewToken returns a random token that grants user uID the download of an export, expiring a while after now
+func NewToken(uID string, from, to int, format model.ExportFormat, now time.Time) (model.ExportToken, error) {
+	t := model.ExportToken{UserID: uID, From: from, To: to, Format: format, ExpiresAt: int(now.Add(tokenTTL).Unix())}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return t, err
+	}
+	t.Token = hex.EncodeToString(b)
+	return t, nil
+}
+
+// row is an exported achievement
+type row struct {
+	ID       string
+	Project  string
+	Category string
+	Tags     []string
+	Note     string
+	Start    time.Time
+	End      time.Time
+	Seconds  int
+	// Rounded is nil when the project's time is rounded per day, since the rounding doesn't belong to any entry
+	Rounded  *int
+	Billable bool
+}
+
+// encoder writes rows in a format
+type encoder interface {
+	begin() error
+	write(r row) error
+	// flush writes what's buffered, like at the end of each project
+	flush() error
+	end() error
+}
+
+func newEncoder(w io.Writer, format model.ExportFormat, now time.Time) encoder {
+	switch format {
+	case model.ExportFormatJSONL:
+		return newJSONL(w)
+	case model.ExportFormatIcs:
+		return newICS(w, now)
+	default:
+		return newCSV(w)
+	}
+}
+
+// ContentType returns the media type and file extension of format
+func ContentType(format model.ExportFormat) (mediaType, ext string) {
+	switch format {
+	case model.ExportFormatJSONL:
+		return "application/x-ndjson", ".jsonl"
+	case model.ExportFormatIcs:
+		return "text/calendar; charset=utf-8", ".ics"
+	default:
+		return "text/csv; charset=utf-8", ".csv"
+	}
+}
+
+// Write writes to w the finished achievements of the user of t that started between t.From and t.To,
+// in t.Format, sorted by project name and then start. If w is an http.Flusher, it's flushed after each project.
+func Write(ctx context.Context, s storage.Store, w io.Writer, t model.ExportToken, now time.Time) error {
+	if err := Validate(t.From, t.To, t.Format); err != nil {
+		return err
+	}
+	cs, err := s.GetUserCategories(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	categories := make(map[string]string, len(cs))
+	for _, c := range cs {
+		categories[c.ID] = c.Name
+	}
+	ps, err := s.GetUserProjects(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	archived, err := s.GetArchivedProjects(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	ps = append(ps, archived...)
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Name != ps[j].Name {
+			return ps[i].Name < ps[j].Name
+		}
+		return ps[i].ID < ps[j].ID
+	})
+
+	enc := newEncoder(w, t.Format, now)
+	if err := enc.begin(); err != nil {
+		return err
+	}
+	terms := billing.NewTerms(s, t.UserID)
+	for _, p := range ps {
+		as, err := s.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(as, func(i, j int) bool { return as[i].Start < as[j].Start })
+		rule, err := terms.RoundingOf(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range as {
+			if a.End == 0 || a.Start < t.From || a.Start >= t.To {
+				continue
+			}
+			ts, err := s.GetAchievementTags(ctx, a.ID)
+			if err != nil {
+				return err
+			}
+			r := row{
+				ID:       a.ID,
+				Project:  p.Name,
+				Category: categories[p.CategoryID],
+				Tags:     make([]string, len(ts)),
+				Note:     a.Note,
+				Start:    time.Unix(int64(a.Start), 0).UTC(),
+				End:      time.Unix(int64(a.End), 0).UTC(),
+				Seconds:  a.End - a.Start,
+				Billable: billing.Billable(a, p),
+			}
+			for i, tag := range ts {
+				r.Tags[i] = tag.Name
+			}
+			sort.Strings(r.Tags)
+			if rule == nil || rule.Scope == model.RoundingScopeEntry {
+				rounded := rounding.Seconds(r.Seconds, rule)
+				r.Rounded = &rounded
+			}
+			if err := enc.write(r); err != nil {
+				return err
+			}
+		}
+
+		if err := enc.flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	return enc.end()
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uID = "0"
+
+var (
+	from = 1598918400 // 2020-09-01
+	to   = from + 7*86400
+	now  = time.Unix(int64(to), 0)
+
+	work    = model.Category{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: uID, Name: "Work"}
+	reading = model.Category{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", UserID: uID, Name: "Reading"}
+)
+
+// newStore mocks a store with the project Client X, rounded up to 15 minutes per entry, and the archived
+// project Book, rounded per day
+func newStore() *mocks.Store {
+	s := &mocks.Store{}
+	ctx := context.Background()
+	x := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Client X", CategoryID: work.ID,
+		Billable: true,
+		Rounding: &model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry}}
+	book := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Book", CategoryID: reading.ID,
+		Archived: true,
+		Rounding: &model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeDay}}
+
+	s.On("GetUserCategories", ctx, uID).Return([]model.Category{work, reading}, nil)
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{x}, nil)
+	s.On("GetArchivedProjects", ctx, uID).Return([]model.Project{book}, nil)
+	s.On("GetProjectAchievements", ctx, x.ID).Return([]model.Achievement{
+		// Still running
+		{ID: "a2", ProjectID: x.ID, Start: from + 7200},
+		{ID: "a1", ProjectID: x.ID, Start: from + 3600, End: from + 4200, Note: `Standup, with "quotes"`},
+		// Out of the period
+		{ID: "a3", ProjectID: x.ID, Start: to, End: to + 600},
+	}, nil)
+	s.On("GetProjectAchievements", ctx, book.ID).Return([]model.Achievement{
+		{ID: "a4", ProjectID: book.ID, Start: from + 2*86400, End: from + 2*86400 + 1800},
+	}, nil)
+	s.On("GetAchievementTags", ctx, "a1").Return([]model.Tag{{Name: "urgent"}, {Name: "meeting"}}, nil)
+	s.On("GetAchievementTags", ctx, "a4").Return([]model.Tag{}, nil)
+	return s
+}
+
+func token(format model.ExportFormat) model.ExportToken {
+	return model.ExportToken{Token: "secret", UserID: uID, From: from, To: to, Format: format}
+}
+
+func TestWriteCSV(t *testing.T) {
+	s := newStore()
+	var buf bytes.Buffer
+
+	err := Write(context.Background(), s, &buf, token(model.ExportFormatCsv), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `id,project,category,tags,note,start,end,seconds,roundedSeconds,billable
+a4,Book,Reading,,,2020-09-03T00:00:00Z,2020-09-03T00:30:00Z,1800,,false
+a1,Client X,Work,meeting;urgent,"Standup, with ""quotes""",2020-09-01T01:00:00Z,2020-09-01T01:10:00Z,600,900,true
+`, buf.String())
+	s.AssertExpectations(t)
+}
+
+func TestWriteJSONL(t *testing.T) {
+	s := newStore()
+	var buf bytes.Buffer
+
+	err := Write(context.Background(), s, &buf, token(model.ExportFormatJSONL), now)
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":"a4","project":"Book","category":"Reading","tags":[],"note":"",
+		"start":"2020-09-03T00:00:00Z","end":"2020-09-03T00:30:00Z","seconds":1800,"roundedSeconds":null,
+		"billable":false}`, lines[0])
+	var row map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, []interface{}{"meeting", "urgent"}, row["tags"])
+	assert.Equal(t, float64(900), row["roundedSeconds"])
+}
+
+func TestWriteICS(t *testing.T) {
+	s := newStore()
+	var buf bytes.Buffer
+
+	err := Write(context.Background(), s, &buf, token(model.ExportFormatIcs), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//glow//export//EN\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:a4@glow\r\n"+
+		"DTSTAMP:20200908T000000Z\r\n"+
+		"DTSTART:20200903T000000Z\r\n"+
+		"DTEND:20200903T003000Z\r\n"+
+		"SUMMARY:Book\r\n"+
+		"CATEGORIES:Reading\r\n"+
+		"END:VEVENT\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:a1@glow\r\n"+
+		"DTSTAMP:20200908T000000Z\r\n"+
+		"DTSTART:20200901T010000Z\r\n"+
+		"DTEND:20200901T011000Z\r\n"+
+		"SUMMARY:Client X\r\n"+
+		"DESCRIPTION:Standup\\, with \"quotes\"\r\n"+
+		"CATEGORIES:Work,meeting,urgent\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", buf.String())
+}
+
+func TestICSFolding(t *testing.T) {
+	var buf bytes.Buffer
+	e := newICS(&buf, now)
+
+	e.line("DESCRIPTION", strings.Repeat("a", 70)+strings.Repeat("é", 40))
+	assert.NoError(t, e.flush())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 3)
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), icsLineLength)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+		}
+	}
+	// Characters aren't split
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("a", 70)+strings.Repeat("é", 40),
+		lines[0]+strings.TrimPrefix(lines[1], " ")+strings.TrimPrefix(lines[2], " "))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(from, to, model.ExportFormatCsv))
+	assert.EqualError(t, Validate(to, from, model.ExportFormatCsv), "from must be before to")
+	assert.EqualError(t, Validate(from, to, "XLSX"), `invalid export format "XLSX"`)
+}
+
+func TestNewToken(t *testing.T) {
+	t1, err := NewToken(uID, from, to, model.ExportFormatIcs, now)
+	assert.NoError(t, err)
+	t2, err := NewToken(uID, from, to, model.ExportFormatIcs, now)
+	assert.NoError(t, err)
+
+	assert.Len(t, t1.Token, 64)
+	assert.NotEqual(t, t1.Token, t2.Token)
+	assert.Equal(t, int(now.Add(tokenTTL).Unix()), t1.ExpiresAt)
+	assert.Equal(t, "/export?token="+t1.Token, t1.URL())
+}
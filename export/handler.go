@@ -0,0 +1,54 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Handler streams the exports of the achievements stored in s. Requests are authenticated by the token
+// returned by the createExport mutation, either in the token query parameter or as a bearer token.
+func Handler(s storage.Store, logger logrus.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := RequestToken(r)
+		t, err := s.GetExportToken(r.Context(), token)
+		if token == "" || err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="export"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		mediaType, ext := ContentType(t.Format)
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"glow-%s-%s%s\"",
+			time.Unix(int64(t.From), 0).UTC().Format("20060102"), time.Unix(int64(t.To), 0).UTC().Format("20060102"), ext))
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		// Once streaming has started, errors can't change the response status anymore
+		if err := Write(r.Context(), s, w, t, time.Now()); err != nil {
+			logging.WithContext(r.Context(), logger).WithError(err).WithField("userID", t.UserID).
+				Error("Unable to export achievements")
+		}
+	})
+}
+
+// RequestToken returns the token of a download request, either in the token query parameter or as a bearer token
+func RequestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandlerCSV(t *testing.T) {
+	s := newStore()
+	s.On("GetExportToken", mock.Anything, "secret").Return(token(model.ExportFormatCsv), nil)
+	logger, _ := test.NewNullLogger()
+	w := httptest.NewRecorder()
+
+	Handler(s, logger).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?token=secret", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="glow-20200901-20200908.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "a1,Client X,Work")
+	// Flushed after each project
+	assert.True(t, w.Flushed)
+}
+
+func TestHandlerBearerToken(t *testing.T) {
+	s := newStore()
+	s.On("GetExportToken", mock.Anything, "secret").Return(token(model.ExportFormatIcs), nil)
+	logger, _ := test.NewNullLogger()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+
+	Handler(s, logger).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "BEGIN:VCALENDAR")
+}
+
+func TestHandlerUnauthorized(t *testing.T) {
+	s := newStore()
+	s.On("GetExportToken", mock.Anything, "expired").
+		Return(model.ExportToken{}, errors.New("export token doesn't exist or has expired"))
+	s.On("GetExportToken", mock.Anything, "").
+		Return(model.ExportToken{}, errors.New("export token doesn't exist or has expired"))
+	logger, _ := test.NewNullLogger()
+
+	for _, target := range []string{"/export?token=expired", "/export"} {
+		w := httptest.NewRecorder()
+		Handler(s, logger).ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, target)
+		assert.Equal(t, `Bearer realm="export"`, w.Header().Get("WWW-Authenticate"))
+	}
+
+	w := httptest.NewRecorder()
+	Handler(s, logger).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/export?token=secret", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	s.AssertNotCalled(t, "GetUserProjects", mock.Anything, mock.Anything)
+}
@@ -4,8 +4,19 @@ go 1.13
 
 require (
 	github.com/99designs/gqlgen v0.12.2
+	github.com/alicebob/miniredis/v2 v2.17.0
 	github.com/gomodule/redigo v1.8.2
 	github.com/google/uuid v1.1.1
-	github.com/stretchr/testify v1.5.1
+	github.com/gorilla/websocket v1.4.2
+	github.com/prometheus/client_golang v1.7.1
+	github.com/sirupsen/logrus v1.6.0
+	github.com/stretchr/testify v1.6.1
 	github.com/vektah/gqlparser/v2 v2.0.1
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.13.0
+	go.opentelemetry.io/otel v0.13.0
+	go.opentelemetry.io/otel/exporters/otlp v0.13.0
+	go.opentelemetry.io/otel/exporters/stdout v0.13.0
+	go.opentelemetry.io/otel/sdk v0.13.0
+	google.golang.org/grpc v1.32.0
+	gopkg.in/yaml.v2 v2.2.5
 )
@@ -0,0 +1,16 @@
+package graph
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// validateNote checks the note given to an achievement, if any
+func validateNote(note *string) error {
+	if note != nil && utf8.RuneCountInString(*note) > model.MaxNoteLength {
+		return fmt.Errorf("note can't be longer than %d characters", model.MaxNoteLength)
+	}
+	return nil
+}
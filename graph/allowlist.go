@@ -0,0 +1,78 @@
+//go:generate go run gen_operations.go -dir=$PICARD_OPERATIONS
+
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errOperationNotAllowed = "OPERATION_NOT_ALLOWED"
+
+// OperationAllowlist is a handler extension that only accepts the operations in Queries,
+// indexed by the SHA-256 hash of the query.
+//
+// Clients can either send the full query or just its hash, using the same
+// persistedQuery extension as automatic persisted queries.
+// Unlike with APQ, unknown queries are never registered, they are rejected.
+type OperationAllowlist struct {
+	Queries map[string]string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = OperationAllowlist{}
+
+// ExtensionName returns the name of the extension
+func (a OperationAllowlist) ExtensionName() string {
+	return "OperationAllowlist"
+}
+
+// Validate checks that the extension is properly configured
+func (a OperationAllowlist) Validate(schema graphql.ExecutableSchema) error {
+	if len(a.Queries) == 0 {
+		return fmt.Errorf("OperationAllowlist has no registered queries")
+	}
+	return nil
+}
+
+// MutateOperationParameters replaces the incoming query by the registered one, or rejects it
+func (a OperationAllowlist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	hash := persistedQueryHash(rawParams.Extensions)
+	if hash == "" {
+		hash = QueryHash(rawParams.Query)
+	}
+
+	query, ok := a.Queries[hash]
+	if !ok || (rawParams.Query != "" && rawParams.Query != query) {
+		err := gqlerror.Errorf("operation is not in the allowlist")
+		errcode.Set(err, errOperationNotAllowed)
+		return err
+	}
+
+	rawParams.Query = query
+	return nil
+}
+
+// persistedQueryHash returns the hash sent in the persistedQuery extension, if any
+func persistedQueryHash(extensions map[string]interface{}) string {
+	pq, ok := extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	hash, _ := pq["sha256Hash"].(string)
+	return hash
+}
+
+// QueryHash returns the hash that identifies a persisted query
+func QueryHash(query string) string {
+	b := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(b[:])
+}
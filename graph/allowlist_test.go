@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const allowedQuery = "query Projects { projects { id } }"
+
+func newStrictServer(s *mocks.Store) *handler.Server {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: NewResolver(s, nullLogger()),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(OperationAllowlist{Queries: map[string]string{
+		QueryHash(allowedQuery): allowedQuery,
+	}})
+	return srv
+}
+
+func doPersistedQuery(srv *handler.Server, query, hash string) string {
+	params := map[string]interface{}{"query": query}
+	if hash != "" {
+		params["extensions"] = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+		}
+	}
+	body, _ := json.Marshal(params)
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestAllowlistFullQuery(t *testing.T) {
+	var s mocks.Store
+	srv := newStrictServer(&s)
+
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+
+	actual := doPersistedQuery(srv, allowedQuery, "")
+
+	assert.Equal(t, `{"data":{"projects":[]}}`, actual)
+	s.AssertExpectations(t)
+}
+
+func TestAllowlistHashOnly(t *testing.T) {
+	var s mocks.Store
+	srv := newStrictServer(&s)
+
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+
+	actual := doPersistedQuery(srv, "", QueryHash(allowedQuery))
+
+	assert.Equal(t, `{"data":{"projects":[]}}`, actual)
+	s.AssertExpectations(t)
+}
+
+func TestAllowlistUnknownQuery(t *testing.T) {
+	var s mocks.Store
+	srv := newStrictServer(&s)
+
+	actual := doPersistedQuery(srv, "{ projects { id name } }", "")
+
+	assert.Contains(t, actual, `"code":"OPERATION_NOT_ALLOWED"`)
+	s.AssertExpectations(t)
+}
+
+func TestAllowlistHashMismatch(t *testing.T) {
+	var s mocks.Store
+	srv := newStrictServer(&s)
+
+	actual := doPersistedQuery(srv, "{ projects { id name } }", QueryHash(allowedQuery))
+
+	assert.Contains(t, actual, `"code":"OPERATION_NOT_ALLOWED"`)
+	s.AssertExpectations(t)
+}
@@ -0,0 +1,26 @@
+package graph
+
+import (
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+)
+
+// validateRate checks the hourly rate given to a project or user, if any
+func validateRate(rate *model.MoneyData) error {
+	if rate == nil {
+		return nil
+	}
+	return billing.ValidateRate(*billing.RateFromData(rate))
+}
+
+// validateRounding checks the rounding rule set by d, if any
+func validateRounding(d *model.RoundingData) error {
+	if d == nil {
+		return nil
+	}
+	return rounding.Validate(*rounding.FromData(d))
+}
+
+// roundingFromData is rounding.FromData, for the resolvers whose arguments shadow the package
+var roundingFromData = rounding.FromData
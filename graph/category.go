@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validateCategory checks the data given to create or update a category
+func validateCategory(nc model.NewCategory) error {
+	if strings.TrimSpace(nc.Name) == "" {
+		return fmt.Errorf("category name can't be empty")
+	}
+	if nc.Color != "" && !colorPattern.MatchString(nc.Color) {
+		return fmt.Errorf("invalid color %q, expected #rrggbb", nc.Color)
+	}
+	return nil
+}
+
+// checkCategory fails unless cID is one of the categories of user uID
+func (r *Resolver) checkCategory(ctx context.Context, cID, uID string) error {
+	c, err := r.store.GetCategory(ctx, cID)
+	if err != nil {
+		return err
+	}
+	if c.UserID != uID {
+		return fmt.Errorf("category %s does not exist", cID)
+	}
+	return nil
+}
+
+// projectCategory returns the category cID, checked like checkCategory, or the default category of
+// user uID if it's nil.
+// categoryID can be left out of NewProject while clients catch up with categories, it will be required
+// again afterwards.
+func (r *Resolver) projectCategory(ctx context.Context, cID *string, uID string) (string, error) {
+	if cID != nil {
+		return *cID, r.checkCategory(ctx, *cID, uID)
+	}
+	return r.defaultCategory(ctx, uID)
+}
+
+// defaultCategory returns the "Uncategorized" category of user uID, creating it if it doesn't exist
+func (r *Resolver) defaultCategory(ctx context.Context, uID string) (string, error) {
+	cs, err := r.store.GetUserCategories(ctx, uID)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range cs {
+		if storage.NameKey(c.Name) == storage.NameKey(storage.Uncategorized) {
+			return c.ID, nil
+		}
+	}
+
+	c := model.Category{ID: uuid.New().String(), UserID: uID, Name: storage.Uncategorized, Order: len(cs)}
+	if err := r.store.CreateCategory(ctx, c); err != nil {
+		return "", err
+	}
+	r.log(ctx).WithField("categoryID", c.ID).Info("Category created")
+	return c.ID, nil
+}
@@ -0,0 +1,75 @@
+//go:build ignore
+// +build ignore
+
+// gen_operations registers the operations accepted by the server in strict mode.
+// It reads every .graphql file under -dir, each of them containing a single
+// operation exactly as sent by picard, and writes them indexed by their hash.
+//
+// Usage:
+//
+//	PICARD_OPERATIONS=../picard/src/operations go generate ./graph
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory containing picard's operations")
+	out := flag.String("out", "operations_gen.go", "output file")
+	flag.Parse()
+
+	queries := map[string]string{}
+	if *dir != "" {
+		err := filepath.Walk(*dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".graphql" {
+				return err
+			}
+			query, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			b := sha256.Sum256(query)
+			queries[hex.EncodeToString(b[:])] = string(query)
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Unable to read operations: %s", err)
+		}
+	}
+
+	hashes := make([]string, 0, len(queries))
+	for hash := range queries {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_operations.go, DO NOT EDIT.\n\n")
+	b.WriteString("package graph\n\n")
+	b.WriteString("// RegisteredOperations are picard's operations, indexed by their hash.\n")
+	b.WriteString("// They are the only ones accepted when the server runs in strict mode.\n")
+	b.WriteString("var RegisteredOperations = map[string]string{\n")
+	for _, hash := range hashes {
+		fmt.Fprintf(&b, "%q: %q,\n", hash, queries[hash])
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("Unable to format generated code: %s", err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("Unable to write %s: %s", *out, err)
+	}
+}
@@ -0,0 +1,18 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkNotInvoiced fails if achievement aID has been invoiced, which locks it
+func (r *Resolver) checkNotInvoiced(ctx context.Context, aID string) error {
+	a, err := r.store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if a.InvoiceID != nil {
+		return fmt.Errorf("achievement %s is locked by invoice %s", aID, *a.InvoiceID)
+	}
+	return nil
+}
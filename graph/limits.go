@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// listMultiplier is the estimated number of items returned by a list field.
+// We can't know in advance how many items a list will hold, so every field
+// below a list is assumed to be resolved this many times.
+const listMultiplier = 10
+
+// NewComplexity returns the per-field costs used to compute the complexity of an operation.
+// Fields not declared here cost 1 plus the cost of their children.
+func NewComplexity() generated.ComplexityRoot {
+	var c generated.ComplexityRoot
+
+	c.Query.Projects = func(childComplexity int, includeArchived bool) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.ProjectAchievements = func(childComplexity int, projectID string, tags []string) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.UserAchievements = func(childComplexity int, tags []string) int {
+		// It fans out to every project of the user
+		return listMultiplier * listMultiplier * childComplexity
+	}
+	c.Query.AuditLog = func(childComplexity int, entityID string, from *int, to *int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.Trash = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.Categories = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.CategoryTotals = func(childComplexity int, from *int, to *int) int {
+		// Like userAchievements, it goes through every achievement of the user
+		return listMultiplier * listMultiplier * childComplexity
+	}
+	c.Query.Tags = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.SearchAchievements = func(childComplexity int, text string) int {
+		// Like userAchievements, it can match any achievement of the user
+		return listMultiplier * listMultiplier * childComplexity
+	}
+	c.Query.Invoices = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Query.Report = func(childComplexity int, from int, to int, groupBy []model.ReportGrouping, filter *model.ReportFilter) int {
+		// It goes through every achievement of the user, but only returns their totals
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Query.TimeSeries = func(childComplexity int, from int, to int, bucket model.TimeBucket, groupBy *model.ReportGrouping) int {
+		// Like reports
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Query.Heatmap = func(childComplexity int, year int) int {
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Query.HoursOfWeek = func(childComplexity int, from int, to int) int {
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Project.Children = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Project.Tags = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Project.Total = func(childComplexity int, from *int, to *int) int {
+		// It fans out to every subproject
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Project.Earnings = func(childComplexity int, from *int, to *int) int {
+		// Like the total, it fans out to every subproject
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Project.RoundedTotal = func(childComplexity int, from *int, to *int) int {
+		// Like the total, it fans out to every subproject
+		return listMultiplier * (childComplexity + 1)
+	}
+	c.Achievement.Tags = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+	c.Invoice.Lines = func(childComplexity int) int {
+		return listMultiplier * childComplexity
+	}
+
+	return c
+}
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// DepthLimit is a handler extension that rejects operations nested deeper than Limit
+type DepthLimit struct {
+	Limit int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+// ExtensionName returns the name of the extension
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate checks that the extension is properly configured
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	if d.Limit < 1 {
+		return fmt.Errorf("DepthLimit limit must be greater than 0")
+	}
+	return nil
+}
+
+// MutateOperationContext rejects the operation if it exceeds the depth limit
+func (d DepthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	op := rc.Doc.Operations.ForName(rc.OperationName)
+	if op == nil {
+		return nil
+	}
+
+	depth := selectionSetDepth(op.SelectionSet)
+	if depth > d.Limit {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Limit)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	return nil
+}
+
+// selectionSetDepth returns the number of nested fields of the deepest branch of a selection set.
+// Introspection fields are not taken into account, since clients like the playground
+// need deeply nested introspection queries to load the schema.
+func selectionSetDepth(selectionSet ast.SelectionSet) int {
+	var depth int
+	for _, selection := range selectionSet {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionSetDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			d = selectionSetDepth(s.Definition.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionSetDepth(s.SelectionSet)
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
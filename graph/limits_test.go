@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newLimitedServer(s *mocks.Store, maxDepth, maxComplexity int) *handler.Server {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  NewResolver(s, nullLogger()),
+		Complexity: NewComplexity(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(DepthLimit{Limit: maxDepth})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	return srv
+}
+
+func doQuery(srv http.Handler, query string) *httptest.ResponseRecorder {
+	body := `{"query":"` + query + `"}`
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestDepthLimitAllowed(t *testing.T) {
+	var s mocks.Store
+	srv := newLimitedServer(&s, 2, 500)
+
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+
+	w := doQuery(srv, "{ projects { id name } }")
+
+	assert.Equal(t, `{"data":{"projects":[]}}`, w.Body.String())
+	s.AssertExpectations(t)
+}
+
+func TestDepthLimitExceeded(t *testing.T) {
+	var s mocks.Store
+	srv := newLimitedServer(&s, 1, 500)
+
+	w := doQuery(srv, "{ projects { ...p } } fragment p on Project { id }")
+
+	assert.Equal(t, `{"errors":[{"message":"operation has depth 2, which exceeds the limit of 1","extensions":{"code":"DEPTH_LIMIT_EXCEEDED"}}],"data":null}`, w.Body.String())
+	s.AssertExpectations(t)
+}
+
+func TestDepthLimitIgnoresIntrospection(t *testing.T) {
+	var s mocks.Store
+	srv := newLimitedServer(&s, 1, 500)
+
+	w := doQuery(srv, "{ __schema { queryType { fields { type { ofType { name } } } } } }")
+
+	assert.NotContains(t, w.Body.String(), "DEPTH_LIMIT_EXCEEDED")
+}
+
+func TestComplexityLimitExceeded(t *testing.T) {
+	var s mocks.Store
+	srv := newLimitedServer(&s, 10, 100)
+
+	w := doQuery(srv, "{ userAchievements { id start end } }")
+
+	assert.Equal(t, `{"errors":[{"message":"operation has complexity 300, which exceeds the limit of 100","extensions":{"code":"COMPLEXITY_LIMIT_EXCEEDED"}}],"data":null}`, w.Body.String())
+	s.AssertExpectations(t)
+}
+
+func TestComplexity(t *testing.T) {
+	var s mocks.Store
+	srv := newLimitedServer(&s, 10, 1)
+
+	tests := []struct {
+		query      string
+		complexity int
+	}{
+		{`{ auditLog(entityID: \"1\") { operation timestamp } }`, 20},
+		{`{ trash { id deletedAt } }`, 20},
+		{`{ categories { id name } }`, 20},
+		{`{ categoryTotals { seconds } }`, 100},
+		{`{ tags { id name } }`, 20},
+		{`{ projects { tags { name } } }`, 100},
+		{`{ userAchievements { tags { name } } }`, 1000},
+		{`{ searchAchievements(text: \"review\") { id note } }`, 200},
+		{`{ invoices { id number } }`, 20},
+		{`{ invoices { lines { description } } }`, 100},
+		{`{ projects { roundedTotal } }`, 100},
+	}
+	for _, tt := range tests {
+		w := doQuery(srv, tt.query)
+
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("operation has complexity %d,", tt.complexity), tt.query)
+	}
+	s.AssertExpectations(t)
+}
@@ -0,0 +1,18 @@
+package model
+
+// Achievement is a time dedication to a project, from Start to End, Unix timestamps.
+// End is 0 while the timer is running. Note is markdown, empty if there's none. Its tags are resolved apart.
+// Billable is nil when it follows the project's. InvoiceID is set once it's invoiced, which locks it.
+type Achievement struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"userID"`
+	ProjectID string  `json:"projectID"`
+	Start     int     `json:"start"`
+	End       int     `json:"end"`
+	Note      string  `json:"note"`
+	Billable  *bool   `json:"billable"`
+	InvoiceID *string `json:"invoiceID"`
+}
+
+// MaxNoteLength is the maximum number of characters of an achievement's note
+const MaxNoteLength = 5000
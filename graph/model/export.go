@@ -0,0 +1,19 @@
+package model
+
+import "net/url"
+
+// ExportToken grants the download of the achievements of user UserID started between From and To,
+// in Format, until ExpiresAt. The token is the only credential needed, so it must be kept secret.
+type ExportToken struct {
+	Token     string       `json:"token"`
+	UserID    string       `json:"userID"`
+	From      int          `json:"from"`
+	To        int          `json:"to"`
+	Format    ExportFormat `json:"format"`
+	ExpiresAt int          `json:"expiresAt"`
+}
+
+// URL returns the path the export is downloaded from
+func (t ExportToken) URL() string {
+	return "/export?token=" + url.QueryEscape(t.Token)
+}
@@ -2,28 +2,489 @@
 
 package model
 
-type Achievement struct {
-	ID        string `json:"id"`
-	UserID    string `json:"userID"`
-	ProjectID string `json:"projectID"`
-	Start     int    `json:"start"`
-	End       int    `json:"end"`
-}
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
 
 type AchievementData struct {
-	ProjectID string `json:"projectID"`
-	Start     int    `json:"start"`
-	End       int    `json:"end"`
+	ProjectID string  `json:"projectID"`
+	Start     int     `json:"start"`
+	End       int     `json:"end"`
+	Note      *string `json:"note"`
+}
+
+type AuditEntry struct {
+	ID         string  `json:"id"`
+	Actor      string  `json:"actor"`
+	Operation  string  `json:"operation"`
+	EntityType string  `json:"entityType"`
+	EntityID   string  `json:"entityID"`
+	Before     *string `json:"before"`
+	After      *string `json:"after"`
+	Timestamp  int     `json:"timestamp"`
+}
+
+type Category struct {
+	ID     string `json:"id"`
+	UserID string `json:"userID"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	Icon   string `json:"icon"`
+	Order  int    `json:"order"`
+}
+
+type CategoryTotal struct {
+	Category       *Category `json:"category"`
+	Seconds        int       `json:"seconds"`
+	RoundedSeconds int       `json:"roundedSeconds"`
+	Earnings       []*Money  `json:"earnings"`
+}
+
+type HeatmapDay struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+}
+
+type HourOfWeek struct {
+	Weekday int `json:"weekday"`
+	Hour    int `json:"hour"`
+	Seconds int `json:"seconds"`
+}
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+type ImportResult struct {
+	Format     ImportFormat   `json:"format"`
+	DryRun     bool           `json:"dryRun"`
+	Rows       int            `json:"rows"`
+	Created    int            `json:"created"`
+	Duplicates int            `json:"duplicates"`
+	Projects   []string       `json:"projects"`
+	Tags       []string       `json:"tags"`
+	Errors     []*ImportError `json:"errors"`
+}
+
+type Invoice struct {
+	ID             string         `json:"id"`
+	UserID         string         `json:"userID"`
+	Number         int            `json:"number"`
+	From           int            `json:"from"`
+	To             int            `json:"to"`
+	CreatedAt      int            `json:"createdAt"`
+	Currency       string         `json:"currency"`
+	Lines          []*InvoiceLine `json:"lines"`
+	Total          int            `json:"total"`
+	AchievementIDs []string       `json:"achievementIDs"`
+}
+
+type InvoiceLine struct {
+	Description string `json:"description"`
+	Seconds     int    `json:"seconds"`
+	Amount      int    `json:"amount"`
+}
+
+type Money struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type MoneyData struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type NewCategory struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+	Order int    `json:"order"`
 }
 
 type NewProject struct {
-	Name     string `json:"name"`
-	Category string `json:"category"`
+	Name       string        `json:"name"`
+	CategoryID *string       `json:"categoryID"`
+	ParentID   *string       `json:"parentID"`
+	Rate       *MoneyData    `json:"rate"`
+	Billable   bool          `json:"billable"`
+	Rounding   *RoundingData `json:"rounding"`
+}
+
+type NewTag struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type Report struct {
+	From           int            `json:"from"`
+	To             int            `json:"to"`
+	Seconds        int            `json:"seconds"`
+	RoundedSeconds int            `json:"roundedSeconds"`
+	Entries        int            `json:"entries"`
+	Earnings       []*Money       `json:"earnings"`
+	Groups         []*ReportGroup `json:"groups"`
+}
+
+type ReportFilter struct {
+	ProjectIDs  []string `json:"projectIDs"`
+	CategoryIDs []string `json:"categoryIDs"`
+	TagIDs      []string `json:"tagIDs"`
+	Billable    *bool    `json:"billable"`
+}
+
+type ReportGroup struct {
+	Grouping       ReportGrouping `json:"grouping"`
+	Key            string         `json:"key"`
+	Name           string         `json:"name"`
+	Seconds        int            `json:"seconds"`
+	RoundedSeconds int            `json:"roundedSeconds"`
+	Entries        int            `json:"entries"`
+	Earnings       []*Money       `json:"earnings"`
+	Groups         []*ReportGroup `json:"groups"`
+}
+
+type Rounding struct {
+	Increment int               `json:"increment"`
+	Direction RoundingDirection `json:"direction"`
+	Scope     RoundingScope     `json:"scope"`
+}
+
+type RoundingData struct {
+	Increment int               `json:"increment"`
+	Direction RoundingDirection `json:"direction"`
+	Scope     RoundingScope     `json:"scope"`
+}
+
+type Series struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Seconds []int  `json:"seconds"`
+}
+
+type Tag struct {
+	ID     string `json:"id"`
+	UserID string `json:"userID"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+}
+
+type TimeSeries struct {
+	Bucket TimeBucket `json:"bucket"`
+	Starts []int      `json:"starts"`
+	Series []*Series  `json:"series"`
+}
+
+type TrashItem struct {
+	ID          string       `json:"id"`
+	EntityType  string       `json:"entityType"`
+	DeletedAt   int          `json:"deletedAt"`
+	Project     *Project     `json:"project"`
+	Achievement *Achievement `json:"achievement"`
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCsv   ExportFormat = "CSV"
+	ExportFormatJSONL ExportFormat = "JSONL"
+	ExportFormatIcs   ExportFormat = "ICS"
+)
+
+var AllExportFormat = []ExportFormat{
+	ExportFormatCsv,
+	ExportFormatJSONL,
+	ExportFormatIcs,
+}
+
+func (e ExportFormat) IsValid() bool {
+	switch e {
+	case ExportFormatCsv, ExportFormatJSONL, ExportFormatIcs:
+		return true
+	}
+	return false
+}
+
+func (e ExportFormat) String() string {
+	return string(e)
+}
+
+func (e *ExportFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExportFormat", str)
+	}
+	return nil
+}
+
+func (e ExportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImportFormat string
+
+const (
+	ImportFormatToggl    ImportFormat = "TOGGL"
+	ImportFormatClockify ImportFormat = "CLOCKIFY"
+	ImportFormatGlow     ImportFormat = "GLOW"
+)
+
+var AllImportFormat = []ImportFormat{
+	ImportFormatToggl,
+	ImportFormatClockify,
+	ImportFormatGlow,
+}
+
+func (e ImportFormat) IsValid() bool {
+	switch e {
+	case ImportFormatToggl, ImportFormatClockify, ImportFormatGlow:
+		return true
+	}
+	return false
+}
+
+func (e ImportFormat) String() string {
+	return string(e)
+}
+
+func (e *ImportFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImportFormat", str)
+	}
+	return nil
+}
+
+func (e ImportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type InvoiceGrouping string
+
+const (
+	InvoiceGroupingProject InvoiceGrouping = "PROJECT"
+	InvoiceGroupingDay     InvoiceGrouping = "DAY"
+)
+
+var AllInvoiceGrouping = []InvoiceGrouping{
+	InvoiceGroupingProject,
+	InvoiceGroupingDay,
+}
+
+func (e InvoiceGrouping) IsValid() bool {
+	switch e {
+	case InvoiceGroupingProject, InvoiceGroupingDay:
+		return true
+	}
+	return false
+}
+
+func (e InvoiceGrouping) String() string {
+	return string(e)
+}
+
+func (e *InvoiceGrouping) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoiceGrouping(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoiceGrouping", str)
+	}
+	return nil
+}
+
+func (e InvoiceGrouping) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportGrouping string
+
+const (
+	ReportGroupingProject  ReportGrouping = "PROJECT"
+	ReportGroupingCategory ReportGrouping = "CATEGORY"
+	ReportGroupingTag      ReportGrouping = "TAG"
+	ReportGroupingDay      ReportGrouping = "DAY"
+	ReportGroupingWeek     ReportGrouping = "WEEK"
+	ReportGroupingMonth    ReportGrouping = "MONTH"
+)
+
+var AllReportGrouping = []ReportGrouping{
+	ReportGroupingProject,
+	ReportGroupingCategory,
+	ReportGroupingTag,
+	ReportGroupingDay,
+	ReportGroupingWeek,
+	ReportGroupingMonth,
+}
+
+func (e ReportGrouping) IsValid() bool {
+	switch e {
+	case ReportGroupingProject, ReportGroupingCategory, ReportGroupingTag, ReportGroupingDay, ReportGroupingWeek, ReportGroupingMonth:
+		return true
+	}
+	return false
+}
+
+func (e ReportGrouping) String() string {
+	return string(e)
+}
+
+func (e *ReportGrouping) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportGrouping(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportGrouping", str)
+	}
+	return nil
+}
+
+func (e ReportGrouping) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RoundingDirection string
+
+const (
+	RoundingDirectionUp      RoundingDirection = "UP"
+	RoundingDirectionDown    RoundingDirection = "DOWN"
+	RoundingDirectionNearest RoundingDirection = "NEAREST"
+)
+
+var AllRoundingDirection = []RoundingDirection{
+	RoundingDirectionUp,
+	RoundingDirectionDown,
+	RoundingDirectionNearest,
+}
+
+func (e RoundingDirection) IsValid() bool {
+	switch e {
+	case RoundingDirectionUp, RoundingDirectionDown, RoundingDirectionNearest:
+		return true
+	}
+	return false
+}
+
+func (e RoundingDirection) String() string {
+	return string(e)
+}
+
+func (e *RoundingDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RoundingDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RoundingDirection", str)
+	}
+	return nil
+}
+
+func (e RoundingDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RoundingScope string
+
+const (
+	RoundingScopeEntry RoundingScope = "ENTRY"
+	RoundingScopeDay   RoundingScope = "DAY"
+)
+
+var AllRoundingScope = []RoundingScope{
+	RoundingScopeEntry,
+	RoundingScopeDay,
+}
+
+func (e RoundingScope) IsValid() bool {
+	switch e {
+	case RoundingScopeEntry, RoundingScopeDay:
+		return true
+	}
+	return false
+}
+
+func (e RoundingScope) String() string {
+	return string(e)
+}
+
+func (e *RoundingScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RoundingScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RoundingScope", str)
+	}
+	return nil
+}
+
+func (e RoundingScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TimeBucket string
+
+const (
+	TimeBucketHour TimeBucket = "HOUR"
+	TimeBucketDay  TimeBucket = "DAY"
+	TimeBucketWeek TimeBucket = "WEEK"
+)
+
+var AllTimeBucket = []TimeBucket{
+	TimeBucketHour,
+	TimeBucketDay,
+	TimeBucketWeek,
+}
+
+func (e TimeBucket) IsValid() bool {
+	switch e {
+	case TimeBucketHour, TimeBucketDay, TimeBucketWeek:
+		return true
+	}
+	return false
+}
+
+func (e TimeBucket) String() string {
+	return string(e)
+}
+
+func (e *TimeBucket) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TimeBucket(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TimeBucket", str)
+	}
+	return nil
 }
 
-type Project struct {
-	ID       string `json:"id"`
-	UserID   string `json:"userID"`
-	Name     string `json:"name"`
-	Category string `json:"category"`
+func (e TimeBucket) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
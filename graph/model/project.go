@@ -0,0 +1,16 @@
+package model
+
+// Project is a user's project. Its category is resolved from CategoryID, and its parent from ParentID,
+// which is nil for top level projects. Rate and Rounding are the project's own, nil if it uses
+// its parent's or user's.
+type Project struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userID"`
+	Name       string    `json:"name"`
+	CategoryID string    `json:"categoryID"`
+	ParentID   *string   `json:"parentID"`
+	Archived   bool      `json:"archived"`
+	Rate       *Money    `json:"rate"`
+	Billable   bool      `json:"billable"`
+	Rounding   *Rounding `json:"rounding"`
+}
@@ -0,0 +1,7 @@
+// Code generated by gen_operations.go, DO NOT EDIT.
+
+package graph
+
+// RegisteredOperations are picard's operations, indexed by their hash.
+// They are the only ones accepted when the server runs in strict mode.
+var RegisteredOperations = map[string]string{}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// checkParent fails unless parentID is nil or one of the active projects of user uID
+func (r *Resolver) checkParent(ctx context.Context, parentID *string, uID string) error {
+	if parentID == nil {
+		return nil
+	}
+	p, err := r.store.GetProject(ctx, *parentID)
+	if err != nil {
+		return err
+	}
+	if p.UserID != uID {
+		return fmt.Errorf("project %s does not exist", *parentID)
+	}
+	if p.Archived {
+		return fmt.Errorf("project %s is archived", *parentID)
+	}
+	return nil
+}
+
+// subtree returns p followed by all its descendants, parents before their children
+func (r *Resolver) subtree(ctx context.Context, p model.Project) ([]model.Project, error) {
+	ps := []model.Project{p}
+	for i := 0; i < len(ps); i++ {
+		children, err := r.store.GetChildProjects(ctx, ps[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, children...)
+	}
+	return ps, nil
+}
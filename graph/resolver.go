@@ -1,15 +1,25 @@
 package graph
 
 import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/logging"
 	"github.com/smeruelo/glow/storage"
 )
 
 // Resolver contains the dependencies needed to build the schema resolvers
 type Resolver struct {
-	store storage.Store
+	store  storage.Store
+	logger logrus.FieldLogger
+}
+
+// NewResolver receives a DB store and a logger and creates a Resolver with them.
+func NewResolver(s storage.Store, logger logrus.FieldLogger) *Resolver {
+	return &Resolver{store: s, logger: logger}
 }
 
-// NewResolver receives a DB store and creates a Resolver with it.
-func NewResolver(s storage.Store) *Resolver {
-	return &Resolver{store: s}
+// log returns the logger for the request ctx belongs to
+func (r *Resolver) log(ctx context.Context) logrus.FieldLogger {
+	return logging.WithContext(ctx, r.logger)
 }
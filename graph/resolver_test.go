@@ -0,0 +1,12 @@
+package graph
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// nullLogger returns a logger that discards everything
+func nullLogger() *logrus.Logger {
+	logger, _ := test.NewNullLogger()
+	return logger
+}
@@ -5,57 +5,623 @@ package graph
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/google/uuid"
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/export"
 	"github.com/smeruelo/glow/graph/generated"
 	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/importer"
+	"github.com/smeruelo/glow/invoice"
+	"github.com/smeruelo/glow/report"
+	"github.com/smeruelo/glow/rounding"
+	"github.com/smeruelo/glow/storage"
 )
 
+func (r *achievementResolver) Tags(ctx context.Context, obj *model.Achievement) ([]*model.Tag, error) {
+	ts, err := r.store.GetAchievementTags(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return tagSlice(ts), nil
+}
+
+func (r *achievementResolver) Billable(ctx context.Context, obj *model.Achievement) (bool, error) {
+	if obj.Billable != nil {
+		return *obj.Billable, nil
+	}
+	p, err := r.store.GetProject(ctx, obj.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	return p.Billable, nil
+}
+
 func (r *mutationResolver) CreateProject(ctx context.Context, input model.NewProject) (*model.Project, error) {
+	categoryID, err := r.projectCategory(ctx, input.CategoryID, "0")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkParent(ctx, input.ParentID, "0"); err != nil {
+		return nil, err
+	}
+	if err := validateRate(input.Rate); err != nil {
+		return nil, err
+	}
+	if err := validateRounding(input.Rounding); err != nil {
+		return nil, err
+	}
 	p := model.Project{
-		ID:       uuid.New().String(),
-		UserID:   "0",
-		Name:     input.Name,
-		Category: input.Category,
+		ID:         uuid.New().String(),
+		UserID:     "0",
+		Name:       input.Name,
+		CategoryID: categoryID,
+		ParentID:   input.ParentID,
+		Rate:       billing.RateFromData(input.Rate),
+		Billable:   input.Billable,
+		Rounding:   rounding.FromData(input.Rounding),
+	}
+	if err := r.store.CreateProject(ctx, p); err != nil {
+		return &p, err
 	}
-	return &p, r.store.CreateProject(p)
+	r.log(ctx).WithField("projectID", p.ID).Info("Project created")
+	return &p, nil
 }
 
 func (r *mutationResolver) UpdateProject(ctx context.Context, id string, input model.NewProject) (*model.Project, error) {
-	p, err := r.store.UpdateProject(id, input)
-	return &p, err
+	// Without a category the project keeps its own
+	if input.CategoryID != nil {
+		if err := r.checkCategory(ctx, *input.CategoryID, "0"); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.checkParent(ctx, input.ParentID, "0"); err != nil {
+		return nil, err
+	}
+	if err := validateRate(input.Rate); err != nil {
+		return nil, err
+	}
+	if err := validateRounding(input.Rounding); err != nil {
+		return nil, err
+	}
+	p, err := r.store.UpdateProject(ctx, id, input)
+	if err != nil {
+		return &p, err
+	}
+	r.log(ctx).WithField("projectID", id).Info("Project updated")
+	return &p, nil
+}
+
+func (r *mutationResolver) ArchiveProject(ctx context.Context, id string) (*model.Project, error) {
+	p, err := r.store.ArchiveProject(ctx, id, "0")
+	if err != nil {
+		return &p, err
+	}
+	r.log(ctx).WithField("projectID", id).Info("Project archived")
+	return &p, nil
+}
+
+func (r *mutationResolver) UnarchiveProject(ctx context.Context, id string) (*model.Project, error) {
+	p, err := r.store.UnarchiveProject(ctx, id, "0")
+	if err != nil {
+		return &p, err
+	}
+	r.log(ctx).WithField("projectID", id).Info("Project unarchived")
+	return &p, nil
 }
 
 func (r *mutationResolver) DeleteProject(ctx context.Context, id string) (string, error) {
-	return id, r.store.DeleteProject(id, "0")
+	if err := r.store.DeleteProject(ctx, id, "0"); err != nil {
+		return id, err
+	}
+	r.log(ctx).WithField("projectID", id).Info("Project deleted")
+	return id, nil
 }
 
-func (r *mutationResolver) CreateAchievement(ctx context.Context, projectID string) (*model.Achievement, error) {
+func (r *mutationResolver) CreateAchievement(ctx context.Context, projectID string, note *string) (*model.Achievement, error) {
+	if err := validateNote(note); err != nil {
+		return nil, err
+	}
+	p, err := r.store.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Archived {
+		return nil, fmt.Errorf("project %s is archived", projectID)
+	}
+
 	a := model.Achievement{
 		ID:        uuid.New().String(),
 		UserID:    "0",
 		ProjectID: projectID,
-		Start:     time.Now().Unix(),
+		Start:     int(time.Now().Unix()),
 		End:       0,
 	}
-	return &a, r.store.CreateAchievement(a)
+	if err := r.store.CreateAchievement(ctx, a); err != nil {
+		return &a, err
+	}
+	if note != nil && *note != "" {
+		if err := r.store.SetAchievementNote(ctx, a.ID, *note); err != nil {
+			return &a, err
+		}
+		a.Note = *note
+	}
+	r.log(ctx).WithField("achievementID", a.ID).Info("Achievement created")
+	return &a, nil
 }
 
 func (r *mutationResolver) UpdateAchievement(ctx context.Context, id string, input model.AchievementData) (*model.Achievement, error) {
-	a, err := r.store.UpdateAchievement(id, input)
-	return &a, err
+	if err := validateNote(input.Note); err != nil {
+		return nil, err
+	}
+	if err := r.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	a, err := r.store.UpdateAchievement(ctx, id, input)
+	if err != nil {
+		return &a, err
+	}
+	// The note is left as it is unless a new one is given
+	if input.Note != nil {
+		if err := r.store.SetAchievementNote(ctx, id, *input.Note); err != nil {
+			return &a, err
+		}
+		a.Note = *input.Note
+	}
+	r.log(ctx).WithField("achievementID", id).Info("Achievement updated")
+	return &a, nil
 }
 
 func (r *mutationResolver) DeleteAchievement(ctx context.Context, id string, projectID string) (string, error) {
-	return id, r.store.DeleteProject(id, pID)
+	if err := r.checkNotInvoiced(ctx, id); err != nil {
+		return id, err
+	}
+	if err := r.store.DeleteAchievement(ctx, id, projectID); err != nil {
+		return id, err
+	}
+	r.log(ctx).WithField("achievementID", id).Info("Achievement deleted")
+	return id, nil
+}
+
+func (r *mutationResolver) RestoreProject(ctx context.Context, id string) (*model.Project, error) {
+	p, err := r.store.RestoreProject(ctx, id, "0")
+	if err != nil {
+		return &p, err
+	}
+	r.log(ctx).WithField("projectID", id).Info("Project restored")
+	return &p, nil
+}
+
+func (r *mutationResolver) RestoreAchievement(ctx context.Context, id string) (*model.Achievement, error) {
+	a, err := r.store.RestoreAchievement(ctx, id, "0")
+	if err != nil {
+		return &a, err
+	}
+	r.log(ctx).WithField("achievementID", id).Info("Achievement restored")
+	return &a, nil
+}
+
+func (r *mutationResolver) Purge(ctx context.Context, id string) (string, error) {
+	items, err := r.store.GetTrash(ctx, "0")
+	if err != nil {
+		return id, err
+	}
+	for _, item := range items {
+		if item.ID != id {
+			continue
+		}
+		if item.EntityType == storage.EntityProject {
+			err = r.store.PurgeProject(ctx, id, "0")
+		} else {
+			err = r.store.PurgeAchievement(ctx, id, "0")
+		}
+		if err != nil {
+			return id, err
+		}
+		r.log(ctx).WithField("entityID", id).Info("Purged from trash")
+		return id, nil
+	}
+	return id, fmt.Errorf("%s is not in the trash", id)
+}
+
+func (r *mutationResolver) CreateCategory(ctx context.Context, input model.NewCategory) (*model.Category, error) {
+	if err := validateCategory(input); err != nil {
+		return nil, err
+	}
+	c := model.Category{
+		ID:     uuid.New().String(),
+		UserID: "0",
+		Name:   strings.TrimSpace(input.Name),
+		Color:  input.Color,
+		Icon:   input.Icon,
+		Order:  input.Order,
+	}
+	if err := r.store.CreateCategory(ctx, c); err != nil {
+		return &c, err
+	}
+	r.log(ctx).WithField("categoryID", c.ID).Info("Category created")
+	return &c, nil
+}
+
+func (r *mutationResolver) UpdateCategory(ctx context.Context, id string, input model.NewCategory) (*model.Category, error) {
+	if err := validateCategory(input); err != nil {
+		return nil, err
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	c, err := r.store.UpdateCategory(ctx, id, input)
+	if err != nil {
+		return &c, err
+	}
+	r.log(ctx).WithField("categoryID", id).Info("Category updated")
+	return &c, nil
+}
+
+func (r *mutationResolver) DeleteCategory(ctx context.Context, id string) (string, error) {
+	if err := r.store.DeleteCategory(ctx, id, "0"); err != nil {
+		return id, err
+	}
+	r.log(ctx).WithField("categoryID", id).Info("Category deleted")
+	return id, nil
+}
+
+func (r *mutationResolver) CreateTag(ctx context.Context, input model.NewTag) (*model.Tag, error) {
+	if err := validateTag(input); err != nil {
+		return nil, err
+	}
+	t := model.Tag{
+		ID:     uuid.New().String(),
+		UserID: "0",
+		Name:   strings.TrimSpace(input.Name),
+		Color:  input.Color,
+	}
+	if err := r.store.CreateTag(ctx, t); err != nil {
+		return &t, err
+	}
+	r.log(ctx).WithField("tagID", t.ID).Info("Tag created")
+	return &t, nil
+}
+
+func (r *mutationResolver) UpdateTag(ctx context.Context, id string, input model.NewTag) (*model.Tag, error) {
+	if err := validateTag(input); err != nil {
+		return nil, err
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	t, err := r.store.UpdateTag(ctx, id, input)
+	if err != nil {
+		return &t, err
+	}
+	r.log(ctx).WithField("tagID", id).Info("Tag updated")
+	return &t, nil
+}
+
+func (r *mutationResolver) DeleteTag(ctx context.Context, id string) (string, error) {
+	if err := r.store.DeleteTag(ctx, id, "0"); err != nil {
+		return id, err
+	}
+	r.log(ctx).WithField("tagID", id).Info("Tag deleted")
+	return id, nil
+}
+
+func (r *mutationResolver) TagAchievement(ctx context.Context, id string, tagID string) (*model.Achievement, error) {
+	if err := r.checkTag(ctx, tagID, "0"); err != nil {
+		return nil, err
+	}
+	if err := r.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := r.store.TagAchievement(ctx, id, tagID); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("achievementID", id).WithField("tagID", tagID).Info("Achievement tagged")
+	a, err := r.store.GetAchievement(ctx, id)
+	return &a, err
+}
+
+func (r *mutationResolver) UntagAchievement(ctx context.Context, id string, tagID string) (*model.Achievement, error) {
+	if err := r.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := r.store.UntagAchievement(ctx, id, tagID); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("achievementID", id).WithField("tagID", tagID).Info("Achievement untagged")
+	a, err := r.store.GetAchievement(ctx, id)
+	return &a, err
+}
+
+func (r *mutationResolver) TagProject(ctx context.Context, id string, tagID string) (*model.Project, error) {
+	if err := r.checkTag(ctx, tagID, "0"); err != nil {
+		return nil, err
+	}
+	if err := r.store.TagProject(ctx, id, tagID); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("projectID", id).WithField("tagID", tagID).Info("Project tagged")
+	p, err := r.store.GetProject(ctx, id)
+	return &p, err
+}
+
+func (r *mutationResolver) UntagProject(ctx context.Context, id string, tagID string) (*model.Project, error) {
+	if err := r.store.UntagProject(ctx, id, tagID); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("projectID", id).WithField("tagID", tagID).Info("Project untagged")
+	p, err := r.store.GetProject(ctx, id)
+	return &p, err
+}
+
+func (r *mutationResolver) SetAchievementBillable(ctx context.Context, id string, billable *bool) (*model.Achievement, error) {
+	if err := r.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := r.store.SetAchievementBillable(ctx, id, billable); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("achievementID", id).Info("Achievement billable set")
+	a, err := r.store.GetAchievement(ctx, id)
+	return &a, err
 }
 
-func (r *queryResolver) Projects(ctx context.Context) ([]*model.Project, error) {
-	all, err := r.store.GetUserProjects("0")
+func (r *mutationResolver) SetUserRate(ctx context.Context, rate *model.MoneyData) (*model.Money, error) {
+	if err := validateRate(rate); err != nil {
+		return nil, err
+	}
+	money := billing.RateFromData(rate)
+	if err := r.store.SetUserRate(ctx, "0", money); err != nil {
+		return nil, err
+	}
+	r.log(ctx).Info("User rate set")
+	return money, nil
+}
+
+func (r *mutationResolver) SetUserRounding(ctx context.Context, rounding *model.RoundingData) (*model.Rounding, error) {
+	if err := validateRounding(rounding); err != nil {
+		return nil, err
+	}
+	rule := roundingFromData(rounding)
+	if err := r.store.SetUserRounding(ctx, "0", rule); err != nil {
+		return nil, err
+	}
+	r.log(ctx).Info("User rounding set")
+	return rule, nil
+}
+
+func (r *mutationResolver) GenerateInvoice(ctx context.Context, projectIDs []string, from int, to int, rate *model.MoneyData, groupBy model.InvoiceGrouping) (*model.Invoice, error) {
+	if err := validateRate(rate); err != nil {
+		return nil, err
+	}
+	if to <= from {
+		return nil, fmt.Errorf("invoice period must end after it starts")
+	}
+	inv, err := invoice.Build(ctx, r.store, invoice.Request{
+		UserID:     "0",
+		ProjectIDs: projectIDs,
+		From:       from,
+		To:         to,
+		Rate:       billing.RateFromData(rate),
+		GroupBy:    groupBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	inv.ID = uuid.New().String()
+	inv.CreatedAt = int(time.Now().Unix())
+	inv, err = r.store.CreateInvoice(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("invoiceID", inv.ID).WithField("number", inv.Number).Info("Invoice generated")
+	return &inv, nil
+}
+
+func (r *mutationResolver) CreateExport(ctx context.Context, from int, to int, format model.ExportFormat) (*model.ExportToken, error) {
+	if err := export.Validate(from, to, format); err != nil {
+		return nil, err
+	}
+	t, err := export.NewToken("0", from, to, format, time.Now())
 	if err != nil {
 		return nil, err
 	}
+	if err := r.store.CreateExportToken(ctx, t); err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("format", format).Info("Export created")
+	return &t, nil
+}
+
+func (r *mutationResolver) ImportAchievements(ctx context.Context, file graphql.Upload, format *model.ImportFormat, categoryID string, timeZone string, dryRun bool) (*model.ImportResult, error) {
+	if format != nil && !format.IsValid() {
+		return nil, fmt.Errorf("invalid import format %q", *format)
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", timeZone)
+	}
+	if err := r.checkCategory(ctx, categoryID, "0"); err != nil {
+		return nil, err
+	}
+	res, err := importer.Import(ctx, r.store, file.File, importer.Options{
+		UserID:     "0",
+		Format:     format,
+		CategoryID: categoryID,
+		Location:   loc,
+		DryRun:     dryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log(ctx).WithField("file", file.Filename).WithField("created", res.Created).WithField("dryRun", dryRun).
+		Info("Achievements imported")
+	return res, nil
+}
+
+func (r *projectResolver) Category(ctx context.Context, obj *model.Project) (*model.Category, error) {
+	c, err := r.store.GetCategory(ctx, obj.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *projectResolver) Parent(ctx context.Context, obj *model.Project) (*model.Project, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	p, err := r.store.GetProject(ctx, *obj.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *projectResolver) Children(ctx context.Context, obj *model.Project) ([]*model.Project, error) {
+	all, err := r.store.GetChildProjects(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	ps := make([]*model.Project, len(all))
+	for i := range all {
+		ps[i] = &all[i]
+	}
+	return ps, nil
+}
+
+func (r *projectResolver) Total(ctx context.Context, obj *model.Project, from *int, to *int) (int, error) {
+	var f, t int
+	if from != nil {
+		f = *from
+	}
+	if to != nil {
+		t = *to
+	}
+
+	// The time of a project includes the time of all its descendants
+	ps, err := r.subtree(ctx, *obj)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	now := int(time.Now().Unix())
+	for _, p := range ps {
+		as, err := r.store.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return 0, err
+		}
+		for _, a := range as {
+			total += report.Duration(a.Start, a.End, f, t, now)
+		}
+	}
+	return total, nil
+}
+
+func (r *projectResolver) Tags(ctx context.Context, obj *model.Project) ([]*model.Tag, error) {
+	ts, err := r.store.GetProjectTags(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return tagSlice(ts), nil
+}
+
+func (r *projectResolver) Earnings(ctx context.Context, obj *model.Project, from *int, to *int) ([]*model.Money, error) {
+	var f, t int
+	if from != nil {
+		f = *from
+	}
+	if to != nil {
+		t = *to
+	}
+
+	// Like its total, the earnings of a project include the ones of all its descendants.
+	// Rounding applies to the billable time of each project.
+	ps, err := r.subtree(ctx, *obj)
+	if err != nil {
+		return nil, err
+	}
+	ledger := billing.Ledger{}
+	terms := billing.NewTerms(r.store, obj.UserID)
+	now := int(time.Now().Unix())
+	for _, p := range ps {
+		as, err := r.store.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		var billed []rounding.Entry
+		for _, a := range as {
+			if billing.Billable(a, p) {
+				billed = append(billed, report.Entry(a, f, t, now))
+			}
+		}
+		if len(billed) == 0 {
+			continue
+		}
+
+		rate, err := terms.RateOf(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		rule, err := terms.RoundingOf(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		ledger.Add(rate, rounding.Total(billed, rule))
+	}
+	return ledger.Earnings(), nil
+}
+
+func (r *projectResolver) RoundedTotal(ctx context.Context, obj *model.Project, from *int, to *int) (int, error) {
+	var f, t int
+	if from != nil {
+		f = *from
+	}
+	if to != nil {
+		t = *to
+	}
+
+	// The time of each project is rounded by its own rule, and then added up
+	ps, err := r.subtree(ctx, *obj)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	terms := billing.NewTerms(r.store, obj.UserID)
+	now := int(time.Now().Unix())
+	for _, p := range ps {
+		as, err := r.store.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return 0, err
+		}
+		if len(as) == 0 {
+			continue
+		}
+		entries := make([]rounding.Entry, len(as))
+		for i, a := range as {
+			entries[i] = report.Entry(a, f, t, now)
+		}
+		rule, err := terms.RoundingOf(ctx, p)
+		if err != nil {
+			return 0, err
+		}
+		total += rounding.Total(entries, rule)
+	}
+	return total, nil
+}
+
+func (r *queryResolver) Projects(ctx context.Context, includeArchived bool) ([]*model.Project, error) {
+	all, err := r.store.GetUserProjects(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	if includeArchived {
+		archived, err := r.store.GetArchivedProjects(ctx, "0")
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, archived...)
+	}
 	ps := make([]*model.Project, len(all))
 	for i := range all {
 		ps[i] = &all[i]
@@ -64,17 +630,20 @@ func (r *queryResolver) Projects(ctx context.Context) ([]*model.Project, error)
 }
 
 func (r *queryResolver) Project(ctx context.Context, id string) (*model.Project, error) {
-	p, err := r.store.GetProject(id)
+	p, err := r.store.GetProject(ctx, id)
 	return &p, err
 }
 
 func (r *queryResolver) Achievement(ctx context.Context, id string) (*model.Achievement, error) {
-	a, err := r.store.GetAchievement(id)
+	a, err := r.store.GetAchievement(ctx, id)
 	return &a, err
 }
 
-func (r *queryResolver) ProjectAchievements(ctx context.Context, projectID string) ([]*model.Achievement, error) {
-	all, err := r.store.GetProjectAchievements(projectID)
+func (r *queryResolver) ProjectAchievements(ctx context.Context, projectID string, tags []string) ([]*model.Achievement, error) {
+	if len(tags) > 0 {
+		return r.taggedAchievements(ctx, tags, func(a model.Achievement) bool { return a.ProjectID == projectID })
+	}
+	all, err := r.store.GetProjectAchievements(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,32 +654,226 @@ func (r *queryResolver) ProjectAchievements(ctx context.Context, projectID strin
 	return as, nil
 }
 
-func (r *queryResolver) UserAchievements(ctx context.Context) ([]*model.Achievement, error) {
+func (r *queryResolver) UserAchievements(ctx context.Context, tags []string) ([]*model.Achievement, error) {
 	uID := "0"
-	projects, err := r.store.GetUserProjects(uID)
+	if len(tags) > 0 {
+		return r.taggedAchievements(ctx, tags, func(a model.Achievement) bool { return a.UserID == uID })
+	}
+	all, err := r.store.GetUserAchievements(ctx, uID)
 	if err != nil {
 		return nil, err
 	}
+	as := make([]*model.Achievement, len(all))
+	for i := range all {
+		as[i] = &all[i]
+	}
+	return as, nil
+}
 
-	as := []*model.Achievement{}
-	for p := range projects {
-		pAs, err := r.store.GetProjectAchievements(p.ID)
+func (r *queryResolver) AuditLog(ctx context.Context, entityID string, from *int, to *int) ([]*model.AuditEntry, error) {
+	var f, t int
+	if from != nil {
+		f = *from
+	}
+	if to != nil {
+		t = *to
+	}
+	all, err := r.store.GetAuditLog(ctx, entityID, f, t)
+	if err != nil {
+		return nil, err
+	}
+	es := make([]*model.AuditEntry, len(all))
+	for i := range all {
+		es[i] = &all[i]
+	}
+	return es, nil
+}
+
+func (r *queryResolver) Trash(ctx context.Context) ([]*model.TrashItem, error) {
+	all, err := r.store.GetTrash(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*model.TrashItem, len(all))
+	for i := range all {
+		items[i] = &all[i]
+	}
+	return items, nil
+}
+
+func (r *queryResolver) Categories(ctx context.Context) ([]*model.Category, error) {
+	all, err := r.store.GetUserCategories(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	cs := make([]*model.Category, len(all))
+	for i := range all {
+		cs[i] = &all[i]
+	}
+	return cs, nil
+}
+
+func (r *queryResolver) CategoryTotals(ctx context.Context, from *int, to *int) ([]*model.CategoryTotal, error) {
+	var f, t int
+	if from != nil {
+		f = *from
+	}
+	if to != nil {
+		t = *to
+	}
+
+	cs, err := r.store.GetUserCategories(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	ps, err := r.store.GetUserProjects(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	archived, err := r.store.GetArchivedProjects(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	as, err := r.store.GetUserAchievements(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+
+	projects := map[string]model.Project{}
+	for _, p := range append(ps, archived...) {
+		projects[p.ID] = p
+	}
+	now := int(time.Now().Unix())
+	entries := map[string][]rounding.Entry{}
+	billed := map[string][]rounding.Entry{}
+	for _, a := range as {
+		e := report.Entry(a, f, t, now)
+		entries[a.ProjectID] = append(entries[a.ProjectID], e)
+		if billing.Billable(a, projects[a.ProjectID]) {
+			billed[a.ProjectID] = append(billed[a.ProjectID], e)
+		}
+	}
+
+	// Time is rounded per project, by the project's rule, and then added up per category
+	seconds := map[string]int{}
+	rounded := map[string]int{}
+	ledgers := map[string]billing.Ledger{}
+	terms := billing.NewTerms(r.store, "0")
+	for pID, es := range entries {
+		p := projects[pID]
+		rule, err := terms.RoundingOf(ctx, p)
 		if err != nil {
 			return nil, err
 		}
-		for a := range pAs {
-			append(as, &a)
+		for _, e := range es {
+			seconds[p.CategoryID] += e.Seconds
+		}
+		rounded[p.CategoryID] += rounding.Total(es, rule)
+
+		if len(billed[pID]) == 0 {
+			continue
 		}
+		rate, err := terms.RateOf(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		if ledgers[p.CategoryID] == nil {
+			ledgers[p.CategoryID] = billing.Ledger{}
+		}
+		ledgers[p.CategoryID].Add(rate, rounding.Total(billed[pID], rule))
+	}
+
+	totals := make([]*model.CategoryTotal, len(cs))
+	for i := range cs {
+		totals[i] = &model.CategoryTotal{
+			Category:       &cs[i],
+			Seconds:        seconds[cs[i].ID],
+			RoundedSeconds: rounded[cs[i].ID],
+			Earnings:       ledgers[cs[i].ID].Earnings(),
+		}
+	}
+	return totals, nil
+}
+
+func (r *queryResolver) Tags(ctx context.Context) ([]*model.Tag, error) {
+	ts, err := r.store.GetUserTags(ctx, "0")
+	if err != nil {
+		return nil, err
 	}
+	return tagSlice(ts), nil
+}
 
+func (r *queryResolver) SearchAchievements(ctx context.Context, text string) ([]*model.Achievement, error) {
+	all, err := r.store.SearchAchievements(ctx, "0", text)
+	if err != nil {
+		return nil, err
+	}
+	as := make([]*model.Achievement, len(all))
+	for i := range all {
+		as[i] = &all[i]
+	}
 	return as, nil
 }
 
+func (r *queryResolver) UserRate(ctx context.Context) (*model.Money, error) {
+	return r.store.GetUserRate(ctx, "0")
+}
+
+func (r *queryResolver) UserRounding(ctx context.Context) (*model.Rounding, error) {
+	return r.store.GetUserRounding(ctx, "0")
+}
+
+func (r *queryResolver) Invoices(ctx context.Context) ([]*model.Invoice, error) {
+	all, err := r.store.GetUserInvoices(ctx, "0")
+	if err != nil {
+		return nil, err
+	}
+	invs := make([]*model.Invoice, len(all))
+	for i := range all {
+		invs[i] = &all[i]
+	}
+	return invs, nil
+}
+
+func (r *queryResolver) Invoice(ctx context.Context, id string) (*model.Invoice, error) {
+	inv, err := r.store.GetInvoice(ctx, id)
+	return &inv, err
+}
+
+func (r *queryResolver) Report(ctx context.Context, from int, to int, groupBy []model.ReportGrouping, filter *model.ReportFilter) (*model.Report, error) {
+	req := report.Request{UserID: "0", From: from, To: to, GroupBy: groupBy, Now: int(time.Now().Unix())}
+	if filter != nil {
+		req.Filter = *filter
+	}
+	return report.Build(ctx, r.store, req)
+}
+
+func (r *queryResolver) TimeSeries(ctx context.Context, from int, to int, bucket model.TimeBucket, groupBy *model.ReportGrouping) (*model.TimeSeries, error) {
+	return report.Series(ctx, r.store, report.SeriesRequest{UserID: "0", From: from, To: to, Bucket: bucket,
+		GroupBy: groupBy, Now: int(time.Now().Unix())})
+}
+
+func (r *queryResolver) Heatmap(ctx context.Context, year int) ([]*model.HeatmapDay, error) {
+	return report.Heatmap(ctx, r.store, "0", year, int(time.Now().Unix()))
+}
+
+func (r *queryResolver) HoursOfWeek(ctx context.Context, from int, to int) ([]*model.HourOfWeek, error) {
+	return report.HoursOfWeek(ctx, r.store, "0", from, to, int(time.Now().Unix()))
+}
+
+// Achievement returns generated.AchievementResolver implementation.
+func (r *Resolver) Achievement() generated.AchievementResolver { return &achievementResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
+// Project returns generated.ProjectResolver implementation.
+func (r *Resolver) Project() generated.ProjectResolver { return &projectResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+type achievementResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type projectResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
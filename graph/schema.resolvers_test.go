@@ -3,9 +3,13 @@ package graph
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
 	"github.com/smeruelo/glow/storage/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,23 +17,25 @@ import (
 
 func TestCreateProjectSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
 	np := model.NewProject{
-		Name:     "Test",
-		Category: "Default",
+		Name:       "Test",
+		CategoryID: &cID,
 	}
 	p := model.Project{
-		ID:       "",
-		UserID:   "0",
-		Name:     "Test",
-		Category: "Default",
+		ID:         "",
+		UserID:     "0",
+		Name:       "Test",
+		CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
 	}
 	expected := &p
 
-	s.On("CreateProject", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
-		project := args.Get(0).(model.Project)
+	s.On("GetCategory", ctx, *np.CategoryID).Return(model.Category{ID: *np.CategoryID, UserID: "0"}, nil)
+	s.On("CreateProject", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		project := args.Get(1).(model.Project)
 		p.ID = project.ID
 	})
 
@@ -42,15 +48,17 @@ func TestCreateProjectSuccess(t *testing.T) {
 
 func TestCreateProjectFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
 	np := model.NewProject{
-		Name:     "Test",
-		Category: "Default",
+		Name:       "Test",
+		CategoryID: &cID,
 	}
 
-	s.On("CreateProject", mock.Anything).Return(errors.New(""))
+	s.On("GetCategory", ctx, *np.CategoryID).Return(model.Category{ID: *np.CategoryID, UserID: "0"}, nil)
+	s.On("CreateProject", ctx, mock.Anything).Return(errors.New(""))
 
 	_, err := r.CreateProject(ctx, np)
 
@@ -60,19 +68,19 @@ func TestCreateProjectFail(t *testing.T) {
 
 func TestProjectSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &queryResolver{Resolver: NewResolver(&s)}
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	p := model.Project{
-		ID:       pID,
-		UserID:   "0",
-		Name:     "Test",
-		Category: "Default",
+		ID:         pID,
+		UserID:     "0",
+		Name:       "Test",
+		CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
 	}
 	expected := &p
 
-	s.On("GetProject", pID).Return(p, nil)
+	s.On("GetProject", ctx, pID).Return(p, nil)
 
 	actual, err := r.Project(ctx, pID)
 
@@ -83,12 +91,12 @@ func TestProjectSuccess(t *testing.T) {
 
 func TestProjectFail(t *testing.T) {
 	var s mocks.Store
-	r := &queryResolver{Resolver: NewResolver(&s)}
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 
-	s.On("GetProject", pID).Return(model.Project{}, errors.New(""))
+	s.On("GetProject", ctx, pID).Return(model.Project{}, errors.New(""))
 
 	_, err := r.Project(ctx, pID)
 
@@ -98,27 +106,58 @@ func TestProjectFail(t *testing.T) {
 
 func TestProjectsSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &queryResolver{Resolver: NewResolver(&s)}
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	p1 := model.Project{
-		ID:       "3b054f50-9d3d-4114-bfc4-000000000001",
-		UserID:   "0",
-		Name:     "Test 1",
-		Category: "Default",
+		ID:         "3b054f50-9d3d-4114-bfc4-000000000001",
+		UserID:     "0",
+		Name:       "Test 1",
+		CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
 	}
 	p2 := model.Project{
-		ID:       "3b054f50-9d3d-4114-bfc4-000000000002",
-		UserID:   "0",
-		Name:     "Test 2",
-		Category: "Programming",
+		ID:         "3b054f50-9d3d-4114-bfc4-000000000002",
+		UserID:     "0",
+		Name:       "Test 2",
+		CategoryID: "16fd2706-8baf-433b-82eb-8c7fada847da",
 	}
 	uID := "0"
 	expected := []*model.Project{&p1, &p2}
 
-	s.On("GetUserProjects", uID).Return([]model.Project{p1, p2}, nil)
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{p1, p2}, nil)
 
-	actual, err := r.Projects(ctx)
+	actual, err := r.Projects(ctx, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestProjectsIncludeArchived(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	p1 := model.Project{
+		ID:         "3b054f50-9d3d-4114-bfc4-000000000001",
+		UserID:     "0",
+		Name:       "Test 1",
+		CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	}
+	p2 := model.Project{
+		ID:         "3b054f50-9d3d-4114-bfc4-000000000002",
+		UserID:     "0",
+		Name:       "Test 2",
+		CategoryID: "16fd2706-8baf-433b-82eb-8c7fada847da",
+		Archived:   true,
+	}
+	uID := "0"
+	expected := []*model.Project{&p1, &p2}
+
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{p1}, nil)
+	s.On("GetArchivedProjects", ctx, uID).Return([]model.Project{p2}, nil)
+
+	actual, err := r.Projects(ctx, true)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
@@ -127,14 +166,14 @@ func TestProjectsSuccess(t *testing.T) {
 
 func TestProjectsFail(t *testing.T) {
 	var s mocks.Store
-	r := &queryResolver{Resolver: NewResolver(&s)}
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	uID := "0"
 
-	s.On("GetUserProjects", uID).Return([]model.Project{}, errors.New(""))
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{}, errors.New(""))
 
-	_, err := r.Projects(ctx)
+	_, err := r.Projects(ctx, false)
 
 	assert.Error(t, err)
 	s.AssertExpectations(t)
@@ -142,23 +181,25 @@ func TestProjectsFail(t *testing.T) {
 
 func TestUpdateProjectSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	p := model.Project{
-		ID:       pID,
-		UserID:   "0",
-		Name:     "Test",
-		Category: "Reading",
+		ID:         pID,
+		UserID:     "0",
+		Name:       "Test",
+		CategoryID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
 	}
+	cID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
 	np := model.NewProject{
-		Name:     "Test",
-		Category: "Reading",
+		Name:       "Test",
+		CategoryID: &cID,
 	}
 	expected := &p
 
-	s.On("UpdateProject", pID, np).Return(p, nil)
+	s.On("GetCategory", ctx, *np.CategoryID).Return(model.Category{ID: *np.CategoryID, UserID: "0"}, nil)
+	s.On("UpdateProject", ctx, pID, np).Return(p, nil)
 
 	actual, err := r.UpdateProject(ctx, pID, np)
 
@@ -169,17 +210,19 @@ func TestUpdateProjectSuccess(t *testing.T) {
 
 func TestUpdateProjectFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	cID := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
 	np := model.NewProject{
-		Name:     "Test",
-		Category: "Reading",
+		Name:       "Test",
+		CategoryID: &cID,
 	}
 	var p model.Project
 
-	s.On("UpdateProject", pID, np).Return(p, errors.New(""))
+	s.On("GetCategory", ctx, *np.CategoryID).Return(model.Category{ID: *np.CategoryID, UserID: "0"}, nil)
+	s.On("UpdateProject", ctx, pID, np).Return(p, errors.New(""))
 
 	_, err := r.UpdateProject(ctx, pID, np)
 
@@ -189,14 +232,14 @@ func TestUpdateProjectFail(t *testing.T) {
 
 func TestDeleteProjectSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	uID := "0"
 	expected := pID
 
-	s.On("DeleteProject", pID, uID).Return(nil)
+	s.On("DeleteProject", ctx, pID, uID).Return(nil)
 
 	actual, err := r.DeleteProject(ctx, pID)
 
@@ -207,13 +250,13 @@ func TestDeleteProjectSuccess(t *testing.T) {
 
 func TestDeleteProjectFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	uID := "0"
 
-	s.On("DeleteProject", pID, uID).Return(errors.New(""))
+	s.On("DeleteProject", ctx, pID, uID).Return(errors.New(""))
 
 	_, err := r.DeleteProject(ctx, pID)
 
@@ -223,7 +266,7 @@ func TestDeleteProjectFail(t *testing.T) {
 
 func TestCreateAchievementSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
@@ -236,12 +279,14 @@ func TestCreateAchievementSuccess(t *testing.T) {
 	}
 	expected := &a
 
-	s.On("CreateAchievement", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
-		ach := args.Get(0).(model.Achievement)
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID}, nil)
+	s.On("CreateAchievement", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		ach := args.Get(1).(model.Achievement)
 		a.ID = ach.ID
 	})
 
-	actual, err := r.CreateAchievement(pID)
+	actual, err := r.CreateAchievement(ctx, pID, nil)
+	a.Start = actual.Start
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
@@ -250,14 +295,15 @@ func TestCreateAchievementSuccess(t *testing.T) {
 
 func TestCreateAchievementFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 
-	s.On("CreateAchievement", mock.Anything).Return(errors.New(""))
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID}, nil)
+	s.On("CreateAchievement", ctx, mock.Anything).Return(errors.New(""))
 
-	err := r.CreateAchievement(pID)
+	_, err := r.CreateAchievement(ctx, pID, nil)
 
 	assert.Error(t, err)
 	s.AssertExpectations(t)
@@ -265,7 +311,7 @@ func TestCreateAchievementFail(t *testing.T) {
 
 func TestAchievementSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
@@ -278,7 +324,7 @@ func TestAchievementSuccess(t *testing.T) {
 	}
 	expected := &a
 
-	s.On("GetAchievement", aID).Return(a, nil)
+	s.On("GetAchievement", ctx, aID).Return(a, nil)
 
 	actual, err := r.Query().Achievement(ctx, aID)
 
@@ -289,12 +335,12 @@ func TestAchievementSuccess(t *testing.T) {
 
 func TestAchievementFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 
-	s.On("GetAchievement", aID).Return(a, nil)
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{}, errors.New(""))
 
 	_, err := r.Query().Achievement(ctx, aID)
 
@@ -304,7 +350,7 @@ func TestAchievementFail(t *testing.T) {
 
 func TestProjectAchievementsSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
@@ -324,9 +370,9 @@ func TestProjectAchievementsSuccess(t *testing.T) {
 	}
 	expected := []*model.Achievement{&a1, &a2}
 
-	s.On("GetProjectAchievements", pID).Return([]model.Achievement{&a1, &a2}, nil)
+	s.On("GetProjectAchievements", ctx, pID).Return([]model.Achievement{a1, a2}, nil)
 
-	actual, err := r.Query().ProjectAchievements(ctx, pID)
+	actual, err := r.Query().ProjectAchievements(ctx, pID, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
@@ -335,22 +381,22 @@ func TestProjectAchievementsSuccess(t *testing.T) {
 
 func TestProjectAchievementsFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	pID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
 
-	s.On("GetProjectAchievements", pID).Return([]model.Achievement{}, errors.New(""))
+	s.On("GetProjectAchievements", ctx, pID).Return([]model.Achievement{}, errors.New(""))
 
-	_, err := r.Query().ProjectAchievements(ctx, pID)
+	_, err := r.Query().ProjectAchievements(ctx, pID, nil)
 
 	assert.Error(t, err)
 	s.AssertExpectations(t)
 }
 
-func TestUserAchievementsSucces(t *testingT) {
+func TestUserAchievementsSucces(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	uID := "0"
@@ -370,33 +416,33 @@ func TestUserAchievementsSucces(t *testingT) {
 	}
 	expected := []*model.Achievement{&a1, &a2}
 
-	s.On("GetUserAchievements", uID).Return([]model.Achievement{&a1, &a2}, nil)
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{a1, a2}, nil)
 
-	actual, err := r.Query().UserAchievements(ctx, uID)
+	actual, err := r.Query().UserAchievements(ctx, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
 	s.AssertExpectations(t)
 }
 
-func TestUserAchievementsFail(t *testingT) {
+func TestUserAchievementsFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	uID := "0"
 
-	s.On("GetUserAchievements", uID).Return([]model.Achievement{}, errors.New(""))
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{}, errors.New(""))
 
-	_, err := r.Query().UserAchievements(ctx, uID)
+	_, err := r.Query().UserAchievements(ctx, nil)
 
 	assert.Error(t, err)
 	s.AssertExpectations(t)
 }
 
-func TestUpdateAchievementSuccess(t *testingT) {
+func TestUpdateAchievementSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
@@ -414,7 +460,8 @@ func TestUpdateAchievementSuccess(t *testingT) {
 	}
 	expected := &a
 
-	s.On("UpdateAchievement", aID, ad).Return(a, nil)
+	s.On("GetAchievement", ctx, aID).Return(a, nil)
+	s.On("UpdateAchievement", ctx, aID, ad).Return(a, nil)
 
 	actual, err := r.Mutation().UpdateAchievement(ctx, aID, ad)
 
@@ -423,9 +470,9 @@ func TestUpdateAchievementSuccess(t *testingT) {
 	s.AssertExpectations(t)
 }
 
-func TestUpdateAchievementFail(t *testingT) {
+func TestUpdateAchievementFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
@@ -435,7 +482,8 @@ func TestUpdateAchievementFail(t *testingT) {
 		End:       1598342861,
 	}
 
-	s.On("UpdateAchievement", aID, ad).Return(model.Achievement{}, errors.New(""))
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{ID: aID}, nil)
+	s.On("UpdateAchievement", ctx, aID, ad).Return(model.Achievement{}, errors.New(""))
 
 	_, err := r.Mutation().UpdateAchievement(ctx, aID, ad)
 
@@ -443,16 +491,17 @@ func TestUpdateAchievementFail(t *testingT) {
 	s.AssertExpectations(t)
 }
 
-func TestDeleteAchievementSuccess(t *testingT) {
+func TestDeleteAchievementSuccess(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	pID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
 	expected := aID
 
-	s.On("DeleteAchievement", aID, pID).Return(nil)
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{ID: aID, ProjectID: pID}, nil)
+	s.On("DeleteAchievement", ctx, aID, pID).Return(nil)
 
 	actual, err := r.Mutation().DeleteAchievement(ctx, aID, pID)
 
@@ -461,18 +510,858 @@ func TestDeleteAchievementSuccess(t *testingT) {
 	s.AssertExpectations(t)
 }
 
-func TestDeleteAchievementFail(t *testingT) {
+func TestDeleteAchievementFail(t *testing.T) {
 	var s mocks.Store
-	r := &mutationResolver{Resolver: NewResolver(&s)}
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
 	ctx := context.Background()
 
 	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
 	pID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
 
-	s.On("DeleteAchievement", aID, pID).Return(errors.New(""))
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{ID: aID, ProjectID: pID}, nil)
+	s.On("DeleteAchievement", ctx, aID, pID).Return(errors.New(""))
 
 	_, err := r.Mutation().DeleteAchievement(ctx, aID, pID)
 
 	assert.Error(t, err)
 	s.AssertExpectations(t)
 }
+
+func TestAuditLogSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	from := 1600000000
+	e1 := model.AuditEntry{ID: "1600000000000-0", Operation: "CreateProject", EntityID: pID, Timestamp: 1600000000}
+	e2 := model.AuditEntry{ID: "1600000060000-0", Operation: "UpdateProject", EntityID: pID, Timestamp: 1600000060}
+	expected := []*model.AuditEntry{&e1, &e2}
+
+	s.On("GetAuditLog", ctx, pID, from, 0).Return([]model.AuditEntry{e1, e2}, nil)
+
+	actual, err := r.AuditLog(ctx, pID, &from, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestAuditLogFail(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+
+	s.On("GetAuditLog", ctx, pID, 0, 0).Return(nil, errors.New(""))
+
+	_, err := r.AuditLog(ctx, pID, nil, nil)
+
+	assert.Error(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestTrashSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	i := model.TrashItem{ID: pID, EntityType: storage.EntityProject, DeletedAt: 1600000000, Project: &model.Project{ID: pID}}
+	expected := []*model.TrashItem{&i}
+
+	s.On("GetTrash", ctx, "0").Return([]model.TrashItem{i}, nil)
+
+	actual, err := r.Trash(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestRestoreProjectSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	p := model.Project{ID: pID, UserID: "0", Name: "Test", CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}
+	expected := &p
+
+	s.On("RestoreProject", ctx, pID, "0").Return(p, nil)
+
+	actual, err := r.RestoreProject(ctx, pID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestRestoreAchievementFail(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+
+	s.On("RestoreAchievement", ctx, aID, "0").Return(model.Achievement{}, errors.New(""))
+
+	_, err := r.RestoreAchievement(ctx, aID)
+
+	assert.Error(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestPurgeSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	aID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
+
+	s.On("GetTrash", ctx, "0").Return([]model.TrashItem{
+		{ID: pID, EntityType: storage.EntityProject},
+		{ID: aID, EntityType: storage.EntityAchievement},
+	}, nil)
+	s.On("PurgeAchievement", ctx, aID, "0").Return(nil)
+
+	actual, err := r.Purge(ctx, aID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, aID, actual)
+	s.AssertExpectations(t)
+}
+
+func TestPurgeNotInTrash(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+
+	s.On("GetTrash", ctx, "0").Return([]model.TrashItem{}, nil)
+
+	_, err := r.Purge(ctx, pID)
+
+	assert.EqualError(t, err, pID+" is not in the trash")
+	s.AssertExpectations(t)
+}
+
+func TestArchiveProjectSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	p := model.Project{ID: pID, UserID: "0", Name: "Test", CategoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Archived: true}
+	expected := &p
+
+	s.On("ArchiveProject", ctx, pID, "0").Return(p, nil)
+
+	actual, err := r.ArchiveProject(ctx, pID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestUnarchiveProjectFail(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+
+	s.On("UnarchiveProject", ctx, pID, "0").Return(model.Project{}, errors.New(""))
+
+	_, err := r.UnarchiveProject(ctx, pID)
+
+	assert.Error(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestCreateAchievementArchivedProject(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, Archived: true}, nil)
+
+	_, err := r.CreateAchievement(ctx, pID, nil)
+
+	assert.EqualError(t, err, "project "+pID+" is archived")
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "CreateAchievement", ctx, mock.Anything)
+}
+
+func TestCreateProjectUnknownCategory(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	np := model.NewProject{
+		Name:       "Test",
+		CategoryID: &cID,
+	}
+
+	s.On("GetCategory", ctx, cID).Return(model.Category{ID: cID, UserID: "1"}, nil)
+
+	_, err := r.CreateProject(ctx, np)
+
+	assert.EqualError(t, err, "category "+cID+" does not exist")
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "CreateProject", ctx, mock.Anything)
+}
+
+func TestCreateProjectDefaultCategory(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	s.On("GetUserCategories", ctx, "0").Return([]model.Category{
+		{ID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", UserID: "0", Name: "Work"},
+		{ID: cID, UserID: "0", Name: "uncategorized", Order: 1},
+	}, nil)
+	s.On("CreateProject", ctx, mock.MatchedBy(func(p model.Project) bool { return p.CategoryID == cID })).Return(nil)
+
+	p, err := r.CreateProject(ctx, model.NewProject{Name: "Test"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, cID, p.CategoryID)
+	s.AssertExpectations(t)
+}
+
+func TestCreateProjectCreatesDefaultCategory(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	var created model.Category
+	s.On("GetUserCategories", ctx, "0").Return([]model.Category{
+		{ID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", UserID: "0", Name: "Work"},
+	}, nil)
+	s.On("CreateCategory", ctx, mock.MatchedBy(func(c model.Category) bool {
+		return c.UserID == "0" && c.Name == "Uncategorized" && c.Order == 1
+	})).Return(nil).Run(func(args mock.Arguments) {
+		created = args.Get(1).(model.Category)
+	})
+	s.On("CreateProject", ctx, mock.Anything).Return(nil)
+
+	p, err := r.CreateProject(ctx, model.NewProject{Name: "Test"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, p.CategoryID)
+	s.AssertExpectations(t)
+}
+
+func TestCreateCategorySuccess(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	nc := model.NewCategory{
+		Name:  " Work ",
+		Color: "#ff8800",
+		Icon:  "briefcase",
+		Order: 2,
+	}
+	c := model.Category{
+		UserID: "0",
+		Name:   "Work",
+		Color:  "#ff8800",
+		Icon:   "briefcase",
+		Order:  2,
+	}
+	expected := &c
+
+	s.On("CreateCategory", ctx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		c.ID = args.Get(1).(model.Category).ID
+	})
+
+	actual, err := r.CreateCategory(ctx, nc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCreateCategoryInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		nc   model.NewCategory
+		err  string
+	}{
+		{"empty name", model.NewCategory{Name: "  "}, "category name can't be empty"},
+		{"color", model.NewCategory{Name: "Work", Color: "orange"}, `invalid color "orange", expected #rrggbb`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s mocks.Store
+			r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+
+			_, err := r.CreateCategory(context.Background(), tt.nc)
+
+			assert.EqualError(t, err, tt.err)
+			s.AssertNotCalled(t, "CreateCategory", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateCategorySuccess(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	nc := model.NewCategory{Name: "Reading", Color: "#00aa00"}
+	c := model.Category{ID: cID, UserID: "0", Name: "Reading", Color: "#00aa00"}
+	expected := &c
+
+	s.On("UpdateCategory", ctx, cID, nc).Return(c, nil)
+
+	actual, err := r.UpdateCategory(ctx, cID, nc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestDeleteCategoryFail(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	s.On("DeleteCategory", ctx, cID, "0").Return(errors.New(`category "Work" has projects`))
+
+	_, err := r.DeleteCategory(ctx, cID)
+
+	assert.Error(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestProjectCategory(t *testing.T) {
+	var s mocks.Store
+	r := &projectResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	c := model.Category{ID: cID, UserID: "0", Name: "Work"}
+	expected := &c
+
+	s.On("GetCategory", ctx, cID).Return(c, nil)
+
+	actual, err := r.Category(ctx, &model.Project{CategoryID: cID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCategoriesSuccess(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	c1 := model.Category{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: "0", Name: "Work"}
+	c2 := model.Category{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", UserID: "0", Name: "Reading", Order: 1}
+	expected := []*model.Category{&c1, &c2}
+
+	s.On("GetUserCategories", ctx, "0").Return([]model.Category{c1, c2}, nil)
+
+	actual, err := r.Categories(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCategoryTotals(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	c1 := model.Category{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: "0", Name: "Work"}
+	c2 := model.Category{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", UserID: "0", Name: "Reading", Order: 1}
+	p1 := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: "0", CategoryID: c1.ID}
+	p2 := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: "0", CategoryID: c1.ID, Archived: true}
+	from, to := 1600000000, 1600010000
+
+	s.On("GetUserCategories", ctx, "0").Return([]model.Category{c1, c2}, nil)
+	s.On("GetUserProjects", ctx, "0").Return([]model.Project{p1}, nil)
+	s.On("GetArchivedProjects", ctx, "0").Return([]model.Project{p2}, nil)
+	s.On("GetUserAchievements", ctx, "0").Return([]model.Achievement{
+		{ProjectID: p1.ID, Start: 1599999000, End: 1600001000},
+		{ProjectID: p2.ID, Start: 1600002000, End: 1600003800},
+		{ProjectID: p1.ID, Start: 1590000000, End: 1590003600},
+	}, nil)
+	s.On("GetUserRounding", ctx, "0").Return(&model.Rounding{
+		Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry,
+	}, nil).Once()
+
+	actual, err := r.CategoryTotals(ctx, &from, &to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.CategoryTotal{
+		{Category: &c1, Seconds: 2800, RoundedSeconds: 3600, Earnings: []*model.Money{}},
+		{Category: &c2, Seconds: 0, RoundedSeconds: 0, Earnings: []*model.Money{}},
+	}, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCreateProjectArchivedParent(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	parentID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	np := model.NewProject{
+		Name:       "Backend",
+		CategoryID: &cID,
+		ParentID:   &parentID,
+	}
+
+	s.On("GetCategory", ctx, cID).Return(model.Category{ID: cID, UserID: "0"}, nil)
+	s.On("GetProject", ctx, parentID).Return(model.Project{ID: parentID, UserID: "0", Archived: true}, nil)
+
+	_, err := r.CreateProject(ctx, np)
+
+	assert.EqualError(t, err, "project "+parentID+" is archived")
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "CreateProject", ctx, mock.Anything)
+}
+
+func TestProjectParent(t *testing.T) {
+	var s mocks.Store
+	r := &projectResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	parentID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	parent := model.Project{ID: parentID, UserID: "0", Name: "Website"}
+	expected := &parent
+
+	s.On("GetProject", ctx, parentID).Return(parent, nil)
+
+	actual, err := r.Parent(ctx, &model.Project{ParentID: &parentID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	actual, err = r.Parent(ctx, &model.Project{})
+
+	assert.NoError(t, err)
+	assert.Nil(t, actual)
+	s.AssertExpectations(t)
+}
+
+func TestProjectTotalRollsUp(t *testing.T) {
+	var s mocks.Store
+	r := &projectResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: "0"}
+	child := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: "0", ParentID: &p.ID}
+	grandchild := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: "0", ParentID: &child.ID}
+	from, to := 1600000000, 1600010000
+
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ProjectID: p.ID, Start: 1599999000, End: 1600001000},
+	}, nil)
+	s.On("GetProjectAchievements", ctx, child.ID).Return([]model.Achievement{}, nil)
+	s.On("GetProjectAchievements", ctx, grandchild.ID).Return([]model.Achievement{
+		{ProjectID: grandchild.ID, Start: 1600002000, End: 1600003800},
+		{ProjectID: grandchild.ID, Start: 1590000000, End: 1590003600},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{child}, nil)
+	s.On("GetChildProjects", ctx, child.ID).Return([]model.Project{grandchild}, nil)
+	s.On("GetChildProjects", ctx, grandchild.ID).Return([]model.Project{}, nil)
+
+	actual, err := r.Total(ctx, &p, &from, &to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2800, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCreateTagInvalid(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	_, err := r.CreateTag(ctx, model.NewTag{Name: " "})
+	assert.EqualError(t, err, "tag name can't be empty")
+
+	_, err = r.CreateTag(ctx, model.NewTag{Name: "meeting", Color: "blue"})
+	assert.EqualError(t, err, `invalid color "blue", expected #rrggbb`)
+
+	s.AssertNotCalled(t, "CreateTag", ctx, mock.Anything)
+}
+
+func TestTagAchievementOtherUsersTag(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a00001"
+	tID := "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5"
+
+	s.On("GetTag", ctx, tID).Return(model.Tag{ID: tID, UserID: "1"}, nil)
+
+	_, err := r.TagAchievement(ctx, aID, tID)
+
+	assert.EqualError(t, err, "tag "+tID+" does not exist")
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "TagAchievement", ctx, aID, tID)
+}
+
+func TestProjectAchievementsTagged(t *testing.T) {
+	var s mocks.Store
+	r := &queryResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "b1265627-d9f2-4a0b-b60d-322273b00001"
+	tags := []string{"5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5", "0f8b2b9e-3c1d-4e5f-a6b7-c8d9e0f1a2b3"}
+	a1 := model.Achievement{ID: "3b054f50-9d3d-4114-bfc4-395f70a00001", UserID: "0", ProjectID: pID}
+	a2 := model.Achievement{ID: "3b054f50-9d3d-4114-bfc4-395f70a00002", UserID: "0", ProjectID: "b1265627-d9f2-4a0b-b60d-322273b00002"}
+	expected := []*model.Achievement{&a1}
+
+	s.On("GetTaggedAchievements", ctx, tags).Return([]model.Achievement{a1, a2}, nil)
+
+	actual, err := r.ProjectAchievements(ctx, pID, tags)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "GetProjectAchievements", ctx, pID)
+}
+
+func TestAchievementTags(t *testing.T) {
+	var s mocks.Store
+	r := &achievementResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a00001"
+	t1 := model.Tag{ID: "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5", UserID: "0", Name: "deep work"}
+	t2 := model.Tag{ID: "0f8b2b9e-3c1d-4e5f-a6b7-c8d9e0f1a2b3", UserID: "0", Name: "meeting"}
+	expected := []*model.Tag{&t1, &t2}
+
+	s.On("GetAchievementTags", ctx, aID).Return([]model.Tag{t1, t2}, nil)
+
+	actual, err := r.Tags(ctx, &model.Achievement{ID: aID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	s.AssertExpectations(t)
+}
+
+func TestCreateAchievementWithNote(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "b1265627-d9f2-4a0b-b60d-322273b7df83"
+	note := "Reviewed the *rounding* PR"
+
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID}, nil)
+	s.On("CreateAchievement", ctx, mock.Anything).Return(nil)
+	s.On("SetAchievementNote", ctx, mock.Anything, note).Return(nil)
+
+	actual, err := r.CreateAchievement(ctx, pID, &note)
+
+	assert.NoError(t, err)
+	assert.Equal(t, note, actual.Note)
+	s.AssertExpectations(t)
+}
+
+func TestUpdateAchievementNoteTooLong(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	note := strings.Repeat("ñ", model.MaxNoteLength+1)
+	ad := model.AchievementData{ProjectID: "b1265627-d9f2-4a0b-b60d-322273b7df83", Note: &note}
+
+	_, err := r.UpdateAchievement(ctx, "3b054f50-9d3d-4114-bfc4-395f70a59d26", ad)
+
+	assert.EqualError(t, err, "note can't be longer than 5000 characters")
+	s.AssertNotCalled(t, "UpdateAchievement", ctx, mock.Anything, mock.Anything)
+}
+
+func TestUpdateAchievementKeepsNote(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	a := model.Achievement{ID: aID, UserID: "0", ProjectID: "b1265627-d9f2-4a0b-b60d-322273b7df83", Note: "standup"}
+	ad := model.AchievementData{ProjectID: a.ProjectID, Start: 1598341158, End: 1598342861}
+
+	s.On("GetAchievement", ctx, aID).Return(a, nil)
+	s.On("UpdateAchievement", ctx, aID, ad).Return(a, nil)
+
+	actual, err := r.UpdateAchievement(ctx, aID, ad)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "standup", actual.Note)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "SetAchievementNote", ctx, aID, mock.Anything)
+}
+
+func TestProjectEarningsRollsUp(t *testing.T) {
+	var s mocks.Store
+	r := &projectResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	eur := model.Money{Amount: 6000, Currency: "EUR"}
+	usd := model.Money{Amount: 3000, Currency: "USD"}
+	notBillable := false
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: "0", Billable: true}
+	child := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: "0", ParentID: &p.ID, Rate: &usd, Billable: true}
+	grandchild := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: "0", ParentID: &child.ID, Billable: true}
+
+	s.On("GetUserRate", ctx, "0").Return(&eur, nil).Once()
+	s.On("GetUserRounding", ctx, "0").Return((*model.Rounding)(nil), nil).Once()
+	s.On("GetProject", ctx, p.ID).Return(p, nil)
+	s.On("GetProject", ctx, child.ID).Return(child, nil)
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ProjectID: p.ID, Start: 1600000000, End: 1600001800},
+		{ProjectID: p.ID, Start: 1600002000, End: 1600005600, Billable: &notBillable},
+	}, nil)
+	s.On("GetProjectAchievements", ctx, child.ID).Return([]model.Achievement{
+		{ProjectID: child.ID, Start: 1600010000, End: 1600013600},
+	}, nil)
+	s.On("GetProjectAchievements", ctx, grandchild.ID).Return([]model.Achievement{
+		{ProjectID: grandchild.ID, Start: 1600020000, End: 1600021800},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{child}, nil)
+	s.On("GetChildProjects", ctx, child.ID).Return([]model.Project{grandchild}, nil)
+	s.On("GetChildProjects", ctx, grandchild.ID).Return([]model.Project{}, nil)
+
+	actual, err := r.Earnings(ctx, &p, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Money{
+		{Amount: 3000, Currency: "EUR"},
+		{Amount: 4500, Currency: "USD"},
+	}, actual)
+	s.AssertExpectations(t)
+}
+
+func TestProjectRoundedTotal(t *testing.T) {
+	var s mocks.Store
+	r := &projectResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: "0",
+		Rounding: &model.Rounding{Increment: 15, Direction: model.RoundingDirectionNearest, Scope: model.RoundingScopeDay}}
+	child := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: "0", ParentID: &p.ID,
+		Rounding: &model.Rounding{Increment: 60, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry}}
+
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ProjectID: p.ID, Start: 1600000000, End: 1600000600},
+		{ProjectID: p.ID, Start: 1600003600, End: 1600004200},
+	}, nil)
+	s.On("GetProjectAchievements", ctx, child.ID).Return([]model.Achievement{
+		{ProjectID: child.ID, Start: 1600010000, End: 1600010060},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{child}, nil)
+	s.On("GetChildProjects", ctx, child.ID).Return([]model.Project{}, nil)
+
+	actual, err := r.RoundedTotal(ctx, &p, nil, nil)
+
+	// 20 minutes in a day round to 15, and the minute of the child up to an hour
+	assert.NoError(t, err)
+	assert.Equal(t, 900+3600, actual)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "GetUserRounding", ctx, "0")
+}
+
+func TestAchievementBillableFollowsProject(t *testing.T) {
+	var s mocks.Store
+	r := &achievementResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	notBillable := false
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, UserID: "0", Billable: true}, nil).Once()
+
+	actual, err := r.Billable(ctx, &model.Achievement{ProjectID: pID})
+	assert.NoError(t, err)
+	assert.True(t, actual)
+
+	actual, err = r.Billable(ctx, &model.Achievement{ProjectID: pID, Billable: &notBillable})
+	assert.NoError(t, err)
+	assert.False(t, actual)
+	s.AssertExpectations(t)
+}
+
+func TestSetUserRateInvalid(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	_, err := r.SetUserRate(ctx, &model.MoneyData{Amount: 5000, Currency: "euros"})
+
+	assert.EqualError(t, err, `"euros" is not a currency code`)
+	s.AssertNotCalled(t, "SetUserRate", ctx, mock.Anything, mock.Anything)
+}
+
+func TestSetUserRoundingInvalid(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	_, err := r.SetUserRounding(ctx, &model.RoundingData{Increment: 0, Direction: model.RoundingDirectionUp,
+		Scope: model.RoundingScopeEntry})
+
+	assert.EqualError(t, err, "rounding increment must be between 1 and 1440 minutes")
+	s.AssertNotCalled(t, "SetUserRounding", ctx, mock.Anything, mock.Anything)
+}
+
+func TestCreateProjectWithRate(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	np := model.NewProject{
+		Name:       "Client X",
+		CategoryID: &cID,
+		Rate:       &model.MoneyData{Amount: 9000, Currency: "EUR"},
+		Billable:   true,
+	}
+
+	s.On("GetCategory", ctx, cID).Return(model.Category{ID: cID, UserID: "0"}, nil)
+	s.On("CreateProject", ctx, mock.Anything).Return(nil)
+
+	actual, err := r.CreateProject(ctx, np)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Money{Amount: 9000, Currency: "EUR"}, actual.Rate)
+	assert.True(t, actual.Billable)
+	s.AssertExpectations(t)
+}
+
+func TestUpdateAchievementInvoiced(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	iID := "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	ad := model.AchievementData{ProjectID: "b1265627-d9f2-4a0b-b60d-322273b7df83", Start: 1598341158, End: 1598342861}
+
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{ID: aID, InvoiceID: &iID}, nil)
+
+	_, err := r.UpdateAchievement(ctx, aID, ad)
+
+	assert.EqualError(t, err, "achievement "+aID+" is locked by invoice "+iID)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "UpdateAchievement", ctx, mock.Anything, mock.Anything)
+}
+
+func TestGenerateInvoice(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: "0", Name: "glow", Billable: true}
+	s.On("GetProject", ctx, p.ID).Return(p, nil)
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", ProjectID: p.ID, Start: 1600000000, End: 1600003600},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{}, nil)
+	s.On("GetUserRounding", ctx, "0").Return((*model.Rounding)(nil), nil)
+	s.On("CreateInvoice", ctx, mock.Anything).Return(func(ctx context.Context, inv model.Invoice) model.Invoice {
+		inv.Number = 3
+		return inv
+	}, nil)
+
+	actual, err := r.GenerateInvoice(ctx, []string{p.ID}, 1598918400, 1601510400,
+		&model.MoneyData{Amount: 5000, Currency: "EUR"}, model.InvoiceGroupingProject)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, actual.Number)
+	assert.NotEmpty(t, actual.ID)
+	assert.Equal(t, 5000, actual.Total)
+	assert.Equal(t, []string{"9a6f1c1e-4b1e-4d0e-8d9b-000000000001"}, actual.AchievementIDs)
+	s.AssertExpectations(t)
+}
+
+func TestCreateExport(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	s.On("CreateExportToken", ctx, mock.MatchedBy(func(t model.ExportToken) bool {
+		return t.UserID == "0" && t.From == 1598918400 && t.To == 1601510400 && t.Format == model.ExportFormatIcs &&
+			t.Token != ""
+	})).Return(nil)
+
+	actual, err := r.CreateExport(ctx, 1598918400, 1601510400, model.ExportFormatIcs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/export?token="+actual.Token, actual.URL())
+	assert.Greater(t, actual.ExpiresAt, int(time.Now().Unix()))
+	s.AssertExpectations(t)
+
+	_, err = r.CreateExport(ctx, 1601510400, 1598918400, model.ExportFormatCsv)
+	assert.EqualError(t, err, "from must be before to")
+}
+
+func TestImportAchievements(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	s.On("GetCategory", ctx, cID).Return(model.Category{ID: cID, UserID: "0", Name: "Work"}, nil)
+	s.On("GetUserProjects", ctx, "0").Return([]model.Project{}, nil)
+	s.On("GetArchivedProjects", ctx, "0").Return([]model.Project{}, nil)
+	s.On("GetUserCategories", ctx, "0").Return([]model.Category{}, nil)
+	s.On("GetUserTags", ctx, "0").Return([]model.Tag{}, nil)
+	file := graphql.Upload{Filename: "toggl.csv", File: strings.NewReader(
+		"Client,Project,Description,Billable,Start date,Start time,End date,End time,Duration,Tags\n" +
+			",Website,,No,2020-09-01,10:00:00,2020-09-01,11:00:00,01:00:00,\n")}
+
+	actual, err := r.ImportAchievements(ctx, file, nil, cID, "Europe/Madrid", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.ImportFormatToggl, actual.Format)
+	assert.Equal(t, 1, actual.Created)
+	assert.Equal(t, []string{"Website"}, actual.Projects)
+	s.AssertNotCalled(t, "CreateProject", mock.Anything, mock.Anything)
+
+	_, err = r.ImportAchievements(ctx, file, nil, cID, "Mars/Olympus_Mons", false)
+	assert.EqualError(t, err, `invalid time zone "Mars/Olympus_Mons"`)
+}
+
+func TestTagAchievementInvoiced(t *testing.T) {
+	var s mocks.Store
+	r := &mutationResolver{Resolver: NewResolver(&s, nullLogger())}
+	ctx := context.Background()
+
+	aID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	iID := "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	tag := model.Tag{ID: "c1f0a4f8-3a4e-4f6b-9d0e-000000000001", UserID: "0", Name: "urgent"}
+
+	s.On("GetTag", ctx, tag.ID).Return(tag, nil)
+	s.On("GetAchievement", ctx, aID).Return(model.Achievement{ID: aID, InvoiceID: &iID}, nil)
+
+	_, err := r.TagAchievement(ctx, aID, tag.ID)
+	assert.EqualError(t, err, "achievement "+aID+" is locked by invoice "+iID)
+	_, err = r.UntagAchievement(ctx, aID, tag.ID)
+	assert.EqualError(t, err, "achievement "+aID+" is locked by invoice "+iID)
+
+	s.AssertNotCalled(t, "TagAchievement", ctx, mock.Anything, mock.Anything)
+	s.AssertNotCalled(t, "UntagAchievement", ctx, mock.Anything, mock.Anything)
+}
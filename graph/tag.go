@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// validateTag checks the data given to create or update a tag
+func validateTag(nt model.NewTag) error {
+	if strings.TrimSpace(nt.Name) == "" {
+		return fmt.Errorf("tag name can't be empty")
+	}
+	if nt.Color != "" && !colorPattern.MatchString(nt.Color) {
+		return fmt.Errorf("invalid color %q, expected #rrggbb", nt.Color)
+	}
+	return nil
+}
+
+// checkTag fails unless tID is one of the tags of user uID
+func (r *Resolver) checkTag(ctx context.Context, tID, uID string) error {
+	t, err := r.store.GetTag(ctx, tID)
+	if err != nil {
+		return err
+	}
+	if t.UserID != uID {
+		return fmt.Errorf("tag %s does not exist", tID)
+	}
+	return nil
+}
+
+// taggedAchievements returns the achievements that have all the tags in tagIDs and pass keep
+func (r *Resolver) taggedAchievements(ctx context.Context, tagIDs []string,
+	keep func(a model.Achievement) bool) ([]*model.Achievement, error) {
+	all, err := r.store.GetTaggedAchievements(ctx, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	as := []*model.Achievement{}
+	for i := range all {
+		if keep(all[i]) {
+			as = append(as, &all[i])
+		}
+	}
+	return as, nil
+}
+
+// tagSlice returns pointers to the tags in ts
+func tagSlice(ts []model.Tag) []*model.Tag {
+	tags := make([]*model.Tag, len(ts))
+	for i := range ts {
+		tags[i] = &ts[i]
+	}
+	return tags
+}
@@ -0,0 +1,237 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+// entry is a time entry read from a row of an import
+type entry struct {
+	// Client is empty in glow's imports, or when the entry's project has no client
+	Client  string
+	Project string
+	// Category is only known in glow's imports
+	Category string
+	Tags     []string
+	Note     string
+	Start    time.Time
+	End      time.Time
+	// Billable is nil when the row doesn't say
+	Billable *bool
+}
+
+// columns are the columns a format is read from, by their name in lower case. The ones in required must be
+// in the header, the rest are optional.
+type columns struct {
+	required []string
+	// detect are the columns that tell the format apart from the others
+	detect []string
+}
+
+var formats = []struct {
+	format model.ImportFormat
+	columns
+}{
+	// Ordered so that the most specific headers are detected first
+	{model.ImportFormatGlow, columns{
+		required: []string{"project", "start", "end"},
+		detect:   []string{"id", "project", "start", "end", "roundedseconds"},
+	}},
+	{model.ImportFormatClockify, columns{
+		required: []string{"project", "start date", "start time", "end date", "end time"},
+		detect:   []string{"start date", "start time", "duration (h)"},
+	}},
+	{model.ImportFormatToggl, columns{
+		required: []string{"project", "start date", "start time", "end date", "end time"},
+		detect:   []string{"start date", "start time", "duration"},
+	}},
+}
+
+// Date and time layouts of Toggl and Clockify, which depend on the settings of the user that exported them
+var (
+	togglDates    = []string{"2006-01-02"}
+	togglTimes    = []string{"15:04:05"}
+	clockifyDates = []string{"01/02/2006", "2006-01-02"}
+	clockifyTimes = []string{"03:04:05 PM", "15:04:05", "03:04 PM", "15:04"}
+)
+
+// reader reads the entries of a CSV export of Toggl, Clockify or glow
+type reader struct {
+	csv    *csv.Reader
+	format model.ImportFormat
+	loc    *time.Location
+	index  map[string]int
+}
+
+// newReader reads the header of r, detecting its format if format is nil. Times without a time zone,
+// like Toggl's and Clockify's, are in loc.
+func newReader(r io.Reader, format *model.ImportFormat, loc *time.Location) (*reader, error) {
+	c := csv.NewReader(r)
+	c.FieldsPerRecord = -1
+	c.LazyQuotes = true
+	header, err := c.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = storage.NameKey(name)
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	has := func(names []string) bool {
+		for _, n := range names {
+			if _, ok := index[n]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, f := range formats {
+		if format == nil && has(f.detect) {
+			detected := f.format
+			format = &detected
+		}
+		if format == nil || *format != f.format {
+			continue
+		}
+		for _, n := range f.required {
+			if _, ok := index[n]; !ok {
+				return nil, fmt.Errorf("missing column %q", n)
+			}
+		}
+		return &reader{csv: c, format: f.format, loc: loc, index: index}, nil
+	}
+	if format != nil {
+		return nil, fmt.Errorf("invalid import format %q", *format)
+	}
+	return nil, errors.New("unknown CSV format, it isn't an export of Toggl, Clockify or glow")
+}
+
+// get returns the column name of record, or an empty string if it's missing
+func (r *reader) get(record []string, name string) string {
+	i, ok := r.index[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// next reads the entry in the next row. It returns io.EOF after the last one, and a rowError
+// if the row isn't a valid entry, in which case the rest can still be read.
+func (r *reader) next() (entry, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		return entry{}, err
+	}
+	var e entry
+	if r.format == model.ImportFormatGlow {
+		e, err = r.glow(record)
+	} else {
+		e, err = r.tracker(record)
+	}
+	if err != nil {
+		return e, rowError{err}
+	}
+	if e.Project == "" {
+		return e, rowError{errors.New("entry has no project")}
+	}
+	return e, nil
+}
+
+// glow reads a row of glow's own CSV export
+func (r *reader) glow(record []string) (entry, error) {
+	e := entry{
+		Project:  r.get(record, "project"),
+		Category: r.get(record, "category"),
+		Tags:     splitTags(r.get(record, "tags"), ";"),
+		Note:     r.get(record, "note"),
+	}
+	var err error
+	if e.Start, err = time.Parse(time.RFC3339, r.get(record, "start")); err != nil {
+		return e, fmt.Errorf("invalid start %q", r.get(record, "start"))
+	}
+	if e.End, err = time.Parse(time.RFC3339, r.get(record, "end")); err != nil {
+		return e, fmt.Errorf("invalid end %q", r.get(record, "end"))
+	}
+	e.Billable, err = parseBillable(r.get(record, "billable"))
+	return e, err
+}
+
+// tracker reads a row of the detailed reports of Toggl or Clockify, which share the names of their columns
+func (r *reader) tracker(record []string) (entry, error) {
+	dates, times := togglDates, togglTimes
+	if r.format == model.ImportFormatClockify {
+		dates, times = clockifyDates, clockifyTimes
+	}
+	e := entry{
+		Client:  r.get(record, "client"),
+		Project: r.get(record, "project"),
+		Tags:    splitTags(r.get(record, "tags"), ","),
+		Note:    r.get(record, "description"),
+	}
+	var err error
+	if e.Start, err = parseLocal(r.get(record, "start date"), r.get(record, "start time"), dates, times, r.loc); err != nil {
+		return e, fmt.Errorf("invalid start: %v", err)
+	}
+	if e.End, err = parseLocal(r.get(record, "end date"), r.get(record, "end time"), dates, times, r.loc); err != nil {
+		return e, fmt.Errorf("invalid end: %v", err)
+	}
+	e.Billable, err = parseBillable(r.get(record, "billable"))
+	return e, err
+}
+
+// parseLocal parses the date d and time t, in any of the given layouts, as a time in loc
+func parseLocal(d, t string, dates, times []string, loc *time.Location) (time.Time, error) {
+	for _, dl := range dates {
+		for _, tl := range times {
+			if v, err := time.ParseInLocation(dl+" "+tl, d+" "+t, loc); err == nil {
+				return v, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q isn't a known date and time", d+" "+t)
+}
+
+// parseBillable parses whether a row is billable, nil if it's empty
+func parseBillable(v string) (*bool, error) {
+	var b bool
+	switch strings.ToLower(v) {
+	case "":
+		return nil, nil
+	case "yes", "true":
+		b = true
+	case "no", "false":
+		b = false
+	default:
+		return nil, fmt.Errorf("invalid billable %q", v)
+	}
+	return &b, nil
+}
+
+// splitTags splits the tags of a column, separated by sep
+func splitTags(v, sep string) []string {
+	var tags []string
+	for _, t := range strings.Split(v, sep) {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, csv string, format *model.ImportFormat) (model.ImportFormat, []entry, []error) {
+	r, err := newReader(strings.NewReader(csv), format, madrid)
+	require.NoError(t, err)
+	var (
+		es   []entry
+		errs []error
+	)
+	for {
+		e, err := r.next()
+		if err == io.EOF {
+			return r.format, es, errs
+		}
+		errs = append(errs, err)
+		es = append(es, e)
+	}
+}
+
+func TestReadToggl(t *testing.T) {
+	format, es, errs := readAll(t, "\ufeff"+`User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()
+Ana,ana@example.com,ACME,Website,,Design,Yes,2020-09-01,10:00:00,2020-09-01,11:30:00,01:30:00,"urgent, design",
+Ana,ana@example.com,,Reading,,,No,2020-09-01,23:30:00,2020-09-02,00:15:00,00:45:00,,
+Ana,ana@example.com,,Reading,,,Maybe,2020-09-02,10:00:00,2020-09-02,11:00:00,01:00:00,,
+`, nil)
+
+	assert.Equal(t, model.ImportFormatToggl, format)
+	require.Len(t, es, 3)
+	yes, no := true, false
+	assert.Equal(t, entry{
+		Client:   "ACME",
+		Project:  "Website",
+		Tags:     []string{"urgent", "design"},
+		Note:     "Design",
+		Start:    time.Date(2020, 9, 1, 10, 0, 0, 0, madrid),
+		End:      time.Date(2020, 9, 1, 11, 30, 0, 0, madrid),
+		Billable: &yes,
+	}, es[0])
+	assert.NoError(t, errs[0])
+	assert.Equal(t, time.Date(2020, 9, 2, 0, 15, 0, 0, madrid), es[1].End)
+	assert.Equal(t, &no, es[1].Billable)
+	assert.NoError(t, errs[1])
+	assert.EqualError(t, errs[2], `invalid billable "Maybe"`)
+	assert.IsType(t, rowError{}, errs[2])
+}
+
+func TestReadClockify(t *testing.T) {
+	format, es, errs := readAll(t, `Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)
+Website,ACME,Design,,Ana,,ana@example.com,urgent,Yes,09/01/2020,10:00:00 AM,09/01/2020,01:30:00 PM,03:30:00,3.50
+Website,ACME,,,Ana,,ana@example.com,,No,2020-09-01,14:00,2020-09-01,15:00,01:00:00,1.00
+Website,ACME,,,Ana,,ana@example.com,,No,01.09.2020,14:00,01.09.2020,15:00,01:00:00,1.00
+`, nil)
+
+	assert.Equal(t, model.ImportFormatClockify, format)
+	require.Len(t, es, 3)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "ACME", es[0].Client)
+	assert.Equal(t, []string{"urgent"}, es[0].Tags)
+	assert.Equal(t, time.Date(2020, 9, 1, 10, 0, 0, 0, madrid), es[0].Start)
+	assert.Equal(t, time.Date(2020, 9, 1, 13, 30, 0, 0, madrid), es[0].End)
+	assert.NoError(t, errs[1])
+	assert.Equal(t, time.Date(2020, 9, 1, 14, 0, 0, 0, madrid), es[1].Start)
+	assert.EqualError(t, errs[2], `invalid start: "01.09.2020 14:00" isn't a known date and time`)
+}
+
+func TestReadGlow(t *testing.T) {
+	format, es, errs := readAll(t, `id,project,category,tags,note,start,end,seconds,roundedSeconds,billable
+a1,Client X,Work,meeting;urgent,"Standup, with ""quotes""",2020-09-01T01:00:00Z,2020-09-01T01:10:00Z,600,900,true
+a2,,Work,,,2020-09-01T02:00:00Z,2020-09-01T02:10:00Z,600,900,true
+`, nil)
+
+	assert.Equal(t, model.ImportFormatGlow, format)
+	require.Len(t, es, 2)
+	assert.NoError(t, errs[0])
+	yes := true
+	assert.Equal(t, entry{
+		Project:  "Client X",
+		Category: "Work",
+		Tags:     []string{"meeting", "urgent"},
+		Note:     `Standup, with "quotes"`,
+		Start:    time.Date(2020, 9, 1, 1, 0, 0, 0, time.UTC),
+		End:      time.Date(2020, 9, 1, 1, 10, 0, 0, time.UTC),
+		Billable: &yes,
+	}, es[0])
+	assert.EqualError(t, errs[1], "entry has no project")
+}
+
+func TestNewReaderErrors(t *testing.T) {
+	toggl := model.ImportFormatToggl
+	_, err := newReader(strings.NewReader(""), nil, time.UTC)
+	assert.EqualError(t, err, "the file is empty")
+
+	_, err = newReader(strings.NewReader("date,hours\n2020-09-01,8\n"), nil, time.UTC)
+	assert.EqualError(t, err, "unknown CSV format, it isn't an export of Toggl, Clockify or glow")
+
+	_, err = newReader(strings.NewReader("Client,Project,Start date,Start time\n"), &toggl, time.UTC)
+	assert.EqualError(t, err, `missing column "end date"`)
+
+	invalid := model.ImportFormat("HARVEST")
+	_, err = newReader(strings.NewReader("Project\n"), &invalid, time.UTC)
+	assert.EqualError(t, err, `invalid import format "HARVEST"`)
+}
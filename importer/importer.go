@@ -0,0 +1,313 @@
+// Package importer imports as achievements the time entries of CSV exports of Toggl, Clockify or glow itself.
+// Clients and projects are mapped onto the user's projects, creating the missing ones, and entries that
+// the user already has are skipped, so importing the same file twice is harmless.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Options are the options of an import
+type Options struct {
+	UserID string
+	// Format is detected from the header when nil
+	Format *model.ImportFormat
+	// CategoryID is the category of the projects created by the import, when the file doesn't tell
+	CategoryID string
+	// Location is the time zone of the times without one, like Toggl's and Clockify's
+	Location *time.Location
+	// DryRun reports what the import would create, without creating anything
+	DryRun bool
+}
+
+// rowError is an error in a row, which is reported and skipped
+type rowError struct {
+	error
+}
+
+// projectKey identifies a project by its parent, empty for top level projects, and its name
+type projectKey struct {
+	parentID string
+	name     string
+}
+
+// period is the start and end of an achievement, which tell whether an entry was already imported
+type period struct {
+	start, end int
+}
+
+type importer struct {
+	s      storage.Store
+	o      Options
+	result *model.ImportResult
+
+	projects map[projectKey]model.Project
+	// byName are the projects by their name alone, top level ones first, to match entries without a client
+	byName     map[string]model.Project
+	categories map[string]string
+	tags       map[string]string
+	// periods are the periods of the achievements of each project, loaded as they're needed
+	periods map[string]map[period]bool
+}
+
+// Import imports the entries of the CSV file r for user o.UserID. Rows that can't be imported are reported
+// in the result's errors, and the rest are still imported. Any other error stops the import, and
+// the entries imported until then are kept.
+func Import(ctx context.Context, s storage.Store, r io.Reader, o Options) (*model.ImportResult, error) {
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	rd, err := newReader(r, o.Format, o.Location)
+	if err != nil {
+		return nil, err
+	}
+	i := &importer{
+		s: s,
+		o: o,
+		result: &model.ImportResult{
+			Format:   rd.format,
+			DryRun:   o.DryRun,
+			Projects: []string{},
+			Tags:     []string{},
+			Errors:   []*model.ImportError{},
+		},
+		periods: make(map[string]map[period]bool),
+	}
+	if err := i.load(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		e, err := rd.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(rowError); !ok {
+				return nil, err
+			}
+		}
+		i.result.Rows++
+		if err == nil {
+			err = i.add(ctx, e)
+		}
+		if err != nil {
+			if _, ok := err.(rowError); !ok {
+				return nil, err
+			}
+			i.result.Errors = append(i.result.Errors, &model.ImportError{Row: i.result.Rows, Message: err.Error()})
+		}
+	}
+	return i.result, nil
+}
+
+// load loads the projects, categories and tags of the user, which the entries are matched against
+func (i *importer) load(ctx context.Context) error {
+	ps, err := i.s.GetUserProjects(ctx, i.o.UserID)
+	if err != nil {
+		return err
+	}
+	archived, err := i.s.GetArchivedProjects(ctx, i.o.UserID)
+	if err != nil {
+		return err
+	}
+	ps = append(ps, archived...)
+	// Top level projects first, so that they're preferred when matching by name alone
+	sort.SliceStable(ps, func(a, b int) bool { return ps[a].ParentID == nil && ps[b].ParentID != nil })
+	i.projects = make(map[projectKey]model.Project, len(ps))
+	i.byName = make(map[string]model.Project, len(ps))
+	for _, p := range ps {
+		k := keyOf(p.ParentID, p.Name)
+		if _, ok := i.projects[k]; !ok {
+			i.projects[k] = p
+		}
+		if _, ok := i.byName[k.name]; !ok {
+			i.byName[k.name] = p
+		}
+	}
+
+	cs, err := i.s.GetUserCategories(ctx, i.o.UserID)
+	if err != nil {
+		return err
+	}
+	i.categories = make(map[string]string, len(cs))
+	for _, c := range cs {
+		i.categories[storage.NameKey(c.Name)] = c.ID
+	}
+
+	ts, err := i.s.GetUserTags(ctx, i.o.UserID)
+	if err != nil {
+		return err
+	}
+	i.tags = make(map[string]string, len(ts))
+	for _, t := range ts {
+		i.tags[storage.NameKey(t.Name)] = t.ID
+	}
+	return nil
+}
+
+func keyOf(parentID *string, name string) projectKey {
+	k := projectKey{name: storage.NameKey(name)}
+	if parentID != nil {
+		k.parentID = *parentID
+	}
+	return k
+}
+
+// add imports entry e, unless it's already imported
+func (i *importer) add(ctx context.Context, e entry) error {
+	if !e.End.After(e.Start) {
+		return rowError{fmt.Errorf("entry must end after it starts")}
+	}
+	if utf8.RuneCountInString(e.Note) > model.MaxNoteLength {
+		return rowError{fmt.Errorf("note can't be longer than %d characters", model.MaxNoteLength)}
+	}
+
+	p, err := i.project(ctx, e)
+	if err != nil {
+		return err
+	}
+	periods, err := i.periodsOf(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	pd := period{int(e.Start.Unix()), int(e.End.Unix())}
+	if periods[pd] {
+		i.result.Duplicates++
+		return nil
+	}
+	periods[pd] = true
+
+	tagIDs := make([]string, 0, len(e.Tags))
+	for _, name := range e.Tags {
+		tID, err := i.tag(ctx, name)
+		if err != nil {
+			return err
+		}
+		tagIDs = append(tagIDs, tID)
+	}
+
+	i.result.Created++
+	if i.o.DryRun {
+		return nil
+	}
+	a := model.Achievement{
+		ID:        uuid.New().String(),
+		UserID:    i.o.UserID,
+		ProjectID: p.ID,
+		Start:     pd.start,
+		End:       pd.end,
+	}
+	if err := i.s.CreateAchievement(ctx, a); err != nil {
+		return err
+	}
+	if e.Note != "" {
+		if err := i.s.SetAchievementNote(ctx, a.ID, e.Note); err != nil {
+			return err
+		}
+	}
+	if e.Billable != nil && *e.Billable != p.Billable {
+		if err := i.s.SetAchievementBillable(ctx, a.ID, e.Billable); err != nil {
+			return err
+		}
+	}
+	for _, tID := range tagIDs {
+		if err := i.s.TagAchievement(ctx, a.ID, tID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// project returns the project of entry e. Its client, if any, is a top level project and the project one
+// of its subprojects. Without a client, the project is matched by its name alone.
+func (i *importer) project(ctx context.Context, e entry) (model.Project, error) {
+	categoryID := i.o.CategoryID
+	if cID, ok := i.categories[storage.NameKey(e.Category)]; ok && e.Category != "" {
+		categoryID = cID
+	}
+	if e.Client == "" {
+		if p, ok := i.byName[storage.NameKey(e.Project)]; ok {
+			return p, nil
+		}
+		return i.findOrCreate(ctx, nil, e.Project, categoryID, e.Project)
+	}
+	client, err := i.findOrCreate(ctx, nil, e.Client, categoryID, e.Client)
+	if err != nil {
+		return client, err
+	}
+	return i.findOrCreate(ctx, &client.ID, e.Project, categoryID, e.Client+" / "+e.Project)
+}
+
+// findOrCreate returns the project name under parentID, creating it in categoryID if it doesn't exist.
+// Created projects are reported by their path.
+func (i *importer) findOrCreate(ctx context.Context, parentID *string, name, categoryID, path string) (model.Project, error) {
+	k := keyOf(parentID, name)
+	if p, ok := i.projects[k]; ok {
+		return p, nil
+	}
+	p := model.Project{
+		ID:         uuid.New().String(),
+		UserID:     i.o.UserID,
+		Name:       name,
+		CategoryID: categoryID,
+		ParentID:   parentID,
+		Billable:   true,
+	}
+	if !i.o.DryRun {
+		if err := i.s.CreateProject(ctx, p); err != nil {
+			return p, err
+		}
+	}
+	i.projects[k] = p
+	if _, ok := i.byName[k.name]; !ok || parentID == nil {
+		i.byName[k.name] = p
+	}
+	// New projects have no achievements
+	i.periods[p.ID] = make(map[period]bool)
+	i.result.Projects = append(i.result.Projects, path)
+	return p, nil
+}
+
+// periodsOf returns the periods of the achievements of project pID
+func (i *importer) periodsOf(ctx context.Context, pID string) (map[period]bool, error) {
+	if periods, ok := i.periods[pID]; ok {
+		return periods, nil
+	}
+	as, err := i.s.GetProjectAchievements(ctx, pID)
+	if err != nil {
+		return nil, err
+	}
+	periods := make(map[period]bool, len(as))
+	for _, a := range as {
+		periods[period{a.Start, a.End}] = true
+	}
+	i.periods[pID] = periods
+	return periods, nil
+}
+
+// tag returns the ID of the tag name, creating it if the user doesn't have it
+func (i *importer) tag(ctx context.Context, name string) (string, error) {
+	k := storage.NameKey(name)
+	if tID, ok := i.tags[k]; ok {
+		return tID, nil
+	}
+	t := model.Tag{ID: uuid.New().String(), UserID: i.o.UserID, Name: name}
+	if !i.o.DryRun {
+		if err := i.s.CreateTag(ctx, t); err != nil {
+			return "", err
+		}
+	}
+	i.tags[k] = t.ID
+	i.result.Tags = append(i.result.Tags, name)
+	return t.ID, nil
+}
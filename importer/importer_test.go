@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const uID = "0"
+
+var (
+	madrid, _ = time.LoadLocation("Europe/Madrid")
+
+	work    = model.Category{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: uID, Name: "Work"}
+	reading = model.Category{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", UserID: uID, Name: "Reading"}
+	urgent  = model.Tag{ID: "9b2e5c1a-7f1d-4c3e-a0a4-5b6c7d8e9f00", UserID: uID, Name: "Urgent"}
+)
+
+// newStore mocks a store with the project ACME and its subproject Website, which has an achievement
+// on 2020-09-01 from 10:00 to 11:00 in Madrid
+func newStore() (s *mocks.Store, acme, website model.Project) {
+	s = &mocks.Store{}
+	ctx := context.Background()
+	acme = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "ACME", CategoryID: work.ID,
+		Billable: true}
+	website = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website",
+		CategoryID: work.ID, ParentID: &acme.ID, Billable: true}
+
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{website, acme}, nil)
+	s.On("GetArchivedProjects", ctx, uID).Return([]model.Project{}, nil)
+	s.On("GetUserCategories", ctx, uID).Return([]model.Category{work, reading}, nil)
+	s.On("GetUserTags", ctx, uID).Return([]model.Tag{urgent}, nil)
+	s.On("GetProjectAchievements", ctx, website.ID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: website.ID, Start: 1598947200, End: 1598950800},
+	}, nil)
+	return s, acme, website
+}
+
+const togglCSV = `User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags
+Ana,ana@example.com,ACME,Website,,Design,Yes,2020-09-01,10:00:00,2020-09-01,11:00:00,01:00:00,
+Ana,ana@example.com,ACME,website,,Review,No,2020-09-01,12:00:00,2020-09-01,12:30:00,00:30:00,"urgent, design"
+Ana,ana@example.com,ACME,Backend,,,Yes,2020-09-01,09:00:00,2020-09-01,10:00:00,01:00:00,
+Ana,ana@example.com,ACME,Backend,,,Yes,2020-09-01,09:00:00,2020-09-01,08:00:00,01:00:00,
+Ana,ana@example.com,ACME,Backend,,,Yes,2020-09-01,09:00:00,2020-09-01,10:00:00,01:00:00,
+Ana,ana@example.com,,,,Lunch,No,2020-09-01,14:00:00,2020-09-01,15:00:00,01:00:00,
+`
+
+func TestImportToggl(t *testing.T) {
+	s, acme, website := newStore()
+	ctx := context.Background()
+	var (
+		backend model.Project
+		created []model.Achievement
+	)
+	s.On("CreateProject", ctx, mock.MatchedBy(func(p model.Project) bool { return p.Name == "Backend" })).
+		Run(func(args mock.Arguments) { backend = args.Get(1).(model.Project) }).Return(nil)
+	s.On("CreateTag", ctx, mock.MatchedBy(func(t model.Tag) bool { return t.Name == "design" })).Return(nil)
+	s.On("CreateAchievement", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { created = append(created, args.Get(1).(model.Achievement)) }).Return(nil)
+	s.On("SetAchievementNote", ctx, mock.Anything, "Review").Return(nil)
+	no := false
+	s.On("SetAchievementBillable", ctx, mock.Anything, &no).Return(nil)
+	s.On("TagAchievement", ctx, mock.Anything, urgent.ID).Return(nil)
+	s.On("TagAchievement", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	format := model.ImportFormatToggl
+	res, err := Import(ctx, s, strings.NewReader(togglCSV), Options{
+		UserID: uID, Format: &format, CategoryID: reading.ID, Location: madrid})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ImportResult{
+		Format:     model.ImportFormatToggl,
+		Rows:       6,
+		Created:    2,
+		Duplicates: 2,
+		Projects:   []string{"ACME / Backend"},
+		Tags:       []string{"design"},
+		Errors: []*model.ImportError{
+			{Row: 4, Message: "entry must end after it starts"},
+			{Row: 6, Message: "entry has no project"},
+		},
+	}, res)
+	assert.Equal(t, &acme.ID, backend.ParentID)
+	assert.Equal(t, reading.ID, backend.CategoryID)
+	require.Len(t, created, 2)
+	assert.Equal(t, website.ID, created[0].ProjectID)
+	assert.Equal(t, 1598954400, created[0].Start)
+	assert.Equal(t, 1598956200, created[0].End)
+	assert.Equal(t, backend.ID, created[1].ProjectID)
+	s.AssertNumberOfCalls(t, "SetAchievementNote", 1)
+	s.AssertNumberOfCalls(t, "SetAchievementBillable", 1)
+	s.AssertNumberOfCalls(t, "TagAchievement", 2)
+}
+
+func TestImportDryRun(t *testing.T) {
+	s, _, _ := newStore()
+
+	res, err := Import(context.Background(), s, strings.NewReader(togglCSV), Options{
+		UserID: uID, CategoryID: reading.ID, Location: madrid, DryRun: true})
+
+	require.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, model.ImportFormatToggl, res.Format)
+	assert.Equal(t, 2, res.Created)
+	assert.Equal(t, 2, res.Duplicates)
+	assert.Equal(t, []string{"ACME / Backend"}, res.Projects)
+	assert.Equal(t, []string{"design"}, res.Tags)
+	assert.Len(t, res.Errors, 2)
+	for _, m := range []string{"CreateProject", "CreateTag", "CreateAchievement", "SetAchievementNote",
+		"SetAchievementBillable", "TagAchievement"} {
+		s.AssertNotCalled(t, m)
+	}
+}
+
+func TestImportGlow(t *testing.T) {
+	s, _, website := newStore()
+	ctx := context.Background()
+	var created []model.Project
+	s.On("CreateProject", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { created = append(created, args.Get(1).(model.Project)) }).Return(nil)
+	s.On("CreateAchievement", ctx, mock.Anything).Return(nil)
+
+	res, err := Import(ctx, s, strings.NewReader(`id,project,category,tags,note,start,end,seconds,roundedSeconds,billable
+a1,Website,Work,,,2020-09-01T09:00:00Z,2020-09-01T10:00:00Z,3600,3600,true
+a2,Book,reading,,,2020-09-02T20:00:00Z,2020-09-02T21:00:00Z,3600,,true
+a3,Blog,Hobbies,,,2020-09-03T20:00:00Z,2020-09-03T21:00:00Z,3600,,true
+`), Options{UserID: uID, CategoryID: work.ID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Created)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, []string{"Book", "Blog"}, res.Projects)
+	require.Len(t, created, 2)
+	// Subprojects are matched by their name, and categories by theirs
+	assert.Nil(t, created[0].ParentID)
+	assert.Equal(t, reading.ID, created[0].CategoryID)
+	assert.Equal(t, work.ID, created[1].CategoryID)
+	s.AssertCalled(t, "CreateAchievement", ctx, mock.MatchedBy(func(a model.Achievement) bool {
+		return a.ProjectID == website.ID && a.Start == 1598950800
+	}))
+}
+
+func TestImportStoreError(t *testing.T) {
+	s, _, _ := newStore()
+	ctx := context.Background()
+	s.On("CreateTag", ctx, mock.Anything).Return(nil)
+	s.On("CreateAchievement", ctx, mock.Anything).Return(errors.New("connection refused"))
+
+	_, err := Import(ctx, s, strings.NewReader(togglCSV), Options{UserID: uID, CategoryID: work.ID, Location: madrid})
+
+	assert.EqualError(t, err, "connection refused")
+}
@@ -0,0 +1,64 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/export"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Handler serves the invoices stored in s at <invoiceID>.html and <invoiceID>.pdf.
+// It's meant to be mounted with http.StripPrefix. Requests are authenticated like exports, by a token
+// returned by the createExport mutation, and only the owner of an invoice can download it.
+func Handler(s storage.Store, logger logrus.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		ext := path.Ext(r.URL.Path)
+		iID := strings.TrimSuffix(r.URL.Path, ext)
+		if ext != ".html" && ext != ".pdf" {
+			http.NotFound(w, r)
+			return
+		}
+		t, err := s.GetExportToken(r.Context(), export.RequestToken(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="invoice"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		inv, err := s.GetInvoice(r.Context(), iID)
+		if err != nil || inv.UserID != t.UserID {
+			http.NotFound(w, r)
+			return
+		}
+
+		var buf bytes.Buffer
+		contentType := "text/html; charset=utf-8"
+		if ext == ".html" {
+			err = HTML(&buf, inv)
+		} else {
+			contentType = "application/pdf"
+			err = PDF(&buf, inv)
+		}
+		if err != nil {
+			logging.WithContext(r.Context(), logger).WithError(err).WithField("invoiceID", iID).
+				Error("Unable to render invoice")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"invoice-%d%s\"", inv.Number, ext))
+		buf.WriteTo(w)
+	})
+}
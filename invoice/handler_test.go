@@ -0,0 +1,89 @@
+package invoice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func serve(s *mocks.Store, method, path string) *httptest.ResponseRecorder {
+	logger, _ := test.NewNullLogger()
+	h := http.StripPrefix("/invoices/", Handler(s, logger))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func withToken(s *mocks.Store, userID string) {
+	s.On("GetExportToken", mock.Anything, "secret").Return(model.ExportToken{Token: "secret", UserID: userID}, nil)
+}
+
+func TestHandlerPDF(t *testing.T) {
+	var s mocks.Store
+	withToken(&s, uID)
+	s.On("GetInvoice", mock.Anything, inv.ID).Return(inv, nil)
+
+	w := serve(&s, http.MethodGet, "/invoices/"+inv.ID+".pdf?token=secret")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename="invoice-7.pdf"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "%PDF-1.4")
+	s.AssertExpectations(t)
+}
+
+func TestHandlerHTML(t *testing.T) {
+	var s mocks.Store
+	withToken(&s, uID)
+	s.On("GetInvoice", mock.Anything, inv.ID).Return(inv, nil)
+
+	w := serve(&s, http.MethodGet, "/invoices/"+inv.ID+".html?token=secret")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<h1>Invoice 7</h1>")
+	s.AssertExpectations(t)
+}
+
+func TestHandlerNotFound(t *testing.T) {
+	var s mocks.Store
+	withToken(&s, uID)
+	s.On("GetInvoice", mock.Anything, "unknown").Return(model.Invoice{}, errors.New("Key invoice:unknown does not exist"))
+
+	assert.Equal(t, http.StatusNotFound, serve(&s, http.MethodGet, "/invoices/unknown.pdf?token=secret").Code)
+	assert.Equal(t, http.StatusNotFound, serve(&s, http.MethodGet, "/invoices/"+inv.ID+".doc?token=secret").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(&s, http.MethodPost, "/invoices/"+inv.ID+".pdf").Code)
+}
+
+func TestHandlerUnauthorized(t *testing.T) {
+	var s mocks.Store
+	s.On("GetExportToken", mock.Anything, "expired").
+		Return(model.ExportToken{}, errors.New("export token doesn't exist or has expired"))
+	s.On("GetExportToken", mock.Anything, "").
+		Return(model.ExportToken{}, errors.New("export token doesn't exist or has expired"))
+
+	for _, target := range []string{"/invoices/" + inv.ID + ".pdf?token=expired", "/invoices/" + inv.ID + ".pdf"} {
+		w := serve(&s, http.MethodGet, target)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, target)
+		assert.Equal(t, `Bearer realm="invoice"`, w.Header().Get("WWW-Authenticate"))
+	}
+	s.AssertNotCalled(t, "GetInvoice", mock.Anything, mock.Anything)
+}
+
+func TestHandlerOtherUsersInvoice(t *testing.T) {
+	var s mocks.Store
+	withToken(&s, "other")
+	s.On("GetInvoice", mock.Anything, inv.ID).Return(inv, nil)
+
+	w := serve(&s, http.MethodGet, "/invoices/"+inv.ID+".pdf?token=secret")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NotContains(t, w.Body.String(), "%PDF")
+}
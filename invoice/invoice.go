@@ -0,0 +1,154 @@
+// Package invoice bills the billable time of projects, and renders the invoices as HTML and PDF
+package invoice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+	"github.com/smeruelo/glow/storage"
+)
+
+// dayLayout is how days are written in the lines of invoices grouped per day
+const dayLayout = "2006-01-02"
+
+// Request describes what to invoice
+type Request struct {
+	UserID     string
+	ProjectIDs []string
+	// From and To are Unix timestamps. Achievements that started from From and before To are invoiced.
+	From, To int
+	// Rate overrides the rates of the projects, if set
+	Rate    *model.Money
+	GroupBy model.InvoiceGrouping
+}
+
+// line is a line of an invoice being built
+type line struct {
+	key         string
+	description string
+	seconds     int
+	ledger      billing.Ledger
+}
+
+// Build returns the invoice, without ID, number nor creation time, of the billable achievements of the
+// requested projects and their subprojects that started within the requested period and haven't been
+// invoiced yet. Running timers are left out, and days are UTC days. The time of each project in each
+// line is rounded by the project's rounding rule.
+// It fails if there's nothing to invoice, some of the time has no rate, or the rates are in different currencies.
+func Build(ctx context.Context, s storage.Store, req Request) (model.Invoice, error) {
+	inv := model.Invoice{UserID: req.UserID, From: req.From, To: req.To, AchievementIDs: []string{}}
+
+	pending := make([]model.Project, 0, len(req.ProjectIDs))
+	for _, pID := range req.ProjectIDs {
+		p, err := s.GetProject(ctx, pID)
+		if err != nil {
+			return inv, err
+		}
+		if p.UserID != req.UserID {
+			return inv, fmt.Errorf("project %s does not exist", pID)
+		}
+		pending = append(pending, p)
+	}
+
+	terms := billing.NewTerms(s, req.UserID)
+	lines := map[string]*line{}
+	visited := map[string]bool{}
+	for ; len(pending) > 0; pending = pending[1:] {
+		p := pending[0]
+		// A subproject may have been requested along with its parent
+		if visited[p.ID] {
+			continue
+		}
+		visited[p.ID] = true
+
+		as, err := s.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return inv, err
+		}
+		// The time of the project in each line, rounded all at once
+		var keys []string
+		entries := map[string][]rounding.Entry{}
+		for _, a := range as {
+			if a.End == 0 || a.Start < req.From || a.Start >= req.To || a.InvoiceID != nil || !billing.Billable(a, p) {
+				continue
+			}
+
+			key, description := p.ID, p.Name
+			if req.GroupBy == model.InvoiceGroupingDay {
+				key = time.Unix(int64(a.Start), 0).UTC().Format(dayLayout)
+				description = key
+			}
+			if _, ok := lines[key]; !ok {
+				lines[key] = &line{key: key, description: description, ledger: billing.Ledger{}}
+			}
+			if _, ok := entries[key]; !ok {
+				keys = append(keys, key)
+			}
+			entries[key] = append(entries[key], rounding.Entry{Start: a.Start, Seconds: a.End - a.Start})
+			inv.AchievementIDs = append(inv.AchievementIDs, a.ID)
+		}
+
+		if len(keys) > 0 {
+			rate := req.Rate
+			if rate == nil {
+				if rate, err = terms.RateOf(ctx, p); err != nil {
+					return inv, err
+				}
+				if rate == nil {
+					return inv, fmt.Errorf("project %q has no rate", p.Name)
+				}
+			}
+			if inv.Currency == "" {
+				inv.Currency = rate.Currency
+			} else if rate.Currency != inv.Currency {
+				return inv, fmt.Errorf("can't invoice %s and %s in the same invoice", inv.Currency, rate.Currency)
+			}
+
+			rule, err := terms.RoundingOf(ctx, p)
+			if err != nil {
+				return inv, err
+			}
+			for _, key := range keys {
+				seconds := rounding.Total(entries[key], rule)
+				lines[key].seconds += seconds
+				lines[key].ledger.Add(rate, seconds)
+			}
+		}
+
+		children, err := s.GetChildProjects(ctx, p.ID)
+		if err != nil {
+			return inv, err
+		}
+		pending = append(pending, children...)
+	}
+	if len(inv.AchievementIDs) == 0 {
+		return inv, fmt.Errorf("there's no billable time to invoice")
+	}
+
+	sorted := make([]*line, 0, len(lines))
+	for _, l := range lines {
+		sorted = append(sorted, l)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].description != sorted[j].description {
+			return sorted[i].description < sorted[j].description
+		}
+		return sorted[i].key < sorted[j].key
+	})
+
+	// Each line is rounded on its own, and the total is the sum of the lines, as printed
+	for _, l := range sorted {
+		amount := 0
+		for _, m := range l.ledger.Earnings() {
+			amount += m.Amount
+		}
+		inv.Lines = append(inv.Lines, &model.InvoiceLine{Description: l.description, Seconds: l.seconds, Amount: amount})
+		inv.Total += amount
+	}
+	return inv, nil
+}
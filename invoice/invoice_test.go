@@ -0,0 +1,172 @@
+package invoice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+const uID = "0"
+
+var (
+	eur  = model.Money{Amount: 6000, Currency: "EUR"}
+	no   = false
+	iID  = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	from = 1598918400 // 2020-09-01
+	to   = 1601510400 // 2020-10-01
+)
+
+// newProjects mocks the project Client X, billed at eur, with the subproject Website, which has
+// an achievement on 2020-09-01 and two on 2020-09-02, besides some that must not be invoiced
+func newProjects(s *mocks.Store) (x, website model.Project) {
+	ctx := context.Background()
+	x = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Client X", Rate: &eur, Billable: true}
+	website = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website", ParentID: &x.ID, Billable: true}
+
+	s.On("GetProject", ctx, x.ID).Return(x, nil)
+	s.On("GetProjectAchievements", ctx, x.ID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: x.ID, Start: from + 3600, End: from + 5400},
+		// Not billable
+		{ID: "a2", ProjectID: x.ID, Start: from + 7200, End: from + 9000, Billable: &no},
+		// Still running
+		{ID: "a3", ProjectID: x.ID, Start: from + 10800},
+	}, nil)
+	s.On("GetChildProjects", ctx, x.ID).Return([]model.Project{website}, nil)
+	s.On("GetProjectAchievements", ctx, website.ID).Return([]model.Achievement{
+		{ID: "a4", ProjectID: website.ID, Start: from + 86400, End: from + 86400 + 3600},
+		{ID: "a5", ProjectID: website.ID, Start: from + 90000, End: from + 90000 + 1},
+		// Already invoiced
+		{ID: "a6", ProjectID: website.ID, Start: from + 100000, End: from + 103600, InvoiceID: &iID},
+		// Out of the period
+		{ID: "a7", ProjectID: website.ID, Start: to, End: to + 3600},
+		{ID: "a8", ProjectID: website.ID, Start: from - 3600, End: from + 3600},
+	}, nil)
+	s.On("GetChildProjects", ctx, website.ID).Return([]model.Project{}, nil)
+	s.On("GetUserRounding", ctx, uID).Return((*model.Rounding)(nil), nil)
+	return x, website
+}
+
+func TestBuildPerProject(t *testing.T) {
+	var s mocks.Store
+	x, _ := newProjects(&s)
+
+	inv, err := Build(context.Background(), &s, Request{UserID: uID, ProjectIDs: []string{x.ID}, From: from, To: to,
+		GroupBy: model.InvoiceGroupingProject})
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Invoice{
+		UserID:   uID,
+		From:     from,
+		To:       to,
+		Currency: "EUR",
+		Lines: []*model.InvoiceLine{
+			{Description: "Client X", Seconds: 1800, Amount: 3000},
+			// Website bills at its parent's rate, and 1 second at 60.00/h rounds to 2 cents
+			{Description: "Website", Seconds: 3601, Amount: 6002},
+		},
+		Total:          9002,
+		AchievementIDs: []string{"a1", "a4", "a5"},
+	}, inv)
+	s.AssertExpectations(t)
+}
+
+func TestBuildPerDay(t *testing.T) {
+	var s mocks.Store
+	x, website := newProjects(&s)
+	usd := &model.Money{Amount: 3000, Currency: "USD"}
+	s.On("GetProject", context.Background(), website.ID).Return(website, nil)
+
+	// Requesting a subproject along with its parent doesn't invoice it twice
+	inv, err := Build(context.Background(), &s, Request{UserID: uID, ProjectIDs: []string{x.ID, website.ID},
+		From: from, To: to, Rate: usd, GroupBy: model.InvoiceGroupingDay})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", inv.Currency)
+	assert.Equal(t, []*model.InvoiceLine{
+		{Description: "2020-09-01", Seconds: 1800, Amount: 1500},
+		{Description: "2020-09-02", Seconds: 3601, Amount: 3001},
+	}, inv.Lines)
+	assert.Equal(t, 4501, inv.Total)
+}
+
+func TestBuildRounded(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: uID, Name: "Support", Rate: &eur,
+		Rounding: &model.Rounding{Increment: 6, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry},
+		Billable: true}
+
+	s.On("GetProject", ctx, p.ID).Return(p, nil)
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: p.ID, Start: from, End: from + 60},
+		{ID: "a2", ProjectID: p.ID, Start: from + 3600, End: from + 3600 + 700},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{}, nil)
+
+	inv, err := Build(ctx, &s, Request{UserID: uID, ProjectIDs: []string{p.ID}, From: from, To: to})
+
+	// 1 minute is billed as 6, and 11m40s as 12
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.InvoiceLine{{Description: "Support", Seconds: 1080, Amount: 1800}}, inv.Lines)
+	assert.Equal(t, 1800, inv.Total)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "GetUserRounding", ctx, uID)
+}
+
+func TestBuildWithoutRate(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: uID, Name: "Internal", Billable: true}
+
+	s.On("GetProject", ctx, p.ID).Return(p, nil)
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: p.ID, Start: from, End: from + 3600},
+	}, nil)
+	s.On("GetUserRate", ctx, uID).Return((*model.Money)(nil), nil)
+
+	_, err := Build(ctx, &s, Request{UserID: uID, ProjectIDs: []string{p.ID}, From: from, To: to})
+
+	assert.EqualError(t, err, `project "Internal" has no rate`)
+}
+
+func TestBuildMixedCurrencies(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	x, _ := newProjects(&s)
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: uID, Name: "Client Y", Billable: true,
+		Rate: &model.Money{Amount: 5000, Currency: "USD"}}
+
+	s.On("GetProject", ctx, p.ID).Return(p, nil)
+	s.On("GetProjectAchievements", ctx, p.ID).Return([]model.Achievement{
+		{ID: "a9", ProjectID: p.ID, Start: from, End: from + 3600},
+	}, nil)
+	s.On("GetChildProjects", ctx, p.ID).Return([]model.Project{}, nil)
+
+	_, err := Build(ctx, &s, Request{UserID: uID, ProjectIDs: []string{x.ID, p.ID}, From: from, To: to})
+
+	assert.EqualError(t, err, "can't invoice EUR and USD in the same invoice")
+}
+
+func TestBuildNothingToInvoice(t *testing.T) {
+	var s mocks.Store
+	x, _ := newProjects(&s)
+
+	_, err := Build(context.Background(), &s, Request{UserID: uID, ProjectIDs: []string{x.ID}, From: to + 86400, To: to + 2*86400})
+
+	assert.EqualError(t, err, "there's no billable time to invoice")
+}
+
+func TestBuildOtherUsersProject(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	pID := "3b054f50-9d3d-4114-bfc4-000000000003"
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, UserID: "1"}, nil)
+
+	_, err := Build(ctx, &s, Request{UserID: uID, ProjectIDs: []string{pID}, From: from, To: to})
+
+	assert.EqualError(t, err, "project "+pID+" does not exist")
+	s.AssertNotCalled(t, "GetProjectAchievements", ctx, pID)
+}
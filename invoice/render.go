@@ -0,0 +1,101 @@
+package invoice
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+var funcs = template.FuncMap{
+	"date":   date,
+	"period": period,
+	"hours":  hours,
+	"money":  money,
+}
+
+var page = template.Must(template.New("invoice").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.4em; border-bottom: 1px solid #ccc; text-align: left; }
+.number { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Date: {{date .CreatedAt}}<br>Period: {{period .From .To}}</p>
+<table>
+<thead><tr><th>Description</th><th class="number">Time</th><th class="number">Amount</th></tr></thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.Description}}</td><td class="number">{{hours .Seconds}}</td><td class="number">{{money .Amount $.Currency}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot><tr><th colspan="2">Total</th><th class="number">{{money .Total .Currency}}</th></tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+// date returns the UTC day of a Unix timestamp
+func date(t int) string {
+	return time.Unix(int64(t), 0).UTC().Format(dayLayout)
+}
+
+// period returns the days from from to to, which is not included
+func period(from, to int) string {
+	return date(from) + " - " + date(to-1)
+}
+
+// hours returns seconds as hours and minutes, like 12:05
+func hours(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/3600, seconds%3600/60)
+}
+
+func money(amount int, currency string) string {
+	return billing.Format(model.Money{Amount: amount, Currency: currency})
+}
+
+// HTML writes inv as an HTML page
+func HTML(w io.Writer, inv model.Invoice) error {
+	return page.Execute(w, inv)
+}
+
+// PDF writes inv as a PDF document
+func PDF(w io.Writer, inv model.Invoice) error {
+	const descriptionWidth = 40
+	row := func(description, time, amount string) string {
+		return fmt.Sprintf("%-*s %10s %16s", descriptionWidth, description, time, amount)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Invoice %d", inv.Number),
+		"",
+		"Date:   " + date(inv.CreatedAt),
+		"Period: " + period(inv.From, inv.To),
+		"",
+		row("Description", "Time", "Amount"),
+		strings.Repeat("-", descriptionWidth+28),
+	}
+	for _, l := range inv.Lines {
+		description := []rune(l.Description)
+		if len(description) > descriptionWidth {
+			description = append(description[:descriptionWidth-3], []rune("...")...)
+		}
+		lines = append(lines, row(string(description), hours(l.Seconds), money(l.Amount, inv.Currency)))
+	}
+	lines = append(lines,
+		strings.Repeat("-", descriptionWidth+28),
+		row("Total", "", money(inv.Total, inv.Currency)),
+	)
+	return writePDF(w, lines)
+}
@@ -0,0 +1,76 @@
+package invoice
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var inv = model.Invoice{
+	ID:        "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+	UserID:    uID,
+	Number:    7,
+	From:      from,
+	To:        to,
+	CreatedAt: to + 3600,
+	Currency:  "EUR",
+	Lines: []*model.InvoiceLine{
+		{Description: "Client X <Website>", Seconds: 1800, Amount: 3000},
+		{Description: "Diseño (backend)", Seconds: 36060, Amount: 60100},
+	},
+	Total: 63100,
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, HTML(&buf, inv))
+
+	html := buf.String()
+	assert.Contains(t, html, "<h1>Invoice 7</h1>")
+	assert.Contains(t, html, "Period: 2020-09-01 - 2020-09-30")
+	assert.Contains(t, html, "<td>Client X &lt;Website&gt;</td><td class=\"number\">0:30</td><td class=\"number\">30.00 EUR</td>")
+	assert.Contains(t, html, "<td>Diseño (backend)</td><td class=\"number\">10:01</td><td class=\"number\">601.00 EUR</td>")
+	assert.Contains(t, html, "<th class=\"number\">631.00 EUR</th>")
+}
+
+func TestPDF(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, PDF(&buf, inv))
+
+	pdf := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "(Invoice 7) '")
+	assert.Contains(t, string(pdf), "(Dise\xf1o \\(backend\\)")
+
+	// Every object is where the cross-reference table says
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(string(pdf), -1)
+	require.Len(t, offsets, 5)
+	for i, o := range offsets {
+		offset, _ := strconv.Atoi(o[1])
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")), "object %d", i+1)
+	}
+}
+
+func TestPDFPages(t *testing.T) {
+	var buf bytes.Buffer
+	lines := make([]string, 2*linesPerPage+1)
+
+	require.NoError(t, writePDF(&buf, lines))
+
+	assert.Contains(t, buf.String(), "/Kids [4 0 R 6 0 R 8 0 R] /Count 3")
+}
+
+func TestEscapePDF(t *testing.T) {
+	assert.Equal(t, `a\(b\)c\\d`, escapePDF(`a(b)c\d`))
+	assert.Equal(t, "caf\xe9 ? ok", escapePDF("café € ok\n"))
+	assert.False(t, strings.ContainsRune(escapePDF("\t"), '\t'))
+}
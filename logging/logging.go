@@ -0,0 +1,61 @@
+// Package logging provides the structured logger of the server and
+// the request IDs used to correlate the log lines of each request
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New creates a logger writing to out with the given level and format
+func New(out io.Writer, level, format string) (*logrus.Logger, error) {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(out)
+	logger.SetLevel(l)
+	switch format {
+	case FormatText:
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return logger, nil
+}
+
+type contextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// WithContext returns a logger that adds the ID of the request ctx belongs to to every line
+func WithContext(ctx context.Context, logger logrus.FieldLogger) logrus.FieldLogger {
+	id := RequestID(ctx)
+	if id == "" {
+		return logger
+	}
+	return logger.WithField("requestID", id)
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", FormatJSON)
+	assert.NoError(t, err)
+
+	logger.Info("hidden")
+	logger.WithField("key", "value").Warn("shown")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), `"key":"value"`)
+	assert.Contains(t, buf.String(), `"msg":"shown"`)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "verbose", FormatText)
+	assert.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	assert.EqualError(t, err, `unknown log format "xml"`)
+}
+
+func TestWithContext(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	WithContext(context.Background(), logger).Info("without ID")
+	WithContext(WithRequestID(context.Background(), "abc"), logger).Info("with ID")
+
+	entries := hook.AllEntries()
+	assert.NotContains(t, entries[0].Data, "requestID")
+	assert.Equal(t, "abc", entries[1].Data["requestID"])
+}
+
+func serve(logger logrus.FieldLogger, id string) (*httptest.ResponseRecorder, string) {
+	var seen string
+	h := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest("POST", "/query", nil)
+	if id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestMiddleware(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	w, seen := serve(logger, "")
+
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 36)
+	assert.Equal(t, id, seen)
+	entry := hook.LastEntry()
+	assert.Equal(t, "Request completed", entry.Message)
+	assert.Equal(t, id, entry.Data["requestID"])
+	assert.Equal(t, http.StatusTeapot, entry.Data["status"])
+	assert.Equal(t, "/query", entry.Data["path"])
+}
+
+func TestMiddlewareReusesRequestID(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	w, seen := serve(logger, "client-id")
+
+	assert.Equal(t, "client-id", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-id", seen)
+}
+
+func TestMiddlewareRejectsInvalidRequestID(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	for _, id := range []string{"with spaces", strings.Repeat("a", maxRequestIDLength+1)} {
+		w, seen := serve(logger, id)
+
+		assert.NotEqual(t, id, seen)
+		assert.Equal(t, w.Header().Get(RequestIDHeader), seen)
+	}
+}
+
+func TestMiddlewareQuietPaths(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	h := Middleware(logger, "/healthz")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/query", nil))
+	assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+}
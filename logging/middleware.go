@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is the header carrying the request ID, both in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// Middleware assigns an ID to every request, reusing the one sent by the client if valid,
+// adds it to the request context and the response headers, and logs every completed request.
+// Requests to quietPaths, like probes and metrics scrapes, are logged at debug level so that they don't
+// flood the logs.
+func Middleware(logger logrus.FieldLogger, quietPaths ...string) func(http.Handler) http.Handler {
+	quiet := make(map[string]bool, len(quietPaths))
+	for _, path := range quietPaths {
+		quiet[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.New().String()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(WithRequestID(r.Context(), id)))
+
+			level := logrus.InfoLevel
+			if quiet[r.URL.Path] {
+				level = logrus.DebugLevel
+			}
+			logger.WithFields(logrus.Fields{
+				"requestID": id,
+				"method":    r.Method,
+				"path":      r.URL.Path,
+				"status":    rec.status,
+				"duration":  time.Since(start).String(),
+			}).Log(level, "Request completed")
+		})
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// statusRecorder keeps the status code of the response.
+// It can be hijacked, so that websockets keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer can't be hijacked")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hj.Hijack()
+}
@@ -1,42 +1,194 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
-	"github.com/gomodule/redigo/redis"
+	"github.com/gorilla/websocket"
+	"github.com/smeruelo/glow/audit"
+	"github.com/smeruelo/glow/config"
+	"github.com/smeruelo/glow/export"
 	"github.com/smeruelo/glow/graph"
 	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/invoice"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/metrics"
+	"github.com/smeruelo/glow/ratelimit"
+	"github.com/smeruelo/glow/search"
+	"github.com/smeruelo/glow/server"
 	"github.com/smeruelo/glow/storage"
+	"github.com/smeruelo/glow/tracing"
+	"github.com/smeruelo/glow/trash"
+)
+
+// Build information, set with -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version string
+	commit  string
+	date    string
 )
 
 func main() {
-	dbHost, ok := os.LookupEnv("DB_HOST")
-	if !ok {
-		log.Fatal("Environment variable DB_HOST not found")
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatalf("Unable to %s: %s", os.Args[1], err)
+			}
+			return
+		}
 	}
 
-	dbPort, ok := os.LookupEnv("DB_PORT")
-	if !ok {
-		log.Fatal("Environment variable DB_PORT not found")
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s", err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("Invalid log settings: %s", err)
 	}
 
-	db, err := redis.Dial("tcp", dbHost+":"+dbPort)
+	r := cfg.Storage.Redis
+	db := storage.NewPool(r.Address(), r.Password, r.DB, r.PoolSize)
+	if err := storage.Migrate(context.Background(), db, logger); err != nil {
+		logger.WithError(err).Fatal("Unable to migrate database")
+	}
+	provider, traces, err := tracing.NewProvider(cfg.Tracing, version)
 	if err != nil {
-		log.Printf("Unable to connect to database: %s", err)
+		logger.WithError(err).Fatal("Unable to create traces exporter")
+	}
+	t := tracing.New(provider)
+
+	redisearch, err := storage.EnsureSearchIndex(context.Background(), db, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Unable to create search index")
+	}
+	base := storage.NewRedisStore(db, logger)
+	if !redisearch {
+		logger.Warn("RediSearch not available, notes are searched in memory, which misses changes made by other instances")
+		base = search.Store(base, logger)
+	}
+
+	m := metrics.New()
+	store := audit.Store(m.Store(t.Store(base)), logger)
+	resolver := graph.NewResolver(store, logger)
+	// Read straight from Redis, so that scrapes don't show up in the storage metrics and traces
+	m.RunningTimers(func() (int, error) {
+		return storage.RunningTimers(context.Background(), db, logger)
+	})
+
+	graphqlServer := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Complexity: graph.NewComplexity(),
+	}))
+	cors := server.CORS{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           time.Duration(cfg.CORS.MaxAge),
+	}
+	graphqlServer.AddTransport(transport.Websocket{
+		Upgrader:              websocket.Upgrader{CheckOrigin: cors.CheckOrigin},
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	graphqlServer.AddTransport(transport.Options{})
+	graphqlServer.AddTransport(transport.GET{})
+	graphqlServer.AddTransport(transport.POST{})
+	graphqlServer.AddTransport(transport.MultipartForm{})
+	graphqlServer.SetQueryCache(lru.New(1000))
+	graphqlServer.Use(extension.Introspection{})
+
+	// In strict mode only picard's operations, registered at build time, are accepted
+	if cfg.Query.Strict {
+		if len(graph.RegisteredOperations) == 0 {
+			logger.Fatal("Strict mode enabled but there are no registered operations, run go generate ./graph")
+		}
+		graphqlServer.Use(graph.OperationAllowlist{Queries: graph.RegisteredOperations})
+	} else {
+		graphqlServer.Use(extension.AutomaticPersistedQuery{
+			Cache: storage.NewRedisQueryCache(db, time.Duration(cfg.Query.APQTTL), logger),
+		})
+	}
+	var query http.Handler = graphqlServer
+	if rl := cfg.RateLimit; rl.Enabled {
+		limiter := ratelimit.NewMemoryLimiter()
+		if rl.Backend == config.LimiterRedis {
+			limiter = ratelimit.NewRedisLimiter(db)
+		}
+		fields := map[string]ratelimit.Rule{}
+		for f, r := range rl.Fields {
+			fields[f] = ratelimit.Rule{Limit: r.Limit, Period: r.Period}
+		}
+		graphqlServer.Use(ratelimit.Extension{
+			Limiter: limiter,
+			Default: ratelimit.Rule{Limit: rl.Default.Limit, Period: rl.Default.Period},
+			Fields:  fields,
+			Logger:  logger,
+		})
+		// Logged in clients are limited per user, the rest per IP address
+		query = ratelimit.Middleware(ratelimit.BearerUser(store.GetSessionUser), rl.TrustedProxies)(query)
 	}
-	defer db.Close()
-	store := storage.NewRedisStore(db)
+	graphqlServer.Use(graph.DepthLimit{Limit: cfg.Query.MaxDepth})
+	graphqlServer.Use(extension.FixedComplexityLimit(cfg.Query.MaxComplexity))
+	graphqlServer.Use(m.Extension())
+	graphqlServer.Use(t.Extension())
 
-	graphqlServer := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{
-		Resolvers: graph.NewResolver(store),
+	mux := http.NewServeMux()
+	if cfg.Playground.Enabled {
+		mux.Handle(cfg.Playground.Path, playground.Handler("GraphQL playground", "/query"))
+	}
+	mux.Handle("/query", cors.Handler(query))
+	mux.Handle("/invoices/", cors.Handler(http.StripPrefix("/invoices/", invoice.Handler(store, logger))))
+	mux.Handle("/export", cors.Handler(export.Handler(store, logger)))
+
+	// Probes and scrapes are neither traced nor logged but at debug level, they would only add noise
+	quiet := []string{"/healthz", "/readyz", "/metrics"}
+	handler := logging.Middleware(logger, quiet...)(t.Handler(mux, quiet...))
+	srv := server.New(cfg.Listen, handler, logger, db, traces)
+	mux.Handle("/healthz", server.HealthHandler())
+	mux.Handle("/readyz", srv.ReadyHandler(map[string]server.Check{
+		"storage": store.Ping,
+		"migrations": func(ctx context.Context) error {
+			return storage.Migrated(ctx, db, logger)
+		},
 	}))
+	mux.Handle("/version", server.VersionHandler(version, commit, date))
+	mux.Handle("/metrics", m.Handler())
+	purger := trash.NewPurger(store, time.Duration(cfg.Trash.Retention), logger)
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go purger.Run(purgeCtx, time.Duration(cfg.Trash.PurgeInterval))
+
+	go func() {
+		if err := srv.ListenAndServe(cfg.TLS.Cert, cfg.TLS.Key); err != http.ErrServerClosed {
+			logger.WithError(err).Fatal("Unable to serve")
+		}
+	}()
 
-	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", graphqlServer)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	<-stop
 
-	log.Fatal(http.ListenAndServe(":80", nil))
+	// Load balancers need some time to notice that the server isn't ready
+	srv.Unready()
+	logger.WithField("shutdownDelay", time.Duration(cfg.ShutdownDelay)).Info("Shutting down, no longer ready")
+	time.Sleep(time.Duration(cfg.ShutdownDelay))
+
+	logger.WithField("drainTimeout", time.Duration(cfg.DrainTimeout)).Info("Shutting down, waiting for in-flight requests")
+	stopPurge()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.DrainTimeout))
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.WithError(err).Error("Shutdown error")
+	}
 }
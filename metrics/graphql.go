@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Extension returns a handler extension that records the latency and errors
+// of every GraphQL operation and resolver
+func (m *Metrics) Extension() graphql.HandlerExtension {
+	return extension{m}
+}
+
+type extension struct {
+	m *Metrics
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = extension{}
+
+func (e extension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (e extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// operationLabels returns the type and name of the operation being executed
+func operationLabels(rc *graphql.OperationContext) (string, string) {
+	if rc.Operation == nil {
+		return "", ""
+	}
+	name := rc.Operation.Name
+	if name == "" {
+		name = "anonymous"
+	}
+	return string(rc.Operation.Operation), name
+}
+
+func (e extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation == nil || rc.Operation.Operation != ast.Subscription {
+		return next(ctx)
+	}
+
+	// Subscriptions keep responding until their stream ends
+	e.m.activeSubscriptions.Inc()
+	responses := next(ctx)
+	done := false
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		if resp == nil && !done {
+			done = true
+			e.m.activeSubscriptions.Dec()
+		}
+		return resp
+	}
+}
+
+func (e extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	opType, opName := operationLabels(rc)
+
+	resp := next(ctx)
+
+	// The duration of a subscription is not meaningful, only its errors are
+	if opType != string(ast.Subscription) && resp != nil {
+		e.m.operationDuration.WithLabelValues(opType, opName).Observe(time.Since(rc.Stats.OperationStart).Seconds())
+	}
+	if resp != nil && len(resp.Errors) > 0 {
+		e.m.operationErrors.WithLabelValues(opType, opName).Inc()
+	}
+
+	return resp
+}
+
+func (e extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsMethod {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	e.m.fieldDuration.WithLabelValues(fc.Object, fc.Field.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		e.m.fieldErrors.WithLabelValues(fc.Object, fc.Field.Name).Inc()
+	}
+
+	return res, err
+}
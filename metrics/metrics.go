@@ -0,0 +1,115 @@
+// Package metrics exposes Prometheus metrics about GraphQL operations and storage calls
+package metrics
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "glow"
+
+// Metrics contains the collectors of the server
+type Metrics struct {
+	registry *prometheus.Registry
+
+	operationDuration   *prometheus.HistogramVec
+	operationErrors     *prometheus.CounterVec
+	fieldDuration       *prometheus.HistogramVec
+	fieldErrors         *prometheus.CounterVec
+	activeSubscriptions prometheus.Gauge
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them, along with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		operationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "operation_duration_seconds",
+			Help:      "Time taken to execute GraphQL operations.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type", "operation"}),
+		operationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "operation_errors_total",
+			Help:      "Number of GraphQL operations responded with errors.",
+		}, []string{"type", "operation"}),
+		fieldDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "field_duration_seconds",
+			Help:      "Time taken to resolve GraphQL fields backed by a resolver.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"object", "field"}),
+		fieldErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "field_errors_total",
+			Help:      "Number of GraphQL fields whose resolver returned an error.",
+		}, []string{"object", "field"}),
+		activeSubscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "graphql",
+			Name:      "active_subscriptions",
+			Help:      "Number of GraphQL subscriptions currently running.",
+		}),
+
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "call_duration_seconds",
+			Help:      "Time taken by storage calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "call_errors_total",
+			Help:      "Number of storage calls that failed.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.operationDuration,
+		m.operationErrors,
+		m.fieldDuration,
+		m.fieldErrors,
+		m.activeSubscriptions,
+		m.storageDuration,
+		m.storageErrors,
+	)
+
+	return m
+}
+
+// RunningTimers exports the number of running timers, as returned by count on every scrape.
+// If count fails the gauge is NaN.
+func (m *Metrics) RunningTimers(count func() (int, error)) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "running_timers",
+		Help:      "Number of achievements whose timer is still running.",
+	}, func() float64 {
+		n, err := count()
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(n)
+	}))
+}
+
+// Handler returns the handler serving the metrics to Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
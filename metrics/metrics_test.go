@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStore(t *testing.T) {
+	var s mocks.Store
+	m := New()
+	store := m.Store(&s)
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	s.On("GetProject", mock.Anything, pID).Return(model.Project{ID: pID}, nil).Once()
+	s.On("GetProject", mock.Anything, pID).Return(model.Project{}, errors.New("")).Once()
+
+	_, err := store.GetProject(context.Background(), pID)
+	assert.NoError(t, err)
+	_, err = store.GetProject(context.Background(), pID)
+	assert.Error(t, err)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m.storageDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues("GetProject")))
+	s.AssertExpectations(t)
+}
+
+func TestExtension(t *testing.T) {
+	var s mocks.Store
+	logger, _ := test.NewNullLogger()
+	m := New()
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: graph.NewResolver(&s, logger),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(m.Extension())
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	s.On("GetProject", mock.Anything, pID).Return(model.Project{}, errors.New("not found"))
+
+	body := `{"query":"query P { project(id: \"` + pID + `\") { name } }"}`
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m.operationDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.operationErrors.WithLabelValues("query", "P")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.fieldDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.fieldErrors.WithLabelValues("Query", "project")))
+	s.AssertExpectations(t)
+}
+
+func TestRunningTimers(t *testing.T) {
+	m := New()
+	m.RunningTimers(func() (int, error) { return 2, nil })
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, w.Body.String(), "\nglow_running_timers 2\n")
+}
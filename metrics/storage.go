@@ -0,0 +1,411 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Store returns a Store that records the latency and failures of every call to s
+func (m *Metrics) Store(s storage.Store) storage.Store {
+	return store{s: s, m: m}
+}
+
+type store struct {
+	s storage.Store
+	m *Metrics
+}
+
+func (s store) observe(method string, start time.Time, err error) {
+	s.m.storageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.m.storageErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func (s store) CreateProject(ctx context.Context, p model.Project) error {
+	start := time.Now()
+	err := s.s.CreateProject(ctx, p)
+	s.observe("CreateProject", start, err)
+	return err
+}
+
+func (s store) GetProject(ctx context.Context, pID string) (model.Project, error) {
+	start := time.Now()
+	p, err := s.s.GetProject(ctx, pID)
+	s.observe("GetProject", start, err)
+	return p, err
+}
+
+func (s store) GetUserProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	start := time.Now()
+	ps, err := s.s.GetUserProjects(ctx, uID)
+	s.observe("GetUserProjects", start, err)
+	return ps, err
+}
+
+func (s store) UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error) {
+	start := time.Now()
+	p, err := s.s.UpdateProject(ctx, pID, np)
+	s.observe("UpdateProject", start, err)
+	return p, err
+}
+
+func (s store) GetArchivedProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	start := time.Now()
+	ps, err := s.s.GetArchivedProjects(ctx, uID)
+	s.observe("GetArchivedProjects", start, err)
+	return ps, err
+}
+
+func (s store) GetChildProjects(ctx context.Context, pID string) ([]model.Project, error) {
+	start := time.Now()
+	ps, err := s.s.GetChildProjects(ctx, pID)
+	s.observe("GetChildProjects", start, err)
+	return ps, err
+}
+
+func (s store) ArchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	start := time.Now()
+	p, err := s.s.ArchiveProject(ctx, pID, uID)
+	s.observe("ArchiveProject", start, err)
+	return p, err
+}
+
+func (s store) UnarchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	start := time.Now()
+	p, err := s.s.UnarchiveProject(ctx, pID, uID)
+	s.observe("UnarchiveProject", start, err)
+	return p, err
+}
+
+func (s store) DeleteProject(ctx context.Context, pID, uID string) error {
+	start := time.Now()
+	err := s.s.DeleteProject(ctx, pID, uID)
+	s.observe("DeleteProject", start, err)
+	return err
+}
+
+func (s store) CreateCategory(ctx context.Context, c model.Category) error {
+	start := time.Now()
+	err := s.s.CreateCategory(ctx, c)
+	s.observe("CreateCategory", start, err)
+	return err
+}
+
+func (s store) GetCategory(ctx context.Context, cID string) (model.Category, error) {
+	start := time.Now()
+	c, err := s.s.GetCategory(ctx, cID)
+	s.observe("GetCategory", start, err)
+	return c, err
+}
+
+func (s store) GetUserCategories(ctx context.Context, uID string) ([]model.Category, error) {
+	start := time.Now()
+	cs, err := s.s.GetUserCategories(ctx, uID)
+	s.observe("GetUserCategories", start, err)
+	return cs, err
+}
+
+func (s store) UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error) {
+	start := time.Now()
+	c, err := s.s.UpdateCategory(ctx, cID, nc)
+	s.observe("UpdateCategory", start, err)
+	return c, err
+}
+
+func (s store) DeleteCategory(ctx context.Context, cID, uID string) error {
+	start := time.Now()
+	err := s.s.DeleteCategory(ctx, cID, uID)
+	s.observe("DeleteCategory", start, err)
+	return err
+}
+
+func (s store) CreateTag(ctx context.Context, t model.Tag) error {
+	start := time.Now()
+	err := s.s.CreateTag(ctx, t)
+	s.observe("CreateTag", start, err)
+	return err
+}
+
+func (s store) GetTag(ctx context.Context, tID string) (model.Tag, error) {
+	start := time.Now()
+	t, err := s.s.GetTag(ctx, tID)
+	s.observe("GetTag", start, err)
+	return t, err
+}
+
+func (s store) GetUserTags(ctx context.Context, uID string) ([]model.Tag, error) {
+	start := time.Now()
+	ts, err := s.s.GetUserTags(ctx, uID)
+	s.observe("GetUserTags", start, err)
+	return ts, err
+}
+
+func (s store) UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error) {
+	start := time.Now()
+	t, err := s.s.UpdateTag(ctx, tID, nt)
+	s.observe("UpdateTag", start, err)
+	return t, err
+}
+
+func (s store) DeleteTag(ctx context.Context, tID, uID string) error {
+	start := time.Now()
+	err := s.s.DeleteTag(ctx, tID, uID)
+	s.observe("DeleteTag", start, err)
+	return err
+}
+
+func (s store) TagProject(ctx context.Context, pID, tID string) error {
+	start := time.Now()
+	err := s.s.TagProject(ctx, pID, tID)
+	s.observe("TagProject", start, err)
+	return err
+}
+
+func (s store) UntagProject(ctx context.Context, pID, tID string) error {
+	start := time.Now()
+	err := s.s.UntagProject(ctx, pID, tID)
+	s.observe("UntagProject", start, err)
+	return err
+}
+
+func (s store) GetProjectTags(ctx context.Context, pID string) ([]model.Tag, error) {
+	start := time.Now()
+	ts, err := s.s.GetProjectTags(ctx, pID)
+	s.observe("GetProjectTags", start, err)
+	return ts, err
+}
+
+func (s store) TagAchievement(ctx context.Context, aID, tID string) error {
+	start := time.Now()
+	err := s.s.TagAchievement(ctx, aID, tID)
+	s.observe("TagAchievement", start, err)
+	return err
+}
+
+func (s store) UntagAchievement(ctx context.Context, aID, tID string) error {
+	start := time.Now()
+	err := s.s.UntagAchievement(ctx, aID, tID)
+	s.observe("UntagAchievement", start, err)
+	return err
+}
+
+func (s store) GetAchievementTags(ctx context.Context, aID string) ([]model.Tag, error) {
+	start := time.Now()
+	ts, err := s.s.GetAchievementTags(ctx, aID)
+	s.observe("GetAchievementTags", start, err)
+	return ts, err
+}
+
+func (s store) GetTaggedAchievements(ctx context.Context, tagIDs []string) ([]model.Achievement, error) {
+	start := time.Now()
+	as, err := s.s.GetTaggedAchievements(ctx, tagIDs)
+	s.observe("GetTaggedAchievements", start, err)
+	return as, err
+}
+
+func (s store) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	start := time.Now()
+	err := s.s.CreateAchievement(ctx, a)
+	s.observe("CreateAchievement", start, err)
+	return err
+}
+
+func (s store) GetAchievement(ctx context.Context, aID string) (model.Achievement, error) {
+	start := time.Now()
+	a, err := s.s.GetAchievement(ctx, aID)
+	s.observe("GetAchievement", start, err)
+	return a, err
+}
+
+func (s store) GetProjectAchievements(ctx context.Context, pID string) ([]model.Achievement, error) {
+	start := time.Now()
+	as, err := s.s.GetProjectAchievements(ctx, pID)
+	s.observe("GetProjectAchievements", start, err)
+	return as, err
+}
+
+func (s store) GetUserAchievements(ctx context.Context, uID string) ([]model.Achievement, error) {
+	start := time.Now()
+	as, err := s.s.GetUserAchievements(ctx, uID)
+	s.observe("GetUserAchievements", start, err)
+	return as, err
+}
+
+func (s store) UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error) {
+	start := time.Now()
+	a, err := s.s.UpdateAchievement(ctx, aID, newData)
+	s.observe("UpdateAchievement", start, err)
+	return a, err
+}
+
+func (s store) SetAchievementNote(ctx context.Context, aID, note string) error {
+	start := time.Now()
+	err := s.s.SetAchievementNote(ctx, aID, note)
+	s.observe("SetAchievementNote", start, err)
+	return err
+}
+
+func (s store) SetAchievementBillable(ctx context.Context, aID string, billable *bool) error {
+	start := time.Now()
+	err := s.s.SetAchievementBillable(ctx, aID, billable)
+	s.observe("SetAchievementBillable", start, err)
+	return err
+}
+
+func (s store) SearchAchievements(ctx context.Context, uID, text string) ([]model.Achievement, error) {
+	start := time.Now()
+	as, err := s.s.SearchAchievements(ctx, uID, text)
+	s.observe("SearchAchievements", start, err)
+	return as, err
+}
+
+func (s store) DeleteAchievement(ctx context.Context, aID, pID string) error {
+	start := time.Now()
+	err := s.s.DeleteAchievement(ctx, aID, pID)
+	s.observe("DeleteAchievement", start, err)
+	return err
+}
+
+func (s store) GetTrash(ctx context.Context, uID string) ([]model.TrashItem, error) {
+	start := time.Now()
+	items, err := s.s.GetTrash(ctx, uID)
+	s.observe("GetTrash", start, err)
+	return items, err
+}
+
+func (s store) GetExpiredTrash(ctx context.Context, before int) ([]model.TrashItem, error) {
+	start := time.Now()
+	items, err := s.s.GetExpiredTrash(ctx, before)
+	s.observe("GetExpiredTrash", start, err)
+	return items, err
+}
+
+func (s store) RestoreProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	start := time.Now()
+	p, err := s.s.RestoreProject(ctx, pID, uID)
+	s.observe("RestoreProject", start, err)
+	return p, err
+}
+
+func (s store) RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error) {
+	start := time.Now()
+	a, err := s.s.RestoreAchievement(ctx, aID, uID)
+	s.observe("RestoreAchievement", start, err)
+	return a, err
+}
+
+func (s store) PurgeProject(ctx context.Context, pID, uID string) error {
+	start := time.Now()
+	err := s.s.PurgeProject(ctx, pID, uID)
+	s.observe("PurgeProject", start, err)
+	return err
+}
+
+func (s store) PurgeAchievement(ctx context.Context, aID, uID string) error {
+	start := time.Now()
+	err := s.s.PurgeAchievement(ctx, aID, uID)
+	s.observe("PurgeAchievement", start, err)
+	return err
+}
+
+func (s store) GetUsers(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	users, err := s.s.GetUsers(ctx)
+	s.observe("GetUsers", start, err)
+	return users, err
+}
+
+func (s store) GetUserRate(ctx context.Context, uID string) (*model.Money, error) {
+	start := time.Now()
+	rate, err := s.s.GetUserRate(ctx, uID)
+	s.observe("GetUserRate", start, err)
+	return rate, err
+}
+
+func (s store) SetUserRate(ctx context.Context, uID string, rate *model.Money) error {
+	start := time.Now()
+	err := s.s.SetUserRate(ctx, uID, rate)
+	s.observe("SetUserRate", start, err)
+	return err
+}
+
+func (s store) CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error) {
+	start := time.Now()
+	inv, err := s.s.CreateInvoice(ctx, inv)
+	s.observe("CreateInvoice", start, err)
+	return inv, err
+}
+
+func (s store) GetInvoice(ctx context.Context, iID string) (model.Invoice, error) {
+	start := time.Now()
+	inv, err := s.s.GetInvoice(ctx, iID)
+	s.observe("GetInvoice", start, err)
+	return inv, err
+}
+
+func (s store) GetUserInvoices(ctx context.Context, uID string) ([]model.Invoice, error) {
+	start := time.Now()
+	invs, err := s.s.GetUserInvoices(ctx, uID)
+	s.observe("GetUserInvoices", start, err)
+	return invs, err
+}
+
+func (s store) GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error) {
+	start := time.Now()
+	r, err := s.s.GetUserRounding(ctx, uID)
+	s.observe("GetUserRounding", start, err)
+	return r, err
+}
+
+func (s store) SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error {
+	start := time.Now()
+	err := s.s.SetUserRounding(ctx, uID, r)
+	s.observe("SetUserRounding", start, err)
+	return err
+}
+
+func (s store) CreateExportToken(ctx context.Context, t model.ExportToken) error {
+	start := time.Now()
+	err := s.s.CreateExportToken(ctx, t)
+	s.observe("CreateExportToken", start, err)
+	return err
+}
+
+func (s store) GetExportToken(ctx context.Context, token string) (model.ExportToken, error) {
+	start := time.Now()
+	t, err := s.s.GetExportToken(ctx, token)
+	s.observe("GetExportToken", start, err)
+	return t, err
+}
+
+func (s store) AppendAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	start := time.Now()
+	err := s.s.AppendAuditEntry(ctx, e)
+	s.observe("AppendAuditEntry", start, err)
+	return err
+}
+
+func (s store) GetAuditLog(ctx context.Context, entityID string, from, to int) ([]model.AuditEntry, error) {
+	start := time.Now()
+	es, err := s.s.GetAuditLog(ctx, entityID, from, to)
+	s.observe("GetAuditLog", start, err)
+	return es, err
+}
+
+func (s store) GetSessionUser(ctx context.Context, token string) (string, error) {
+	start := time.Now()
+	uID, err := s.s.GetSessionUser(ctx, token)
+	s.observe("GetSessionUser", start, err)
+	return uID, err
+}
+
+func (s store) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := s.s.Ping(ctx)
+	s.observe("Ping", start, err)
+	return err
+}
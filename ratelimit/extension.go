@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/logging"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errRateLimited = "RATE_LIMITED"
+
+// client is the client sending a request, and the headers of its response.
+// Websocket connections have no headers to set, their operations only get errors.
+type client struct {
+	key    string
+	header http.Header
+}
+
+type contextKey struct{}
+
+// Middleware identifies the client sending every request, so that the Extension can limit it
+// and add the RateLimit headers to the response.
+// Clients are identified by the user returned by user, if any, or by their IP address.
+// The IP address is taken from the X-Forwarded-For header when the server is behind trustedProxies proxies.
+func Middleware(user func(r *http.Request) string, trustedProxies int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key string
+			if user != nil {
+				if id := user(r); id != "" {
+					key = "user:" + id
+				}
+			}
+			if key == "" {
+				key = "ip:" + clientIP(r, trustedProxies)
+			}
+
+			c := client{key: key}
+			if r.Header.Get("Upgrade") == "" {
+				c.header = w.Header()
+			}
+			ctx := context.WithValue(r.Context(), contextKey{}, c)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// BearerUser returns the user logged in with the bearer token of a request, looked up by session,
+// for the Middleware. Requests without a token or with an unknown one get "".
+func BearerUser(session func(ctx context.Context, token string) (string, error)) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		auth := r.Header.Get("Authorization")
+		if len(auth) <= len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+			return ""
+		}
+		uID, err := session(r.Context(), strings.TrimSpace(auth[len("Bearer "):]))
+		if err != nil {
+			return ""
+		}
+		return uID
+	}
+}
+
+// clientIP returns the IP address of the client that sent r through trustedProxies proxies.
+// Every proxy appends the address it received the request from to X-Forwarded-For, so the client's
+// is the one added by the farthest trusted proxy. The ones before it could be made up by the client.
+func clientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var ips []string
+		for _, h := range r.Header.Values("X-Forwarded-For") {
+			ips = append(ips, strings.Split(h, ",")...)
+		}
+		if len(ips) > 0 {
+			i := len(ips) - trustedProxies
+			if i < 0 {
+				i = 0
+			}
+			return strings.TrimSpace(ips[i])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Extension is a handler extension that takes a token from the client's bucket for every operation.
+// Operations selecting a root field listed in Fields, such as a mutation, also take a token
+// from the bucket of that field, so that they can be limited further.
+// Requests that didn't go through the Middleware are not limited.
+//
+// If the limiter fails operations are allowed.
+type Extension struct {
+	Limiter Limiter
+	Default Rule
+	Fields  map[string]Rule
+	Logger  logrus.FieldLogger
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Extension{}
+
+// ExtensionName returns the name of the extension
+func (e Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+// Validate checks that the extension is properly configured
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	if e.Limiter == nil {
+		return fmt.Errorf("RateLimit has no limiter")
+	}
+	for name, rule := range e.rules() {
+		if rule.Limit < 1 || rule.Period <= 0 {
+			return fmt.Errorf("invalid rate limit for %s", name)
+		}
+	}
+	return nil
+}
+
+func (e Extension) rules() map[string]Rule {
+	rules := map[string]Rule{"default": e.Default}
+	for name, rule := range e.Fields {
+		rules[name] = rule
+	}
+	return rules
+}
+
+// MutateOperationContext takes the tokens needed by the operation, or rejects it
+func (e Extension) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	c, ok := ctx.Value(contextKey{}).(client)
+	if !ok {
+		return nil
+	}
+
+	buckets := []string{"default"}
+	rules := []Rule{e.Default}
+	for _, f := range rootFields(rc) {
+		if rule, ok := e.Fields[f]; ok {
+			buckets = append(buckets, f)
+			rules = append(rules, rule)
+		}
+	}
+
+	// The headers describe the bucket closest to be empty
+	var tightest Result
+	for i, bucket := range buckets {
+		res, err := e.Limiter.Take(ctx, bucket+":"+c.key, rules[i])
+		if err != nil {
+			logging.WithContext(ctx, e.Logger).WithError(err).Error("Unable to apply rate limit")
+			return nil
+		}
+		if i == 0 || res.Remaining < tightest.Remaining || !res.Allowed {
+			tightest = res
+		}
+		if !res.Allowed {
+			// Rejected operations don't count against the buckets that allowed them
+			for j := 0; j < i; j++ {
+				if err := e.Limiter.Refund(ctx, buckets[j]+":"+c.key, rules[j]); err != nil {
+					logging.WithContext(ctx, e.Logger).WithError(err).Error("Unable to refund rate limit")
+				}
+			}
+			break
+		}
+	}
+	if c.header != nil {
+		setHeaders(c.header, tightest)
+	}
+
+	if !tightest.Allowed {
+		retryAfter := seconds(tightest.RetryAfter)
+		err := gqlerror.Errorf("rate limit exceeded, retry in %d seconds", retryAfter)
+		errcode.Set(err, errRateLimited)
+		err.Extensions["retryAfter"] = retryAfter
+		return err
+	}
+	return nil
+}
+
+// rootFields returns the names of the fields selected at the root of the operation
+func rootFields(rc *graphql.OperationContext) []string {
+	if rc.Operation == nil {
+		return nil
+	}
+	root := "Query"
+	switch rc.Operation.Operation {
+	case ast.Mutation:
+		root = "Mutation"
+	case ast.Subscription:
+		root = "Subscription"
+	}
+
+	var names []string
+	for _, f := range graphql.CollectFields(rc, rc.Operation.SelectionSet, []string{root}) {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// setHeaders adds the RateLimit headers, as defined by the IETF draft, and Retry-After if throttled
+func setHeaders(h http.Header, res Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+	}
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newLimitedServer(s *mocks.Store, def Rule, fields map[string]Rule) http.Handler {
+	logger, _ := test.NewNullLogger()
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: graph.NewResolver(s, logger),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(Extension{Limiter: NewMemoryLimiter(), Default: def, Fields: fields, Logger: logger})
+	return Middleware(nil, 1)(srv)
+}
+
+func post(h http.Handler, ip, query string) *httptest.ResponseRecorder {
+	body := `{"query":"` + strings.Replace(query, `"`, `\"`, -1) + `"}`
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", ip)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestExtensionHeaders(t *testing.T) {
+	var s mocks.Store
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+	h := newLimitedServer(&s, Rule{Limit: 10, Period: time.Minute}, nil)
+
+	w := post(h, "10.0.0.1", "{ projects { id } }")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "10", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "9", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "6", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestExtensionThrottled(t *testing.T) {
+	var s mocks.Store
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+	h := newLimitedServer(&s, Rule{Limit: 1, Period: time.Minute}, nil)
+
+	post(h, "10.0.0.1", "{ projects { id } }")
+	w := post(h, "10.0.0.1", "{ projects { id } }")
+
+	assert.Contains(t, w.Body.String(), `"code":"RATE_LIMITED"`)
+	assert.Contains(t, w.Body.String(), `"retryAfter":60`)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	s.AssertNumberOfCalls(t, "GetUserProjects", 1)
+
+	// Other clients aren't affected
+	w = post(h, "10.0.0.2", "{ projects { id } }")
+	assert.NotContains(t, w.Body.String(), "RATE_LIMITED")
+}
+
+func TestExtensionFieldRule(t *testing.T) {
+	var s mocks.Store
+	s.On("GetUserProjects", mock.Anything, "0").Return([]model.Project{}, nil)
+	s.On("GetCategory", mock.Anything, mock.Anything).Return(model.Category{UserID: "0"}, nil)
+	s.On("CreateProject", mock.Anything, mock.Anything).Return(nil)
+	h := newLimitedServer(&s, Rule{Limit: 10, Period: time.Minute}, map[string]Rule{
+		"createProject": {Limit: 1, Period: time.Minute},
+	})
+	mutation := `mutation { createProject(input: {name: "glow", categoryID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}) { id } }`
+
+	w := post(h, "10.0.0.1", mutation)
+	assert.NotContains(t, w.Body.String(), "RATE_LIMITED")
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = post(h, "10.0.0.1", mutation)
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+
+	// Other operations are only limited by the default rule, which the rejected mutation didn't take from
+	w = post(h, "10.0.0.1", "{ projects { id } }")
+	assert.NotContains(t, w.Body.String(), "RATE_LIMITED")
+	assert.Equal(t, "8", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestBearerUser(t *testing.T) {
+	var s mocks.Store
+	s.On("GetSessionUser", mock.Anything, "t0k3n").Return("42", nil)
+	s.On("GetSessionUser", mock.Anything, "expired").Return("", nil)
+	user := BearerUser(s.GetSessionUser)
+
+	req := httptest.NewRequest("POST", "/query", nil)
+	assert.Equal(t, "", user(req))
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Equal(t, "", user(req))
+	req.Header.Set("Authorization", "Bearer t0k3n")
+	assert.Equal(t, "42", user(req))
+	req.Header.Set("Authorization", "Bearer expired")
+	assert.Equal(t, "", user(req))
+	s.AssertExpectations(t)
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/query", nil)
+	req.RemoteAddr = "192.168.1.10:54321"
+	// Sent by the client with a made up address, and through two proxies
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+
+	assert.Equal(t, "192.168.1.10", clientIP(req, 0))
+	assert.Equal(t, "10.0.0.1", clientIP(req, 1))
+	assert.Equal(t, "203.0.113.7", clientIP(req, 2))
+	assert.Equal(t, "198.51.100.1", clientIP(req, 5))
+}
@@ -0,0 +1,61 @@
+// Package ratelimit throttles the GraphQL operations sent by each client using token buckets
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule allows Limit requests every Period.
+// Its bucket holds up to Limit tokens and it's refilled at a constant rate.
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token is available, only set when not allowed
+}
+
+// Limiter keeps the buckets of every client
+type Limiter interface {
+	// Take takes a token from the bucket identified by key, created from rule if it doesn't exist
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+	// Refund returns a token taken from the bucket identified by key, like when another bucket rejected the operation
+	Refund(ctx context.Context, key string, rule Rule) error
+}
+
+// refill returns the tokens in a bucket after elapsed time since it had tokens
+func refill(rule Rule, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens += float64(rule.Limit) * float64(elapsed) / float64(rule.Period)
+	return math.Min(tokens, float64(rule.Limit))
+}
+
+// result describes a bucket left with tokens after a token was taken, if allowed
+func result(rule Rule, allowed bool, tokens float64) Result {
+	perToken := float64(rule.Period) / float64(rule.Limit)
+	r := Result{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     milliseconds((float64(rule.Limit) - tokens) * perToken),
+	}
+	if !allowed {
+		r.RetryAfter = milliseconds((1 - tokens) * perToken)
+	}
+	return r
+}
+
+// milliseconds rounds ns nanoseconds to milliseconds, dropping floating point errors
+func milliseconds(ns float64) time.Duration {
+	return time.Duration(math.Round(ns/float64(time.Millisecond))) * time.Millisecond
+}
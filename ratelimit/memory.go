@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// sweepInterval is how often buckets that are full again are removed
+const sweepInterval = time.Minute
+
+// NewMemoryLimiter creates a Limiter that keeps the buckets in memory.
+// Limits aren't shared by several instances of the server.
+func NewMemoryLimiter() Limiter {
+	return newMemoryLimiter(time.Now)
+}
+
+func newMemoryLimiter(now func() time.Time) *memoryLimiter {
+	return &memoryLimiter{buckets: map[string]*bucket{}, now: now, lastSweep: now()}
+}
+
+func (l *memoryLimiter) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Limit), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = refill(rule, b.tokens, now.Sub(b.last))
+	b.last = now
+	b.period = rule.Period
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return result(rule, allowed, b.tokens), nil
+}
+
+func (l *memoryLimiter) Refund(ctx context.Context, key string, rule Rule) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Swept buckets are full already
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(b.tokens+1, float64(rule.Limit))
+	}
+	return nil
+}
+
+// sweep removes the buckets that haven't been used for a whole period, they'd be full anyway
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= b.period {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	c := &clock{now: time.Unix(1600000000, 0)}
+	l := newMemoryLimiter(c.Now)
+	rule := Rule{Limit: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	res, _ := l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}, res)
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute}, res)
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.Equal(t, Result{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Minute, RetryAfter: 30 * time.Second}, res)
+
+	// Other clients have their own bucket
+	res, _ = l.Take(ctx, "ip:127.0.0.2", rule)
+	assert.True(t, res.Allowed)
+
+	// A token is added every 30 seconds
+	c.now = c.now.Add(20 * time.Second)
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 10*time.Second, res.RetryAfter)
+	c.now = c.now.Add(10 * time.Second)
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryLimiterRefund(t *testing.T) {
+	c := &clock{now: time.Unix(1600000000, 0)}
+	l := newMemoryLimiter(c.Now)
+	rule := Rule{Limit: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	l.Take(ctx, "ip:127.0.0.1", rule)
+	l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	res, _ := l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.True(t, res.Allowed)
+
+	// Buckets are never filled over their limit
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.Equal(t, 1, res.Remaining)
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.2", rule))
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	c := &clock{now: time.Unix(1600000000, 0)}
+	l := newMemoryLimiter(c.Now)
+	rule := Rule{Limit: 2, Period: time.Minute}
+
+	l.Take(context.Background(), "ip:127.0.0.1", rule)
+	c.now = c.now.Add(2 * time.Minute)
+	l.Take(context.Background(), "ip:127.0.0.2", rule)
+
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "ip:127.0.0.2")
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// takeScript takes a token from the bucket stored in the hash KEYS[1], refilling it first.
+// ARGV holds the limit, the period and the current time, in milliseconds.
+// It replies whether the token was taken and the tokens left, as a string to keep the decimals.
+var takeScript = redis.NewScript(1, `
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(bucket[1]) or limit
+local last = tonumber(bucket[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - last) * limit / period)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", now)
+redis.call("PEXPIRE", KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+// refundScript returns a token to the bucket stored in the hash KEYS[1], if it still exists.
+// ARGV holds the limit.
+var refundScript = redis.NewScript(1, `
+local tokens = tonumber(redis.call("HGET", KEYS[1], "tokens"))
+if tokens then
+	redis.call("HSET", KEYS[1], "tokens", tostring(math.min(tonumber(ARGV[1]), tokens + 1)))
+end
+return 0
+`)
+
+type redisLimiter struct {
+	pool *redis.Pool
+}
+
+// NewRedisLimiter creates a Limiter that keeps the buckets in Redis,
+// so that limits are shared by every instance of the server.
+// Buckets are stored in ratelimit:<key> hashes that expire once they'd be full again.
+func NewRedisLimiter(pool *redis.Pool) Limiter {
+	return redisLimiter{pool: pool}
+}
+
+func (l redisLimiter) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	reply, err := redis.Values(takeScript.Do(conn, "ratelimit:"+key, rule.Limit, rule.Period.Milliseconds(), now))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var allowed int
+	var left string
+	if _, err := redis.Scan(reply, &allowed, &left); err != nil {
+		return Result{}, err
+	}
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid tokens %q in bucket %s", left, key)
+	}
+
+	return result(rule, allowed == 1, tokens), nil
+}
+
+func (l redisLimiter) Refund(ctx context.Context, key string, rule Rule) error {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = refundScript.Do(conn, "ratelimit:"+key, rule.Limit)
+	return err
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/smeruelo/glow/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	pool := storage.NewPool(mr.Addr(), "", 0, 1)
+	defer pool.Close()
+	l := NewRedisLimiter(pool)
+	rule := Rule{Limit: 2, Period: time.Hour}
+	ctx := context.Background()
+
+	res, err := l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Remaining)
+	l.Take(ctx, "ip:127.0.0.1", rule)
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.False(t, res.Allowed)
+
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.True(t, res.Allowed)
+
+	// Buckets are never filled over their limit, nor created
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.1", rule))
+	res, _ = l.Take(ctx, "ip:127.0.0.1", rule)
+	assert.Equal(t, 1, res.Remaining)
+	assert.NoError(t, l.Refund(ctx, "ip:127.0.0.2", rule))
+	assert.False(t, mr.Exists("ratelimit:ip:127.0.0.2"))
+}
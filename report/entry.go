@@ -0,0 +1,33 @@
+package report
+
+import (
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+)
+
+// Duration returns the seconds of an achievement that fall between from and to, Unix timestamps where 0 means
+// unbounded. Achievements whose timer is still running, with no end, last until now.
+func Duration(start, end, from, to, now int) int {
+	if end == 0 {
+		end = now
+	}
+	if from != 0 && start < from {
+		start = from
+	}
+	if to != 0 && end > to {
+		end = to
+	}
+	if end < start {
+		return 0
+	}
+	return end - start
+}
+
+// Entry returns the time of achievement a that falls between from and to, to be rounded
+func Entry(a model.Achievement, from, to, now int) rounding.Entry {
+	start := a.Start
+	if from != 0 && start < from {
+		start = from
+	}
+	return rounding.Entry{Start: start, Seconds: Duration(a.Start, a.End, from, to, now)}
+}
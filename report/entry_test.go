@@ -0,0 +1,29 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name                 string
+		start, end, from, to int
+		expected             int
+	}{
+		{"unbounded", 100, 400, 0, 0, 300},
+		{"inside", 100, 400, 50, 500, 300},
+		{"starts before", 100, 400, 200, 0, 200},
+		{"ends after", 100, 400, 0, 300, 200},
+		{"outside", 100, 400, 500, 600, 0},
+		{"running", 100, 0, 0, 0, 900},
+		{"running until to", 100, 0, 0, 700, 600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Duration(tt.start, tt.end, tt.from, tt.to, 1000))
+		})
+	}
+}
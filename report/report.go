@@ -0,0 +1,308 @@
+// Package report adds up the time of a user's achievements in nested groups, like per project and then per
+// week, so that clients can show summaries without downloading every achievement
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/smeruelo/glow/billing"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Layouts of the keys of the groups of days and months. Weeks are ISO weeks, like 2020-W36.
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+// Request describes a report
+type Request struct {
+	UserID string
+	// From and To are Unix timestamps. The time of achievements outside of the period is left out.
+	From, To int
+	// GroupBy are the groupings of each level of groups, outermost first
+	GroupBy []model.ReportGrouping
+	Filter  model.ReportFilter
+	// Now is the Unix timestamp until which running timers count
+	Now int
+}
+
+// item is the time of an achievement within the period of a report
+type item struct {
+	project model.Project
+	entry   rounding.Entry
+	billed  bool
+	tags    []model.Tag
+}
+
+// node is a group of a report being built. Time is kept per project, to be rounded by each project's rule.
+type node struct {
+	group    model.ReportGroup
+	entries  map[string][]rounding.Entry
+	billed   map[string][]rounding.Entry
+	children map[string]*node
+}
+
+func newNode(grouping model.ReportGrouping, key, name string) *node {
+	return &node{
+		group:    model.ReportGroup{Grouping: grouping, Key: key, Name: name},
+		entries:  map[string][]rounding.Entry{},
+		billed:   map[string][]rounding.Entry{},
+		children: map[string]*node{},
+	}
+}
+
+// Build returns the report of the achievements of req.UserID that pass req.Filter.
+// Achievements are grouped by the time they start within the period, in UTC. Achievements with several tags
+// are in the group of each of their tags, so the groups of tags may add up to more than their parent, and
+// achievements without tags are in a group with an empty key. Filtering by tags keeps the achievements that
+// have all of them.
+func Build(ctx context.Context, s storage.Store, req Request) (*model.Report, error) {
+	if req.From >= req.To {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	grouped := map[model.ReportGrouping]bool{}
+	for _, g := range req.GroupBy {
+		if !g.IsValid() {
+			return nil, fmt.Errorf("invalid grouping %q", g)
+		}
+		if grouped[g] {
+			return nil, fmt.Errorf("can't group by %s twice", g)
+		}
+		grouped[g] = true
+	}
+
+	items, categories, err := load(ctx, s, req, grouped[model.ReportGroupingTag])
+	if err != nil {
+		return nil, err
+	}
+
+	root := newNode("", "", "")
+	projects := map[string]model.Project{}
+	for _, it := range items {
+		root.add(it, req.GroupBy, categories)
+		projects[it.project.ID] = it.project
+	}
+
+	terms := billing.NewTerms(s, req.UserID)
+	if err := root.sum(ctx, terms, projects); err != nil {
+		return nil, err
+	}
+	return &model.Report{
+		From:           req.From,
+		To:             req.To,
+		Seconds:        root.group.Seconds,
+		RoundedSeconds: root.group.RoundedSeconds,
+		Entries:        root.group.Entries,
+		Earnings:       root.group.Earnings,
+		Groups:         root.group.Groups,
+	}, nil
+}
+
+// load returns the items of the report, and the names of the user's categories
+func load(ctx context.Context, s storage.Store, req Request, withTags bool) ([]item, map[string]string, error) {
+	cs, err := s.GetUserCategories(ctx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	categories := make(map[string]string, len(cs))
+	for _, c := range cs {
+		categories[c.ID] = c.Name
+	}
+
+	ps, err := s.GetUserProjects(ctx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	archived, err := s.GetArchivedProjects(ctx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	projects := map[string]model.Project{}
+	for _, p := range append(ps, archived...) {
+		projects[p.ID] = p
+	}
+	keep, err := filterProjects(projects, req.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tagged map[string]bool
+	if len(req.Filter.TagIDs) > 0 {
+		as, err := s.GetTaggedAchievements(ctx, req.Filter.TagIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		tagged = make(map[string]bool, len(as))
+		for _, a := range as {
+			tagged[a.ID] = true
+		}
+	}
+
+	as, err := s.GetUserAchievements(ctx, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []item
+	for _, a := range as {
+		p, ok := projects[a.ProjectID]
+		if !ok || !keep(p) || (tagged != nil && !tagged[a.ID]) {
+			continue
+		}
+		billed := billing.Billable(a, p)
+		if req.Filter.Billable != nil && billed != *req.Filter.Billable {
+			continue
+		}
+		e := Entry(a, req.From, req.To, req.Now)
+		if e.Seconds == 0 {
+			continue
+		}
+
+		it := item{project: p, entry: e, billed: billed}
+		if withTags {
+			if it.tags, err = s.GetAchievementTags(ctx, a.ID); err != nil {
+				return nil, nil, err
+			}
+		}
+		items = append(items, it)
+	}
+	return items, categories, nil
+}
+
+// filterProjects returns whether the achievements of a project pass the filter. Filtering by project
+// includes its subprojects.
+func filterProjects(projects map[string]model.Project, f model.ReportFilter) (func(p model.Project) bool, error) {
+	var kept map[string]bool
+	if len(f.ProjectIDs) > 0 {
+		kept = map[string]bool{}
+		for _, pID := range f.ProjectIDs {
+			if _, ok := projects[pID]; !ok {
+				return nil, fmt.Errorf("project %s does not exist", pID)
+			}
+			kept[pID] = true
+		}
+		// Until no more descendants are found
+		for found := true; found; {
+			found = false
+			for _, p := range projects {
+				if p.ParentID != nil && kept[*p.ParentID] && !kept[p.ID] {
+					kept[p.ID], found = true, true
+				}
+			}
+		}
+	}
+	categories := map[string]bool{}
+	for _, cID := range f.CategoryIDs {
+		categories[cID] = true
+	}
+
+	return func(p model.Project) bool {
+		if kept != nil && !kept[p.ID] {
+			return false
+		}
+		return len(categories) == 0 || categories[p.CategoryID]
+	}, nil
+}
+
+// add adds it to n, and to the groups of n's descendants it belongs to
+func (n *node) add(it item, groupBy []model.ReportGrouping, categories map[string]string) {
+	n.entries[it.project.ID] = append(n.entries[it.project.ID], it.entry)
+	if it.billed {
+		n.billed[it.project.ID] = append(n.billed[it.project.ID], it.entry)
+	}
+	n.group.Entries++
+	if len(groupBy) == 0 {
+		return
+	}
+
+	for _, k := range keys(it, groupBy[0], categories) {
+		child, ok := n.children[k.key]
+		if !ok {
+			child = newNode(groupBy[0], k.key, k.name)
+			n.children[k.key] = child
+		}
+		child.add(it, groupBy[1:], categories)
+	}
+}
+
+type key struct {
+	key, name string
+}
+
+// keys returns the groups of grouping that it belongs to
+func keys(it item, grouping model.ReportGrouping, categories map[string]string) []key {
+	start := time.Unix(int64(it.entry.Start), 0).UTC()
+	switch grouping {
+	case model.ReportGroupingProject:
+		return []key{{it.project.ID, it.project.Name}}
+	case model.ReportGroupingCategory:
+		return []key{{it.project.CategoryID, categories[it.project.CategoryID]}}
+	case model.ReportGroupingTag:
+		if len(it.tags) == 0 {
+			return []key{{}}
+		}
+		ks := make([]key, len(it.tags))
+		for i, t := range it.tags {
+			ks[i] = key{t.ID, t.Name}
+		}
+		return ks
+	case model.ReportGroupingDay:
+		day := start.Format(dayLayout)
+		return []key{{day, day}}
+	case model.ReportGroupingWeek:
+		year, week := start.ISOWeek()
+		w := fmt.Sprintf("%d-W%02d", year, week)
+		return []key{{w, w}}
+	default:
+		month := start.Format(monthLayout)
+		return []key{{month, month}}
+	}
+}
+
+// sum adds up the time and earnings of n and its descendants, and sorts their groups by name,
+// which is chronological for groups of time
+func (n *node) sum(ctx context.Context, terms *billing.Terms, projects map[string]model.Project) error {
+	ledger := billing.Ledger{}
+	for pID, es := range n.entries {
+		p := projects[pID]
+		rule, err := terms.RoundingOf(ctx, p)
+		if err != nil {
+			return err
+		}
+		for _, e := range es {
+			n.group.Seconds += e.Seconds
+		}
+		n.group.RoundedSeconds += rounding.Total(es, rule)
+
+		if len(n.billed[pID]) == 0 {
+			continue
+		}
+		rate, err := terms.RateOf(ctx, p)
+		if err != nil {
+			return err
+		}
+		ledger.Add(rate, rounding.Total(n.billed[pID], rule))
+	}
+	n.group.Earnings = ledger.Earnings()
+
+	n.group.Groups = make([]*model.ReportGroup, 0, len(n.children))
+	for _, child := range n.children {
+		if err := child.sum(ctx, terms, projects); err != nil {
+			return err
+		}
+		n.group.Groups = append(n.group.Groups, &child.group)
+	}
+	sort.Slice(n.group.Groups, func(i, j int) bool {
+		gi, gj := n.group.Groups[i], n.group.Groups[j]
+		if gi.Name != gj.Name {
+			return gi.Name < gj.Name
+		}
+		return gi.Key < gj.Key
+	})
+	return nil
+}
@@ -0,0 +1,193 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/rounding"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const uID = "0"
+
+var (
+	from = 1598918400 // 2020-09-01
+	to   = from + 7*86400
+	now  = to + 1000
+
+	work    = model.Category{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", UserID: uID, Name: "Work"}
+	reading = model.Category{ID: "16fd2706-8baf-433b-82eb-8c7fada847da", UserID: uID, Name: "Reading"}
+
+	urgent  = model.Tag{ID: "6ba7b810-9dad-11d1-80b4-000000000001", UserID: uID, Name: "urgent"}
+	meeting = model.Tag{ID: "6ba7b810-9dad-11d1-80b4-000000000002", UserID: uID, Name: "meeting"}
+)
+
+// newStore mocks a store with the project Client X, billed at 60.00 EUR/h and rounded up to 15 minutes,
+// its subproject Website, and the archived project Book, which isn't billable
+func newStore() (s *mocks.Store, x, website, book model.Project) {
+	s = &mocks.Store{}
+	ctx := context.Background()
+	x = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Client X", CategoryID: work.ID,
+		Rate: &model.Money{Amount: 6000, Currency: "EUR"}, Billable: true,
+		Rounding: &model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry}}
+	website = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website",
+		CategoryID: work.ID, ParentID: &x.ID, Billable: true}
+	book = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: uID, Name: "Book",
+		CategoryID: reading.ID, Archived: true}
+
+	s.On("GetUserCategories", ctx, uID).Return([]model.Category{work, reading}, nil)
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{x, website}, nil)
+	s.On("GetArchivedProjects", ctx, uID).Return([]model.Project{book}, nil)
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: x.ID, Start: from + 3600, End: from + 3600 + 600},
+		{ID: "a2", ProjectID: website.ID, Start: from + 86400, End: from + 86400 + 3600},
+		{ID: "a3", ProjectID: website.ID, Start: from + 86400 + 7200, End: from + 86400 + 7200 + 60},
+		{ID: "a4", ProjectID: book.ID, Start: from + 2*86400, End: from + 2*86400 + 1800},
+		// Started before the period
+		{ID: "a5", ProjectID: x.ID, Start: from - 600, End: from + 600},
+		// After the period
+		{ID: "a6", ProjectID: x.ID, Start: to + 10, End: to + 100},
+		// Of a deleted project
+		{ID: "a7", ProjectID: "3b054f50-9d3d-4114-bfc4-000000000004", Start: from, End: from + 3600},
+		// Still running
+		{ID: "a8", ProjectID: book.ID, Start: to - 600},
+	}, nil)
+	s.On("GetProject", ctx, x.ID).Return(x, nil)
+	s.On("GetUserRounding", ctx, uID).Return((*model.Rounding)(nil), nil)
+	return s, x, website, book
+}
+
+func eur(amount int) []*model.Money {
+	return []*model.Money{{Amount: amount, Currency: "EUR"}}
+}
+
+func TestBuildPerProjectAndDay(t *testing.T) {
+	s, x, website, book := newStore()
+
+	actual, err := Build(context.Background(), s, Request{UserID: uID, From: from, To: to,
+		GroupBy: []model.ReportGrouping{model.ReportGroupingProject, model.ReportGroupingDay}, Now: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Report{
+		From: from, To: to, Seconds: 7260, RoundedSeconds: 8700, Entries: 6, Earnings: eur(10500),
+		Groups: []*model.ReportGroup{
+			{Grouping: model.ReportGroupingProject, Key: book.ID, Name: "Book", Seconds: 2400, RoundedSeconds: 2400,
+				Entries: 2, Earnings: []*model.Money{}, Groups: []*model.ReportGroup{
+					{Grouping: model.ReportGroupingDay, Key: "2020-09-03", Name: "2020-09-03", Seconds: 1800,
+						RoundedSeconds: 1800, Entries: 1, Earnings: []*model.Money{}, Groups: []*model.ReportGroup{}},
+					{Grouping: model.ReportGroupingDay, Key: "2020-09-07", Name: "2020-09-07", Seconds: 600,
+						RoundedSeconds: 600, Entries: 1, Earnings: []*model.Money{}, Groups: []*model.ReportGroup{}},
+				}},
+			// Each of its entries is rounded up to 15 minutes
+			{Grouping: model.ReportGroupingProject, Key: x.ID, Name: "Client X", Seconds: 1200, RoundedSeconds: 1800,
+				Entries: 2, Earnings: eur(3000), Groups: []*model.ReportGroup{
+					{Grouping: model.ReportGroupingDay, Key: "2020-09-01", Name: "2020-09-01", Seconds: 1200,
+						RoundedSeconds: 1800, Entries: 2, Earnings: eur(3000), Groups: []*model.ReportGroup{}},
+				}},
+			// Like its rate, its rounding rule is its parent's
+			{Grouping: model.ReportGroupingProject, Key: website.ID, Name: "Website", Seconds: 3660, RoundedSeconds: 4500,
+				Entries: 2, Earnings: eur(7500), Groups: []*model.ReportGroup{
+					{Grouping: model.ReportGroupingDay, Key: "2020-09-02", Name: "2020-09-02", Seconds: 3660,
+						RoundedSeconds: 4500, Entries: 2, Earnings: eur(7500), Groups: []*model.ReportGroup{}},
+				}},
+		},
+	}, actual)
+	s.AssertNotCalled(t, "GetUserRate", mock.Anything, mock.Anything)
+}
+
+func TestBuildFiltered(t *testing.T) {
+	s, x, _, _ := newStore()
+	billable := true
+
+	actual, err := Build(context.Background(), s, Request{UserID: uID, From: from, To: to,
+		GroupBy: []model.ReportGrouping{model.ReportGroupingCategory}, Now: now,
+		Filter: model.ReportFilter{ProjectIDs: []string{x.ID}, CategoryIDs: []string{work.ID}, Billable: &billable}})
+
+	// Client X along with Website
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.ReportGroup{
+		{Grouping: model.ReportGroupingCategory, Key: work.ID, Name: "Work", Seconds: 4860, RoundedSeconds: 6300,
+			Entries: 4, Earnings: eur(10500), Groups: []*model.ReportGroup{}},
+	}, actual.Groups)
+	assert.Equal(t, 4860, actual.Seconds)
+}
+
+func TestBuildFilteredByMissingProject(t *testing.T) {
+	s, _, _, _ := newStore()
+	pID := "3b054f50-9d3d-4114-bfc4-000000000004"
+
+	_, err := Build(context.Background(), s, Request{UserID: uID, From: from, To: to, Now: now,
+		Filter: model.ReportFilter{ProjectIDs: []string{pID}}})
+
+	assert.EqualError(t, err, "project "+pID+" does not exist")
+}
+
+func TestBuildPerTag(t *testing.T) {
+	s, _, _, _ := newStore()
+	ctx := context.Background()
+	s.On("GetAchievementTags", ctx, "a1").Return([]model.Tag{urgent, meeting}, nil)
+	s.On("GetAchievementTags", ctx, "a2").Return([]model.Tag{urgent}, nil)
+	s.On("GetAchievementTags", ctx, mock.Anything).Return([]model.Tag{}, nil)
+
+	actual, err := Build(ctx, s, Request{UserID: uID, From: from, To: to,
+		GroupBy: []model.ReportGrouping{model.ReportGroupingTag}, Now: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, actual.Entries)
+	if assert.Len(t, actual.Groups, 3) {
+		// Untagged, then by name
+		assert.Equal(t, "", actual.Groups[0].Key)
+		assert.Equal(t, 4, actual.Groups[0].Entries)
+		assert.Equal(t, "meeting", actual.Groups[1].Name)
+		assert.Equal(t, 1, actual.Groups[1].Entries)
+		assert.Equal(t, "urgent", actual.Groups[2].Name)
+		assert.Equal(t, 2, actual.Groups[2].Entries)
+		assert.Equal(t, 4200, actual.Groups[2].Seconds)
+	}
+}
+
+func TestBuildFilteredByTags(t *testing.T) {
+	s, x, website, _ := newStore()
+	ctx := context.Background()
+	s.On("GetTaggedAchievements", ctx, []string{urgent.ID}).Return([]model.Achievement{
+		{ID: "a1", ProjectID: x.ID}, {ID: "a2", ProjectID: website.ID},
+	}, nil)
+
+	actual, err := Build(ctx, s, Request{UserID: uID, From: from, To: to, Now: now,
+		Filter: model.ReportFilter{TagIDs: []string{urgent.ID}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, actual.Entries)
+	assert.Equal(t, 4200, actual.Seconds)
+	assert.Empty(t, actual.Groups)
+	s.AssertNotCalled(t, "GetAchievementTags", ctx, mock.Anything)
+}
+
+func TestBuildInvalid(t *testing.T) {
+	s := &mocks.Store{}
+	ctx := context.Background()
+
+	_, err := Build(ctx, s, Request{UserID: uID, From: to, To: from})
+	assert.EqualError(t, err, "from must be before to")
+
+	_, err = Build(ctx, s, Request{UserID: uID, From: from, To: to,
+		GroupBy: []model.ReportGrouping{model.ReportGroupingDay, model.ReportGroupingProject, model.ReportGroupingDay}})
+	assert.EqualError(t, err, "can't group by DAY twice")
+
+	_, err = Build(ctx, s, Request{UserID: uID, From: from, To: to, GroupBy: []model.ReportGrouping{"YEAR"}})
+	assert.EqualError(t, err, `invalid grouping "YEAR"`)
+}
+
+func TestKeysOfTime(t *testing.T) {
+	start := int(time.Date(2021, 1, 1, 23, 30, 0, 0, time.UTC).Unix())
+	it := item{entry: rounding.Entry{Start: start, Seconds: 3600}}
+
+	assert.Equal(t, []key{{"2021-01-01", "2021-01-01"}}, keys(it, model.ReportGroupingDay, nil))
+	// 2021-01-01 is a Friday, in the last ISO week of 2020
+	assert.Equal(t, []key{{"2020-W53", "2020-W53"}}, keys(it, model.ReportGroupingWeek, nil))
+	assert.Equal(t, []key{{"2021-01", "2021-01"}}, keys(it, model.ReportGroupingMonth, nil))
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+)
+
+// maxBuckets is the maximum number of buckets of a time series, more than the hours of a year
+const maxBuckets = 10000
+
+// Sizes of the buckets, all of them UTC
+const (
+	hour = 3600
+	day  = 24 * hour
+	week = 7 * day
+)
+
+// monday is the Unix timestamp of the first Monday since the epoch, where weeks start from
+const monday = 4 * day
+
+// SeriesRequest describes a time series
+type SeriesRequest struct {
+	UserID string
+	// From and To are Unix timestamps. The first bucket is the one of From, and the last one the one before To.
+	From, To int
+	Bucket   model.TimeBucket
+	// GroupBy splits the series per project, category or tag. There's a single series if nil.
+	GroupBy *model.ReportGrouping
+	// Now is the Unix timestamp until which running timers count
+	Now int
+}
+
+// bucketSize returns the size in seconds of bucket, and the timestamp its buckets are aligned to
+func bucketSize(bucket model.TimeBucket) (size, origin int) {
+	switch bucket {
+	case model.TimeBucketHour:
+		return hour, 0
+	case model.TimeBucketDay:
+		return day, 0
+	default:
+		return week, monday
+	}
+}
+
+// floor returns the start of the bucket of t
+func floor(t, size, origin int) int {
+	offset := (t - origin) % size
+	if offset < 0 {
+		offset += size
+	}
+	return t - offset
+}
+
+// split calls f with the start of each bucket that the time between start and end overlaps,
+// and the seconds of the overlap
+func split(start, end, size, origin int, f func(bucket, seconds int)) {
+	for b := floor(start, size, origin); b < end; b += size {
+		from, to := b, b+size
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		f(b, to-from)
+	}
+}
+
+// Series returns the time spent in each bucket of the requested period. The time of the achievements
+// that cross the boundaries of buckets is split among them. Buckets without time are zero.
+func Series(ctx context.Context, s storage.Store, req SeriesRequest) (*model.TimeSeries, error) {
+	if req.From >= req.To {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if !req.Bucket.IsValid() {
+		return nil, fmt.Errorf("invalid bucket %q", req.Bucket)
+	}
+	var withTags bool
+	if req.GroupBy != nil {
+		switch *req.GroupBy {
+		case model.ReportGroupingProject, model.ReportGroupingCategory:
+		case model.ReportGroupingTag:
+			withTags = true
+		default:
+			return nil, fmt.Errorf("series can't be grouped by %s", *req.GroupBy)
+		}
+	}
+
+	size, origin := bucketSize(req.Bucket)
+	first := floor(req.From, size, origin)
+	n := (req.To - first + size - 1) / size
+	if n > maxBuckets {
+		return nil, fmt.Errorf("%d buckets are too many, the maximum is %d", n, maxBuckets)
+	}
+	ts := &model.TimeSeries{Bucket: req.Bucket, Starts: make([]int, n), Series: []*model.Series{}}
+	for i := range ts.Starts {
+		ts.Starts[i] = first + i*size
+	}
+
+	items, categories, err := load(ctx, s, Request{UserID: req.UserID, From: req.From, To: req.To, Now: req.Now}, withTags)
+	if err != nil {
+		return nil, err
+	}
+	series := map[string]*model.Series{}
+	if req.GroupBy == nil {
+		series[""] = &model.Series{Seconds: make([]int, n)}
+	}
+	for _, it := range items {
+		ks := []key{{}}
+		if req.GroupBy != nil {
+			ks = keys(it, *req.GroupBy, categories)
+		}
+		for _, k := range ks {
+			sr, ok := series[k.key]
+			if !ok {
+				sr = &model.Series{Key: k.key, Name: k.name, Seconds: make([]int, n)}
+				series[k.key] = sr
+			}
+			split(it.entry.Start, it.entry.Start+it.entry.Seconds, size, origin, func(bucket, seconds int) {
+				sr.Seconds[(bucket-first)/size] += seconds
+			})
+		}
+	}
+
+	for _, sr := range series {
+		ts.Series = append(ts.Series, sr)
+	}
+	sort.Slice(ts.Series, func(i, j int) bool {
+		if ts.Series[i].Name != ts.Series[j].Name {
+			return ts.Series[i].Name < ts.Series[j].Name
+		}
+		return ts.Series[i].Key < ts.Series[j].Key
+	})
+	return ts, nil
+}
+
+// Heatmap returns the minutes spent on each UTC day of a year, rounded to the nearest minute
+func Heatmap(ctx context.Context, s storage.Store, uID string, year, now int) ([]*model.HeatmapDay, error) {
+	if year < 1970 || year > 9999 {
+		return nil, fmt.Errorf("invalid year %d", year)
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	ts, err := Series(ctx, s, SeriesRequest{UserID: uID, From: int(from.Unix()), To: int(to.Unix()),
+		Bucket: model.TimeBucketDay, Now: now})
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*model.HeatmapDay, len(ts.Starts))
+	for i, start := range ts.Starts {
+		days[i] = &model.HeatmapDay{
+			Date:    time.Unix(int64(start), 0).UTC().Format(dayLayout),
+			Minutes: (ts.Series[0].Seconds[i] + 30) / 60,
+		}
+	}
+	return days, nil
+}
+
+// HoursOfWeek returns the time spent within a period in each hour of each day of the week, in UTC.
+// Weekdays are numbered from 1, Monday, to 7, Sunday, and the hours of Monday come first.
+func HoursOfWeek(ctx context.Context, s storage.Store, uID string, from, to, now int) ([]*model.HourOfWeek, error) {
+	if from >= to {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	items, _, err := load(ctx, s, Request{UserID: uID, From: from, To: to, Now: now}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var seconds [7 * 24]int
+	for _, it := range items {
+		split(it.entry.Start, it.entry.Start+it.entry.Seconds, hour, 0, func(bucket, s int) {
+			seconds[(bucket-floor(bucket, week, monday))/hour] += s
+		})
+	}
+
+	hours := make([]*model.HourOfWeek, len(seconds))
+	for i := range seconds {
+		hours[i] = &model.HourOfWeek{Weekday: i/24 + 1, Hour: i % 24, Seconds: seconds[i]}
+	}
+	return hours, nil
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	type overlap struct{ bucket, seconds int }
+	var actual []overlap
+	split(3000, 7000, hour, 0, func(bucket, seconds int) { actual = append(actual, overlap{bucket, seconds}) })
+	assert.Equal(t, []overlap{{0, 600}, {3600, 3400}}, actual)
+
+	actual = nil
+	split(7200, 10800, hour, 0, func(bucket, seconds int) { actual = append(actual, overlap{bucket, seconds}) })
+	assert.Equal(t, []overlap{{7200, 3600}}, actual)
+}
+
+func TestFloorWeek(t *testing.T) {
+	// Tuesday 2020-09-01 is in the week of Monday 2020-08-31
+	assert.Equal(t, from-day, floor(from, week, monday))
+	assert.Equal(t, from-day, floor(from-day, week, monday))
+}
+
+func TestSeriesPerProject(t *testing.T) {
+	s, x, website, book := newStore()
+	project := model.ReportGroupingProject
+
+	actual, err := Series(context.Background(), s, SeriesRequest{UserID: uID, From: from, To: to,
+		Bucket: model.TimeBucketDay, GroupBy: &project, Now: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.TimeSeries{
+		Bucket: model.TimeBucketDay,
+		Starts: []int{from, from + day, from + 2*day, from + 3*day, from + 4*day, from + 5*day, from + 6*day},
+		Series: []*model.Series{
+			{Key: book.ID, Name: "Book", Seconds: []int{0, 0, 1800, 0, 0, 0, 600}},
+			{Key: x.ID, Name: "Client X", Seconds: []int{1200, 0, 0, 0, 0, 0, 0}},
+			{Key: website.ID, Name: "Website", Seconds: []int{0, 3660, 0, 0, 0, 0, 0}},
+		},
+	}, actual)
+}
+
+func TestSeriesSplitsEntries(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "glow", CategoryID: work.ID}
+	s.On("GetUserCategories", ctx, uID).Return([]model.Category{work}, nil)
+	s.On("GetUserProjects", ctx, uID).Return([]model.Project{p}, nil)
+	s.On("GetArchivedProjects", ctx, uID).Return([]model.Project{}, nil)
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{
+		{ID: "a1", ProjectID: p.ID, Start: from + 3000, End: from + 7000},
+	}, nil)
+
+	actual, err := Series(ctx, &s, SeriesRequest{UserID: uID, From: from, To: from + 3*hour, Bucket: model.TimeBucketHour})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{from, from + hour, from + 2*hour}, actual.Starts)
+	assert.Equal(t, []*model.Series{{Seconds: []int{600, 3400, 0}}}, actual.Series)
+}
+
+func TestSeriesPerWeek(t *testing.T) {
+	s, _, _, _ := newStore()
+
+	actual, err := Series(context.Background(), s, SeriesRequest{UserID: uID, From: from, To: to,
+		Bucket: model.TimeBucketWeek, Now: now})
+
+	// Weeks start on Mondays, and the period ends on Tuesday 2020-09-08
+	assert.NoError(t, err)
+	assert.Equal(t, []int{from - day, from - day + week}, actual.Starts)
+	assert.Equal(t, []*model.Series{{Seconds: []int{6660, 600}}}, actual.Series)
+}
+
+func TestSeriesInvalid(t *testing.T) {
+	var s mocks.Store
+	ctx := context.Background()
+	day := model.ReportGroupingDay
+
+	_, err := Series(ctx, &s, SeriesRequest{UserID: uID, From: from, To: to, Bucket: model.TimeBucketDay, GroupBy: &day})
+	assert.EqualError(t, err, "series can't be grouped by DAY")
+
+	_, err = Series(ctx, &s, SeriesRequest{UserID: uID, From: from, To: from + 2*366*86400, Bucket: model.TimeBucketHour})
+	assert.EqualError(t, err, "17568 buckets are too many, the maximum is 10000")
+
+	_, err = Series(ctx, &s, SeriesRequest{UserID: uID, From: from, To: to, Bucket: "MONTH"})
+	assert.EqualError(t, err, `invalid bucket "MONTH"`)
+}
+
+func TestHeatmap(t *testing.T) {
+	s, _, _, _ := newStore()
+
+	actual, err := Heatmap(context.Background(), s, uID, 2020, now)
+
+	assert.NoError(t, err)
+	assert.Len(t, actual, 366)
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-01-01", Minutes: 0}, actual[0])
+	// 2020-08-31 has the 10 minutes of the achievement that started the day before
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-08-31", Minutes: 10}, actual[243])
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-09-01", Minutes: 20}, actual[244])
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-09-02", Minutes: 61}, actual[245])
+	// The running timer is split between 2020-09-07 and 2020-09-08
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-09-07", Minutes: 10}, actual[250])
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-09-08", Minutes: 18}, actual[251])
+	assert.Equal(t, &model.HeatmapDay{Date: "2020-12-31", Minutes: 0}, actual[365])
+}
+
+func TestHoursOfWeek(t *testing.T) {
+	s, _, _, _ := newStore()
+
+	actual, err := HoursOfWeek(context.Background(), s, uID, from, to, now)
+
+	assert.NoError(t, err)
+	assert.Len(t, actual, 7*24)
+	assert.Equal(t, &model.HourOfWeek{Weekday: 1, Hour: 0, Seconds: 0}, actual[0])
+	// The running timer of Monday 2020-09-07 at 23:50, until the end of the period
+	assert.Equal(t, &model.HourOfWeek{Weekday: 1, Hour: 23, Seconds: 600}, actual[23])
+	assert.Equal(t, &model.HourOfWeek{Weekday: 2, Hour: 0, Seconds: 600}, actual[24])
+	assert.Equal(t, &model.HourOfWeek{Weekday: 2, Hour: 1, Seconds: 600}, actual[25])
+	assert.Equal(t, &model.HourOfWeek{Weekday: 3, Hour: 0, Seconds: 3600}, actual[48])
+	assert.Equal(t, &model.HourOfWeek{Weekday: 3, Hour: 2, Seconds: 60}, actual[50])
+	assert.Equal(t, &model.HourOfWeek{Weekday: 7, Hour: 23, Seconds: 0}, actual[167])
+}
@@ -0,0 +1,78 @@
+// Package rounding rounds the time reported to clients to increments like 6 or 15 minutes.
+// Stored achievements are never changed, rounding applies to the durations computed from them.
+package rounding
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// maxIncrement is the largest increment in minutes, a whole day
+const maxIncrement = 24 * 60
+
+// Validate checks a rounding rule
+func Validate(r model.Rounding) error {
+	if r.Increment < 1 || r.Increment > maxIncrement {
+		return fmt.Errorf("rounding increment must be between 1 and %d minutes", maxIncrement)
+	}
+	if !r.Direction.IsValid() {
+		return fmt.Errorf("invalid rounding direction %q", r.Direction)
+	}
+	if !r.Scope.IsValid() {
+		return fmt.Errorf("invalid rounding scope %q", r.Scope)
+	}
+	return nil
+}
+
+// FromData returns the rounding rule set by d, nil if there's none
+func FromData(d *model.RoundingData) *model.Rounding {
+	if d == nil {
+		return nil
+	}
+	return &model.Rounding{Increment: d.Increment, Direction: d.Direction, Scope: d.Scope}
+}
+
+// Seconds returns seconds rounded to the increment of r, in its direction. Nothing is rounded with a nil r.
+func Seconds(seconds int, r *model.Rounding) int {
+	if r == nil || r.Increment <= 0 {
+		return seconds
+	}
+	increment := r.Increment * 60
+	switch r.Direction {
+	case model.RoundingDirectionUp:
+		return (seconds + increment - 1) / increment * increment
+	case model.RoundingDirectionDown:
+		return seconds / increment * increment
+	default:
+		return (seconds + increment/2) / increment * increment
+	}
+}
+
+// Entry is some time spent on a project, starting at Start, a Unix timestamp
+type Entry struct {
+	Start   int
+	Seconds int
+}
+
+// Total returns the time of the entries of a project rounded by r, either each of them or the total
+// of each day, in UTC
+func Total(entries []Entry, r *model.Rounding) int {
+	total := 0
+	if r == nil || r.Scope != model.RoundingScopeDay {
+		for _, e := range entries {
+			total += Seconds(e.Seconds, r)
+		}
+		return total
+	}
+
+	days := map[string]int{}
+	for _, e := range entries {
+		days[time.Unix(int64(e.Start), 0).UTC().Format("2006-01-02")] += e.Seconds
+	}
+	for _, seconds := range days {
+		total += Seconds(seconds, r)
+	}
+	return total
+}
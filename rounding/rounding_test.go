@@ -0,0 +1,63 @@
+package rounding
+
+import (
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeconds(t *testing.T) {
+	quarter := func(d model.RoundingDirection) *model.Rounding {
+		return &model.Rounding{Increment: 15, Direction: d, Scope: model.RoundingScopeEntry}
+	}
+	tests := []struct {
+		name     string
+		seconds  int
+		rounding *model.Rounding
+		expected int
+	}{
+		{"no rounding", 61, nil, 61},
+		{"up", 61, quarter(model.RoundingDirectionUp), 900},
+		{"up exact", 900, quarter(model.RoundingDirectionUp), 900},
+		{"up nothing", 0, quarter(model.RoundingDirectionUp), 0},
+		{"down", 1799, quarter(model.RoundingDirectionDown), 900},
+		{"down to nothing", 899, quarter(model.RoundingDirectionDown), 0},
+		{"nearest down", 449, quarter(model.RoundingDirectionNearest), 0},
+		{"nearest half up", 450, quarter(model.RoundingDirectionNearest), 900},
+		{"nearest up", 1400, quarter(model.RoundingDirectionNearest), 1800},
+		{"tenth of an hour", 7*60 + 1, &model.Rounding{Increment: 6, Direction: model.RoundingDirectionUp}, 12 * 60},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Seconds(test.seconds, test.rounding))
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	day := 1598918400 // 2020-09-01 00:00 UTC
+	entries := []Entry{
+		{Start: day + 3600, Seconds: 600},
+		{Start: day + 7200, Seconds: 600},
+		{Start: day + 86400 + 3600, Seconds: 60},
+	}
+
+	perEntry := &model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeEntry}
+	perDay := &model.Rounding{Increment: 15, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeDay}
+
+	assert.Equal(t, 1260, Total(entries, nil))
+	assert.Equal(t, 3*900, Total(entries, perEntry))
+	// 20 minutes on the first day round up to 30, and 1 minute on the second one to 15
+	assert.Equal(t, 1800+900, Total(entries, perDay))
+	assert.Equal(t, 0, Total(nil, perDay))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(model.Rounding{Increment: 6, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeDay}))
+	assert.EqualError(t, Validate(model.Rounding{Increment: 0, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeDay}),
+		"rounding increment must be between 1 and 1440 minutes")
+	assert.Error(t, Validate(model.Rounding{Increment: 6, Direction: "SIDEWAYS", Scope: model.RoundingScopeDay}))
+	assert.Error(t, Validate(model.Rounding{Increment: 6, Direction: model.RoundingDirectionUp}))
+}
@@ -0,0 +1,192 @@
+// Package search finds achievements by the words in their notes when Redis has no RediSearch module
+package search
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Store returns a Store that searches achievements in an in-process index of their notes instead of s.
+// The index of a user is built from s on their first search, and kept up to date with the changes made
+// through the returned Store. It's lost on restart.
+// Changes made by other instances sharing the database don't reach the index, so servers behind a load
+// balancer must use RediSearch instead.
+func Store(s storage.Store, logger logrus.FieldLogger) storage.Store {
+	return &store{Store: s, logger: logger, users: map[string]*index{}}
+}
+
+type store struct {
+	storage.Store
+	logger logrus.FieldLogger
+
+	// mu guards users only, every index has its own lock so that building one doesn't block the others
+	mu    sync.Mutex
+	users map[string]*index
+}
+
+// index maps the words of the notes of a user's achievements to their IDs
+type index struct {
+	mu    sync.Mutex
+	built bool
+	words map[string]map[string]bool
+	notes map[string][]string
+}
+
+func newIndex() *index {
+	return &index{words: map[string]map[string]bool{}, notes: map[string][]string{}}
+}
+
+// add indexes the note of achievement aID, replacing the previous one
+func (idx *index) add(aID, note string) {
+	idx.remove(aID)
+	terms := storage.SearchTerms(note)
+	for _, w := range terms {
+		if idx.words[w] == nil {
+			idx.words[w] = map[string]bool{}
+		}
+		idx.words[w][aID] = true
+	}
+	idx.notes[aID] = terms
+}
+
+func (idx *index) remove(aID string) {
+	for _, w := range idx.notes[aID] {
+		delete(idx.words[w], aID)
+		if len(idx.words[w]) == 0 {
+			delete(idx.words, w)
+		}
+	}
+	delete(idx.notes, aID)
+}
+
+// search returns the IDs of the achievements whose note has all the terms
+func (idx *index) search(terms []string) []string {
+	var aIDs []string
+	for aID := range idx.words[terms[0]] {
+		found := true
+		for _, w := range terms[1:] {
+			if !idx.words[w][aID] {
+				found = false
+				break
+			}
+		}
+		if found {
+			aIDs = append(aIDs, aID)
+		}
+	}
+	return aIDs
+}
+
+// user returns the index of user uID, which may not have been built yet
+func (s *store) user(uID string) *index {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, ok := s.users[uID]
+	if !ok {
+		idx = newIndex()
+		s.users[uID] = idx
+	}
+	return idx
+}
+
+// update applies f to the index of user uID, if it has been built.
+// Changes made while it's being built wait for it, so that none is lost.
+func (s *store) update(uID string, f func(idx *index)) {
+	s.mu.Lock()
+	idx, ok := s.users[uID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.built {
+		f(idx)
+	}
+}
+
+func (s *store) SearchAchievements(ctx context.Context, uID, text string) ([]model.Achievement, error) {
+	terms := storage.SearchTerms(text)
+	if len(terms) == 0 {
+		return []model.Achievement{}, nil
+	}
+
+	idx := s.user(uID)
+	idx.mu.Lock()
+	if !idx.built {
+		as, err := s.Store.GetUserAchievements(ctx, uID)
+		if err != nil {
+			idx.mu.Unlock()
+			return nil, err
+		}
+		for _, a := range as {
+			idx.add(a.ID, a.Note)
+		}
+		idx.built = true
+		logging.WithContext(ctx, s.logger).WithField("achievements", len(as)).Debug("Search index built")
+	}
+	aIDs := idx.search(terms)
+	idx.mu.Unlock()
+
+	as := make([]model.Achievement, 0, len(aIDs))
+	for _, aID := range aIDs {
+		a, err := s.Store.GetAchievement(ctx, aID)
+		if err != nil {
+			// Purged along with its project
+			s.update(uID, func(idx *index) { idx.remove(aID) })
+			continue
+		}
+		// Achievements of deleted projects are kept until their project is purged
+		if _, err := s.Store.GetProject(ctx, a.ProjectID); err != nil {
+			continue
+		}
+		as = append(as, a)
+	}
+	return as, nil
+}
+
+func (s *store) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	if err := s.Store.CreateAchievement(ctx, a); err != nil {
+		return err
+	}
+	s.update(a.UserID, func(idx *index) { idx.add(a.ID, a.Note) })
+	return nil
+}
+
+func (s *store) SetAchievementNote(ctx context.Context, aID, note string) error {
+	a, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.SetAchievementNote(ctx, aID, note); err != nil {
+		return err
+	}
+	s.update(a.UserID, func(idx *index) { idx.add(aID, note) })
+	return nil
+}
+
+func (s *store) DeleteAchievement(ctx context.Context, aID, pID string) error {
+	a, err := s.Store.GetAchievement(ctx, aID)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.DeleteAchievement(ctx, aID, pID); err != nil {
+		return err
+	}
+	s.update(a.UserID, func(idx *index) { idx.remove(aID) })
+	return nil
+}
+
+func (s *store) RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error) {
+	a, err := s.Store.RestoreAchievement(ctx, aID, uID)
+	if err != nil {
+		return a, err
+	}
+	s.update(uID, func(idx *index) { idx.add(aID, a.Note) })
+	return a, nil
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	uID = "e0b4e4b5-6d5f-4a3c-9b3a-1f6d3c2e9a10"
+	pID = "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+)
+
+var (
+	a1 = model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: pID, Note: "Sprint planning *meeting*"}
+	a2 = model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000002", UserID: uID, ProjectID: pID, Note: "1:1 Meeting"}
+)
+
+func newTestStore(s *mocks.Store) *store {
+	logger, _ := test.NewNullLogger()
+	return Store(s, logger).(*store)
+}
+
+func TestSearchAchievements(t *testing.T) {
+	var s mocks.Store
+	store := newTestStore(&s)
+	ctx := context.Background()
+
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{a1, a2}, nil).Once()
+	s.On("GetAchievement", ctx, a1.ID).Return(a1, nil)
+	s.On("GetAchievement", ctx, a2.ID).Return(a2, nil)
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, UserID: uID}, nil)
+
+	as, err := store.SearchAchievements(ctx, uID, "planning, MEETING")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Achievement{a1}, as)
+
+	as, err = store.SearchAchievements(ctx, uID, "meeting")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []model.Achievement{a1, a2}, as)
+
+	as, err = store.SearchAchievements(ctx, uID, "retro")
+	assert.NoError(t, err)
+	assert.Empty(t, as)
+
+	s.AssertExpectations(t)
+}
+
+func TestSearchFollowsChanges(t *testing.T) {
+	var s mocks.Store
+	store := newTestStore(&s)
+	ctx := context.Background()
+
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{a1, a2}, nil).Once()
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, UserID: uID}, nil)
+	_, err := store.SearchAchievements(ctx, uID, "retro")
+	assert.NoError(t, err)
+
+	// a2's note is replaced
+	s.On("GetAchievement", ctx, a2.ID).Return(a2, nil).Once()
+	s.On("SetAchievementNote", ctx, a2.ID, "Planning poker").Return(nil)
+	assert.NoError(t, store.SetAchievementNote(ctx, a2.ID, "Planning poker"))
+
+	// and a1 is deleted
+	s.On("GetAchievement", ctx, a1.ID).Return(a1, nil).Once()
+	s.On("DeleteAchievement", ctx, a1.ID, pID).Return(nil)
+	assert.NoError(t, store.DeleteAchievement(ctx, a1.ID, pID))
+
+	updated := a2
+	updated.Note = "Planning poker"
+	s.On("GetAchievement", ctx, a2.ID).Return(updated, nil)
+	as, err := store.SearchAchievements(ctx, uID, "planning")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Achievement{updated}, as)
+
+	as, err = store.SearchAchievements(ctx, uID, "meeting")
+	assert.NoError(t, err)
+	assert.Empty(t, as)
+
+	s.AssertExpectations(t)
+}
+
+func TestSearchIndexesBuiltPerUser(t *testing.T) {
+	var s mocks.Store
+	store := newTestStore(&s)
+	ctx := context.Background()
+
+	// The index of user 1 takes until the end of the test to build
+	building, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s.On("GetUserAchievements", ctx, "1").Return([]model.Achievement{}, nil).Run(func(mock.Arguments) {
+		close(building)
+		<-release
+	})
+	go store.SearchAchievements(ctx, "1", "meeting")
+	<-building
+
+	s.On("GetUserAchievements", ctx, uID).Return([]model.Achievement{a2}, nil)
+	s.On("GetAchievement", ctx, a2.ID).Return(a2, nil)
+	s.On("GetProject", ctx, pID).Return(model.Project{ID: pID, UserID: uID}, nil)
+	as, err := store.SearchAchievements(ctx, uID, "meeting")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Achievement{a2}, as)
+}
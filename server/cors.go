@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is the policy applied to requests sent by browsers from other origins, such as picard's.
+// Origins are matched exactly, "*" allows every origin and a single "*" inside an origin matches
+// any subdomain, as in https://*.example.com
+// Scripts can read the ExposedHeaders of responses besides the basic ones.
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Handler returns h wrapped so that it answers preflight requests and adds
+// the CORS headers to the responses to allowed origins
+func (c CORS) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if c.originAllowed(origin) {
+			c.allowOrigin(w, origin)
+			if len(c.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (c CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	headers := requestedHeaders(r)
+	if !c.originAllowed(origin) || !contains(c.AllowedMethods, method) || !c.headersAllowed(headers) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.allowOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	if len(headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c CORS) allowOrigin(w http.ResponseWriter, origin string) {
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// CheckOrigin reports whether a websocket connection can be upgraded.
+// Connections from non browser clients, which send no origin, and from the server's own origin
+// are always accepted.
+func (c CORS) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return c.originAllowed(origin)
+}
+
+func (c CORS) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if i := strings.Index(allowed, "*"); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c CORS) headersAllowed(headers []string) bool {
+	for _, h := range headers {
+		if !contains(c.AllowedHeaders, h) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestedHeaders returns the headers listed in the Access-Control-Request-Headers header
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, v := range r.Header["Access-Control-Request-Headers"] {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				headers = append(headers, http.CanonicalHeaderKey(h))
+			}
+		}
+	}
+	return headers
+}
+
+// contains reports whether list contains s, ignoring case
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == "*" || strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCORS = CORS{
+	AllowedOrigins:   []string{"https://picard.example.com", "https://*.preview.example.com"},
+	AllowedMethods:   []string{"GET", "POST"},
+	AllowedHeaders:   []string{"Content-Type", "X-Request-Id"},
+	ExposedHeaders:   []string{"X-Request-ID", "Retry-After"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func preflight(origin, method, headers string) *httptest.ResponseRecorder {
+	h := testCORS.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/query", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	w := preflight("https://picard.example.com", "POST", "content-type, x-request-id")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://picard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-Request-Id", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header()["Vary"], "Origin")
+}
+
+func TestCORSPreflightWildcardOrigin(t *testing.T) {
+	w := preflight("https://pr-42.preview.example.com", "GET", "")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://pr-42.preview.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSPreflightRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"origin", "https://evil.example.com", "POST", ""},
+		{"wildcard origin", "https://preview.example.com", "POST", ""},
+		{"method", "https://picard.example.com", "DELETE", ""},
+		{"header", "https://picard.example.com", "POST", "Authorization"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := preflight(tt.origin, tt.method, tt.headers)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	h := testCORS.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for origin, allowed := range map[string]string{
+		"https://picard.example.com": "https://picard.example.com",
+		"https://evil.example.com":   "",
+	} {
+		req := httptest.NewRequest("POST", "/query", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, allowed, w.Header().Get("Access-Control-Allow-Origin"))
+		if allowed != "" {
+			assert.Equal(t, "X-Request-ID, Retry-After", w.Header().Get("Access-Control-Expose-Headers"))
+		} else {
+			assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+}
+
+func TestCORSCheckOrigin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://glow.example.com", true},
+		{"https://picard.example.com", true},
+		{"https://evil.example.com", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "http://glow.example.com/query", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+
+		assert.Equal(t, tt.allowed, testCORS.CheckOrigin(req), tt.origin)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// Check reports whether a dependency of the server is ready to be used
+type Check func(ctx context.Context) error
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// HealthHandler reports that the process is alive
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+	})
+}
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// ReadyHandler reports whether the server can receive requests:
+// it's not shutting down and every check passes
+func (s *Server) ReadyHandler(checks map[string]Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := readiness{Status: statusOK, Checks: map[string]string{}}
+		if atomic.LoadInt32(&s.shuttingDown) == 1 {
+			resp.Status = statusUnavailable
+			resp.Checks["server"] = "shutting down"
+		}
+		for name, check := range checks {
+			if err := check(r.Context()); err != nil {
+				resp.Status = statusUnavailable
+				resp.Checks[name] = err.Error()
+			} else {
+				resp.Checks[name] = statusOK
+			}
+		}
+
+		status := http.StatusOK
+		if resp.Status != statusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, resp)
+	})
+}
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	Module    string `json:"module"`
+	GoVersion string `json:"goVersion"`
+}
+
+// VersionHandler reports how the running binary was built.
+// version, commit and date are set at build time with ldflags,
+// the rest of the information is read from the binary itself.
+func VersionHandler(version, commit, date string) http.Handler {
+	info := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Module = bi.Main.Path
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, info)
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(h http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	return w
+}
+
+func TestHealth(t *testing.T) {
+	w := get(HealthHandler())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReady(t *testing.T) {
+	srv := New("", http.NotFoundHandler(), nullLogger())
+	checks := map[string]Check{
+		"storage": func(ctx context.Context) error { return nil },
+	}
+
+	w := get(srv.ReadyHandler(checks))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{"storage":"ok"}}`, w.Body.String())
+}
+
+func TestReadyFailingCheck(t *testing.T) {
+	srv := New("", http.NotFoundHandler(), nullLogger())
+	checks := map[string]Check{
+		"storage": func(ctx context.Context) error { return errors.New("connection refused") },
+	}
+
+	w := get(srv.ReadyHandler(checks))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unavailable","checks":{"storage":"connection refused"}}`, w.Body.String())
+}
+
+func TestReadyShuttingDown(t *testing.T) {
+	srv := New("", http.NotFoundHandler(), nullLogger())
+	checks := map[string]Check{
+		"storage": func(ctx context.Context) error { return nil },
+	}
+
+	assert.NoError(t, srv.Shutdown(context.Background()))
+	w := get(srv.ReadyHandler(checks))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unavailable","checks":{"server":"shutting down","storage":"ok"}}`, w.Body.String())
+}
+
+func TestReadyUnready(t *testing.T) {
+	srv := New("", http.NotFoundHandler(), nullLogger())
+
+	srv.Unready()
+	w := get(srv.ReadyHandler(nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"unavailable","checks":{"server":"shutting down"}}`, w.Body.String())
+}
+
+func TestVersion(t *testing.T) {
+	w := get(VersionHandler("v1.2.3", "abc123", "2020-09-01"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":"v1.2.3","commit":"abc123","date":"2020-09-01"`)
+	assert.Contains(t, w.Body.String(), `"goVersion":"`+runtime.Version()+`"`)
+}
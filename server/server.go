@@ -0,0 +1,168 @@
+// Package server runs the HTTP server and stops it gracefully
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Server is an HTTP server that drains in-flight requests before stopping.
+//
+// On shutdown it stops accepting new connections, notifies long-lived requests
+// (like websocket subscriptions) through their context, waits for in-flight requests
+// to complete, closes the remaining hijacked connections, cancels the context of
+// every request and finally closes its dependencies (like the storage) in the given order.
+type Server struct {
+	srv     *http.Server
+	logger  logrus.FieldLogger
+	closers []io.Closer
+
+	cancel        context.CancelFunc
+	longLived     context.Context
+	stopLongLived context.CancelFunc
+	shuttingDown  int32
+
+	mu       sync.Mutex
+	hijacked map[net.Conn]struct{}
+}
+
+// New creates a Server listening on addr.
+// closers are closed once the server has been drained.
+func New(addr string, h http.Handler, logger logrus.FieldLogger, closers ...io.Closer) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	longLived, stopLongLived := context.WithCancel(context.Background())
+	s := &Server{
+		logger:        logger,
+		closers:       closers,
+		cancel:        cancel,
+		longLived:     longLived,
+		stopLongLived: stopLongLived,
+		hijacked:      map[net.Conn]struct{}{},
+	}
+	s.srv = &http.Server{
+		Addr:        addr,
+		Handler:     s.trackHijacked(h),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	return s
+}
+
+// trackHijacked keeps the connections taken over by handlers, like websockets,
+// since http.Server doesn't close them on shutdown.
+// Their requests are canceled as soon as the shutdown starts, since they'd never be drained.
+func (s *Server) trackHijacked(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hj, ok := w.(http.Hijacker); ok && r.Header.Get("Upgrade") != "" {
+			w = hijackTracker{ResponseWriter: w, hijacker: hj, s: s}
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			go func() {
+				select {
+				case <-s.longLived.Done():
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			r = r.WithContext(ctx)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+type hijackTracker struct {
+	http.ResponseWriter
+	hijacker http.Hijacker
+	s        *Server
+}
+
+func (w hijackTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.hijacker.Hijack()
+	if err != nil {
+		return conn, rw, err
+	}
+	w.s.mu.Lock()
+	w.s.hijacked[conn] = struct{}{}
+	w.s.mu.Unlock()
+	return trackedConn{Conn: conn, s: w.s}, rw, nil
+}
+
+type trackedConn struct {
+	net.Conn
+	s *Server
+}
+
+func (c trackedConn) Close() error {
+	c.s.mu.Lock()
+	delete(c.s.hijacked, c.Conn)
+	c.s.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// ListenAndServe listens on the server address and serves requests,
+// using TLS if certFile and keyFile are not empty.
+// It returns http.ErrServerClosed after Shutdown is called.
+func (s *Server) ListenAndServe(certFile, keyFile string) error {
+	l, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l, certFile, keyFile)
+}
+
+// Serve serves requests on the given listener,
+// using TLS if certFile and keyFile are not empty.
+// It returns http.ErrServerClosed after Shutdown is called.
+func (s *Server) Serve(l net.Listener, certFile, keyFile string) error {
+	if certFile != "" || keyFile != "" {
+		return s.srv.ServeTLS(l, certFile, keyFile)
+	}
+	return s.srv.Serve(l)
+}
+
+// Unready makes the server report that it's not ready, while it keeps serving requests,
+// so that load balancers stop sending new ones before it shuts down
+func (s *Server) Unready() {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+}
+
+// Shutdown stops the server gracefully.
+// If ctx expires before every in-flight request completes, the remaining connections
+// are closed anyway and the context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Unready()
+
+	drained := make(chan error, 1)
+	go func() { drained <- s.srv.Shutdown(ctx) }()
+	// Long-lived requests have to finish by themselves, let them know.
+	// The rest keep their context until they're drained.
+	s.stopLongLived()
+
+	err := <-drained
+	if err != nil {
+		s.logger.WithError(err).Warn("Unable to drain every request")
+		s.srv.Close()
+	}
+
+	s.mu.Lock()
+	for conn := range s.hijacked {
+		conn.Close()
+	}
+	s.hijacked = map[net.Conn]struct{}{}
+	s.mu.Unlock()
+	s.cancel()
+
+	for _, c := range s.closers {
+		if cErr := c.Close(); cErr != nil {
+			s.logger.WithError(cErr).Error("Unable to close dependency")
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// nullLogger returns a logger that discards everything
+func nullLogger() *logrus.Logger {
+	logger, _ := test.NewNullLogger()
+	return logger
+}
+
+type closer struct {
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestShutdownCompletesInFlightMutation(t *testing.T) {
+	var s mocks.Store
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	s.On("GetCategory", mock.Anything, cID).Return(model.Category{ID: cID, UserID: "0"}, nil)
+	s.On("CreateProject", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		close(started)
+		<-release
+	})
+
+	logger := nullLogger()
+	gql := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: graph.NewResolver(&s, logger),
+	}))
+	gql.AddTransport(transport.POST{})
+
+	var db closer
+	srv := New("", gql, logger, &db)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() { served <- srv.Serve(l, "", "") }()
+
+	type response struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan response)
+	go func() {
+		body := `{"query":"mutation { createProject(input: {name: \"Test\", categoryID: \"` + cID + `\"}) { name } }"}`
+		resp, err := http.Post("http://"+l.Addr().String()+"/query", "application/json", strings.NewReader(body))
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		responses <- response{status: resp.StatusCode, body: string(b), err: err}
+	}()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+
+	assert.Equal(t, http.ErrServerClosed, <-served)
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the in-flight mutation completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, db.closed)
+
+	close(release)
+
+	resp := <-responses
+	assert.NoError(t, resp.err)
+	assert.Equal(t, http.StatusOK, resp.status)
+	assert.Equal(t, `{"data":{"createProject":{"name":"Test"}}}`, resp.body)
+	assert.NoError(t, <-shutdown)
+	assert.True(t, db.closed)
+	s.AssertExpectations(t)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	var db closer
+	srv := New("", h, nullLogger(), &db)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l, "", "")
+	go http.Get("http://" + l.Addr().String())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, srv.Shutdown(ctx))
+	assert.True(t, db.closed)
+}
+
+func TestShutdownKeepsRequestsContext(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	ended := make(chan error, 2)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		if r.Header.Get("Upgrade") != "" {
+			<-r.Context().Done()
+		} else {
+			<-release
+		}
+		ended <- r.Context().Err()
+	})
+
+	srv := New("", h, nullLogger())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l, "", "")
+	go http.Get("http://" + l.Addr().String())
+	ws, err := http.NewRequest(http.MethodGet, "http://"+l.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Header.Set("Connection", "Upgrade")
+	ws.Header.Set("Upgrade", "websocket")
+	go http.DefaultClient.Do(ws)
+	<-started
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+
+	// Long-lived requests are told to finish, while the rest are drained
+	assert.Equal(t, context.Canceled, <-ended)
+	close(release)
+	assert.NoError(t, <-ended)
+	assert.NoError(t, <-shutdown)
+}
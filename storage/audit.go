@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Audit entries' fields
+const (
+	sAudit      string = "audit"
+	sActor      string = "actor"
+	sOperation  string = "operation"
+	sEntityType string = "entityType"
+	sEntityID   string = "entityID"
+	sBefore     string = "before"
+	sAfter      string = "after"
+	sTimestamp  string = "timestamp"
+)
+
+func (s redisStore) AppendAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	key := fmt.Sprintf("%s:%s", sAudit, e.EntityID)
+	args := []interface{}{key, "*",
+		sActor, e.Actor,
+		sOperation, e.Operation,
+		sEntityType, e.EntityType,
+		sEntityID, e.EntityID,
+		sTimestamp, e.Timestamp,
+	}
+	if e.Before != nil {
+		args = append(args, sBefore, *e.Before)
+	}
+	if e.After != nil {
+		args = append(args, sAfter, *e.After)
+	}
+
+	if _, err := do(ctx, s.pool, "XADD", args...); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) GetAuditLog(ctx context.Context, entityID string, from, to int) ([]model.AuditEntry, error) {
+	// Stream entry IDs start with the time they were added, in milliseconds
+	key := fmt.Sprintf("%s:%s", sAudit, entityID)
+	start, end := strconv.Itoa(from*1000), "+"
+	if to != 0 {
+		end = strconv.Itoa(to*1000 + 999)
+	}
+
+	entries, err := redis.Values(do(ctx, s.pool, "XRANGE", key, start, end))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	es := make([]model.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		var id string
+		var values []interface{}
+		parts, err := redis.Values(entry, nil)
+		if err == nil {
+			_, err = redis.Scan(parts, &id, &values)
+		}
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return es, err
+		}
+		fields, err := redis.StringMap(values, nil)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return es, err
+		}
+
+		e := model.AuditEntry{
+			ID:         id,
+			Actor:      fields[sActor],
+			Operation:  fields[sOperation],
+			EntityType: fields[sEntityType],
+			EntityID:   fields[sEntityID],
+		}
+		if v, ok := fields[sBefore]; ok {
+			e.Before = &v
+		}
+		if v, ok := fields[sAfter]; ok {
+			e.After = &v
+		}
+		e.Timestamp, _ = strconv.Atoi(fields[sTimestamp])
+		es = append(es, e)
+	}
+	return es, nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Rounding rules' fields
+const (
+	sRoundingDirection string = "roundingDirection"
+	sRoundingIncrement string = "roundingIncrement"
+	sRoundingScope     string = "roundingScope"
+)
+
+// boolField is how flags are stored in hashes' fields
+func boolField(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// rateFromFields returns the hourly rate stored in the fields of a project's or user's hash, nil if there's none
+func rateFromFields(fields map[string]string) *model.Money {
+	amount, ok := fields[sRate]
+	if !ok {
+		return nil
+	}
+	rate := model.Money{Currency: fields[sCurrency]}
+	rate.Amount, _ = strconv.Atoi(amount)
+	return &rate
+}
+
+// setRate stores the hourly rate in the hash key, or removes it if it's nil
+func (s redisStore) setRate(ctx context.Context, key string, rate *model.Money) error {
+	var err error
+	if rate != nil {
+		_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sRate, rate.Amount, sCurrency, rate.Currency))
+	} else {
+		_, err = redis.Int64(do(ctx, s.pool, "HDEL", key, sRate, sCurrency))
+	}
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+// roundingFromFields returns the rounding rule stored in the fields of a project's or user's hash,
+// nil if there's none
+func roundingFromFields(fields map[string]string) *model.Rounding {
+	increment, ok := fields[sRoundingIncrement]
+	if !ok {
+		return nil
+	}
+	r := model.Rounding{
+		Direction: model.RoundingDirection(fields[sRoundingDirection]),
+		Scope:     model.RoundingScope(fields[sRoundingScope]),
+	}
+	r.Increment, _ = strconv.Atoi(increment)
+	return &r
+}
+
+// setRounding stores the rounding rule in the hash key, or removes it if it's nil
+func (s redisStore) setRounding(ctx context.Context, key string, r *model.Rounding) error {
+	var err error
+	if r != nil {
+		_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sRoundingIncrement, r.Increment,
+			sRoundingDirection, r.Direction.String(), sRoundingScope, r.Scope.String()))
+	} else {
+		_, err = redis.Int64(do(ctx, s.pool, "HDEL", key, sRoundingIncrement, sRoundingDirection, sRoundingScope))
+	}
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) GetUserRate(ctx context.Context, uID string) (*model.Money, error) {
+	key := fmt.Sprintf("%s:%s", sUser, uID)
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	return rateFromFields(fields), nil
+}
+
+func (s redisStore) SetUserRate(ctx context.Context, uID string, rate *model.Money) error {
+	return s.setRate(ctx, fmt.Sprintf("%s:%s", sUser, uID), rate)
+}
+
+func (s redisStore) GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error) {
+	key := fmt.Sprintf("%s:%s", sUser, uID)
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	return roundingFromFields(fields), nil
+}
+
+func (s redisStore) SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error {
+	return s.setRounding(ctx, fmt.Sprintf("%s:%s", sUser, uID), r)
+}
+
+func (s redisStore) SetAchievementBillable(ctx context.Context, aID string, billable *bool) error {
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return err
+	}
+
+	var err error
+	if billable != nil {
+		_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sBillable, boolField(*billable)))
+	} else {
+		_, err = redis.Int64(do(ctx, s.pool, "HDEL", key, sBillable))
+	}
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
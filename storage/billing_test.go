@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectRate(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Client X", CategoryID: cID,
+		Rate: &model.Money{Amount: 9000, Currency: "EUR"}, Billable: true}
+	require.NoError(t, s.CreateProject(ctx, p))
+
+	actual, err := s.GetProject(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, p, actual)
+
+	actual, err = s.UpdateProject(ctx, p.ID, model.NewProject{Name: p.Name})
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Rate)
+	assert.False(t, actual.Billable)
+
+	actual, err = s.GetProject(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Rate)
+	assert.False(t, actual.Billable)
+}
+
+func TestProjectsAreBillableByDefault(t *testing.T) {
+	mr, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	s := NewRedisStore(pool, logger)
+
+	// As stored before billing existed
+	pID := "3b054f50-9d3d-4114-bfc4-000000000001"
+	mr.HSet("project:"+pID, "userID", uID, "name", "glow", "categoryID", cID)
+
+	p, err := s.GetProject(context.Background(), pID)
+	assert.NoError(t, err)
+	assert.True(t, p.Billable)
+}
+
+func TestUserRate(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	rate, err := s.GetUserRate(ctx, uID)
+	assert.NoError(t, err)
+	assert.Nil(t, rate)
+
+	assert.NoError(t, s.SetUserRate(ctx, uID, &model.Money{Amount: 5000, Currency: "USD"}))
+	rate, err = s.GetUserRate(ctx, uID)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Money{Amount: 5000, Currency: "USD"}, rate)
+
+	assert.NoError(t, s.SetUserRate(ctx, uID, nil))
+	rate, err = s.GetUserRate(ctx, uID)
+	assert.NoError(t, err)
+	assert.Nil(t, rate)
+}
+
+func TestProjectRounding(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Support", CategoryID: cID,
+		Rounding: &model.Rounding{Increment: 6, Direction: model.RoundingDirectionUp, Scope: model.RoundingScopeDay}}
+	require.NoError(t, s.CreateProject(ctx, p))
+
+	actual, err := s.GetProject(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, p, actual)
+
+	actual, err = s.UpdateProject(ctx, p.ID, model.NewProject{Name: p.Name})
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Rounding)
+
+	actual, err = s.GetProject(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Rounding)
+}
+
+func TestUserRounding(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	r := &model.Rounding{Increment: 15, Direction: model.RoundingDirectionNearest, Scope: model.RoundingScopeEntry}
+
+	actual, err := s.GetUserRounding(ctx, uID)
+	assert.NoError(t, err)
+	assert.Nil(t, actual)
+
+	assert.NoError(t, s.SetUserRounding(ctx, uID, r))
+	actual, err = s.GetUserRounding(ctx, uID)
+	assert.NoError(t, err)
+	assert.Equal(t, r, actual)
+
+	// The user's rate is kept apart
+	rate, err := s.GetUserRate(ctx, uID)
+	assert.NoError(t, err)
+	assert.Nil(t, rate)
+
+	assert.NoError(t, s.SetUserRounding(ctx, uID, nil))
+	actual, err = s.GetUserRounding(ctx, uID)
+	assert.NoError(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestSetAchievementBillable(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	a := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: "3b054f50-9d3d-4114-bfc4-000000000001"}
+	require.NoError(t, s.CreateAchievement(ctx, a))
+	notBillable := false
+
+	assert.NoError(t, s.SetAchievementBillable(ctx, a.ID, &notBillable))
+	actual, err := s.GetAchievement(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &notBillable, actual.Billable)
+
+	assert.NoError(t, s.SetAchievementBillable(ctx, a.ID, nil))
+	actual, err = s.GetAchievement(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Billable)
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/logging"
+)
+
+type redisQueryCache struct {
+	pool   *redis.Pool
+	ttl    time.Duration
+	logger logrus.FieldLogger
+}
+
+// NewRedisQueryCache creates a cache for automatic persisted queries stored in Redis.
+// Unlike an in-memory cache, it survives restarts and it's shared by every instance.
+// Queries expire after ttl.
+func NewRedisQueryCache(pool *redis.Pool, ttl time.Duration, logger logrus.FieldLogger) graphql.Cache {
+	return redisQueryCache{pool: pool, ttl: ttl, logger: logger}
+}
+
+func (c redisQueryCache) Get(ctx context.Context, hash string) (interface{}, bool) {
+	key := fmt.Sprintf("%s:%s", sAPQ, hash)
+	query, err := redis.String(do(ctx, c.pool, "GET", key))
+	if err == redis.ErrNil {
+		return nil, false
+	}
+	if err != nil {
+		logging.WithContext(ctx, c.logger).WithError(err).Error("Database error")
+		return nil, false
+	}
+	return query, true
+}
+
+func (c redisQueryCache) Add(ctx context.Context, hash string, query interface{}) {
+	key := fmt.Sprintf("%s:%s", sAPQ, hash)
+	if _, err := do(ctx, c.pool, "SET", key, query, "PX", c.ttl.Milliseconds()); err != nil {
+		logging.WithContext(ctx, c.logger).WithError(err).Error("Database error")
+	}
+}
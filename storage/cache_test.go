@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisQueryCache(t *testing.T) {
+	mr, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	c := NewRedisQueryCache(pool, 500*time.Millisecond, logger)
+	ctx := context.Background()
+
+	_, ok := c.Get(ctx, "abc")
+	assert.False(t, ok)
+
+	c.Add(ctx, "abc", "{ projects { id } }")
+	query, ok := c.Get(ctx, "abc")
+	assert.True(t, ok)
+	assert.Equal(t, "{ projects { id } }", query)
+	// Times to live under a second are kept
+	assert.Equal(t, 500*time.Millisecond, mr.TTL("apq:abc"))
+
+	mr.FastForward(time.Second)
+	_, ok = c.Get(ctx, "abc")
+	assert.False(t, ok)
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Categories' keys and fields
+const (
+	sCategories    string = "categories"
+	sCategory      string = "category"
+	sCategoryNames string = "categoryNames"
+	sColor         string = "color"
+	sIcon          string = "icon"
+	sOrder         string = "order"
+)
+
+// categoryFromFields builds the category cID from the fields of its hash
+func categoryFromFields(cID string, fields map[string]string) model.Category {
+	c := model.Category{
+		ID:     cID,
+		UserID: fields[sUserID],
+		Name:   fields[sName],
+		Color:  fields[sColor],
+		Icon:   fields[sIcon],
+	}
+	c.Order, _ = strconv.Atoi(fields[sOrder])
+	return c
+}
+
+// reserveName assigns name to the entity id in the hash namesKey, failing if another entity has it.
+// kind names the entity in the error.
+func (s redisStore) reserveName(ctx context.Context, namesKey, kind, id, name string) error {
+	n, err := redis.Int64(do(ctx, s.pool, "HSETNX", namesKey, NameKey(name), id))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if n == 0 {
+		owner, err := redis.String(do(ctx, s.pool, "HGET", namesKey, NameKey(name)))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if owner != id {
+			return fmt.Errorf("%s %q does already exist", kind, strings.TrimSpace(name))
+		}
+	}
+	return nil
+}
+
+// reserveCategoryName assigns name to the category cID, failing if another category of the user has it
+func (s redisStore) reserveCategoryName(ctx context.Context, uID, cID, name string) error {
+	return s.reserveName(ctx, fmt.Sprintf("%s:%s", sCategoryNames, uID), "category", cID, name)
+}
+
+func (s redisStore) CreateCategory(ctx context.Context, c model.Category) error {
+	key := fmt.Sprintf("%s:%s", sCategory, c.ID)
+	if err := s.errIfExists(ctx, key); err != nil {
+		return err
+	}
+	if err := s.reserveCategoryName(ctx, c.UserID, c.ID, c.Name); err != nil {
+		return err
+	}
+
+	_, err := redis.Int64(do(ctx, s.pool, "HSET", key,
+		sUserID, c.UserID, sName, c.Name, sColor, c.Color, sIcon, c.Icon, sOrder, c.Order))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	key = fmt.Sprintf("%s:%s", sCategories, c.UserID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, c.ID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return nil
+}
+
+func (s redisStore) GetCategory(ctx context.Context, cID string) (model.Category, error) {
+	key := fmt.Sprintf("%s:%s", sCategory, cID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return model.Category{}, err
+	}
+
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return model.Category{}, err
+	}
+	return categoryFromFields(cID, fields), nil
+}
+
+func (s redisStore) GetUserCategories(ctx context.Context, uID string) ([]model.Category, error) {
+	key := fmt.Sprintf("%s:%s", sCategories, uID)
+	categoryIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	cs := make([]model.Category, len(categoryIDs))
+	for i, cID := range categoryIDs {
+		c, err := s.GetCategory(ctx, cID)
+		if err != nil {
+			return cs, err
+		}
+		cs[i] = c
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].Order != cs[j].Order {
+			return cs[i].Order < cs[j].Order
+		}
+		return NameKey(cs[i].Name) < NameKey(cs[j].Name)
+	})
+	return cs, nil
+}
+
+func (s redisStore) UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error) {
+	c, err := s.GetCategory(ctx, cID)
+	if err != nil {
+		return c, err
+	}
+
+	if NameKey(nc.Name) != NameKey(c.Name) {
+		if err := s.reserveCategoryName(ctx, c.UserID, cID, nc.Name); err != nil {
+			return c, err
+		}
+		key := fmt.Sprintf("%s:%s", sCategoryNames, c.UserID)
+		if _, err := redis.Int64(do(ctx, s.pool, "HDEL", key, NameKey(c.Name))); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return c, err
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sCategory, cID)
+	_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sName, nc.Name, sColor, nc.Color, sIcon, nc.Icon, sOrder, nc.Order))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return c, err
+	}
+
+	c.Name, c.Color, c.Icon, c.Order = nc.Name, nc.Color, nc.Icon, nc.Order
+	return c, nil
+}
+
+func (s redisStore) DeleteCategory(ctx context.Context, cID, uID string) error {
+	c, err := s.GetCategory(ctx, cID)
+	if err != nil {
+		return err
+	}
+	if c.UserID != uID {
+		return fmt.Errorf("category %s does not exist", cID)
+	}
+
+	ps, err := s.GetUserProjects(ctx, uID)
+	if err != nil {
+		return err
+	}
+	archived, err := s.GetArchivedProjects(ctx, uID)
+	if err != nil {
+		return err
+	}
+	ps = append(ps, archived...)
+	items, err := s.GetTrash(ctx, uID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Project != nil {
+			ps = append(ps, *item.Project)
+		}
+	}
+	for _, p := range ps {
+		if p.CategoryID == cID {
+			return fmt.Errorf("category %q has projects", c.Name)
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sCategory, cID)
+	if _, err := redis.Int64(do(ctx, s.pool, "DEL", key)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	key = fmt.Sprintf("%s:%s", sCategories, uID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", key, cID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	key = fmt.Sprintf("%s:%s", sCategoryNames, uID)
+	if _, err := redis.Int64(do(ctx, s.pool, "HDEL", key, NameKey(c.Name))); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteOtherUsersCategory(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	require.NoError(t, s.CreateCategory(ctx, model.Category{ID: cID, UserID: uID, Name: "Work"}))
+
+	assert.EqualError(t, s.DeleteCategory(ctx, cID, "1"), "category "+cID+" does not exist")
+	_, err := s.GetCategory(ctx, cID)
+	assert.NoError(t, err)
+}
+
+func TestUpdateProjectKeepsCategory(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "glow", CategoryID: cID}
+	require.NoError(t, s.CreateProject(ctx, p))
+
+	actual, err := s.UpdateProject(ctx, p.ID, model.NewProject{Name: "glow server"})
+	assert.NoError(t, err)
+	assert.Equal(t, cID, actual.CategoryID)
+	actual, err = s.GetProject(ctx, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, cID, actual.CategoryID)
+}
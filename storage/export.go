@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Export tokens' keys and fields
+const (
+	sExpiresAt   string = "expiresAt"
+	sExportToken string = "exportToken"
+	sFormat      string = "format"
+)
+
+func (s redisStore) CreateExportToken(ctx context.Context, t model.ExportToken) error {
+	key := fmt.Sprintf("%s:%s", sExportToken, t.Token)
+	if err := s.errIfExists(ctx, key); err != nil {
+		return err
+	}
+	_, err := redis.Int64(do(ctx, s.pool, "HSET", key, sUserID, t.UserID, sFrom, t.From, sTo, t.To,
+		sFormat, t.Format.String(), sExpiresAt, t.ExpiresAt))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	// Redis removes the token once it expires
+	if _, err := redis.Int64(do(ctx, s.pool, "EXPIREAT", key, t.ExpiresAt)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) GetExportToken(ctx context.Context, token string) (model.ExportToken, error) {
+	t := model.ExportToken{Token: token}
+	key := fmt.Sprintf("%s:%s", sExportToken, token)
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return t, err
+	}
+	t.ExpiresAt, _ = strconv.Atoi(fields[sExpiresAt])
+	// Expired keys may outlive their expiration for a bit
+	if len(fields) == 0 || t.ExpiresAt <= int(time.Now().Unix()) {
+		return t, fmt.Errorf("export token doesn't exist or has expired")
+	}
+
+	t.UserID = fields[sUserID]
+	t.From, _ = strconv.Atoi(fields[sFrom])
+	t.To, _ = strconv.Atoi(fields[sTo])
+	t.Format = model.ExportFormat(fields[sFormat])
+	return t, nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportToken(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	token := model.ExportToken{Token: "6d1f5c2e", UserID: uID, From: 1598918400, To: 1601510400,
+		Format: model.ExportFormatJSONL, ExpiresAt: int(time.Now().Add(time.Minute).Unix())}
+	require.NoError(t, s.CreateExportToken(ctx, token))
+
+	actual, err := s.GetExportToken(ctx, token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, token, actual)
+
+	assert.Error(t, s.CreateExportToken(ctx, token))
+	_, err = s.GetExportToken(ctx, "unknown")
+	assert.EqualError(t, err, "export token doesn't exist or has expired")
+}
+
+func TestExpiredExportToken(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	token := model.ExportToken{Token: "6d1f5c2e", UserID: uID, From: 1598918400, To: 1601510400,
+		Format: model.ExportFormatCsv, ExpiresAt: int(time.Now().Add(-time.Minute).Unix())}
+	require.NoError(t, s.CreateExportToken(ctx, token))
+
+	_, err := s.GetExportToken(ctx, token.Token)
+	assert.EqualError(t, err, "export token doesn't exist or has expired")
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Invoices' keys and fields
+const (
+	sAchievementIDs string = "achievementIDs"
+	sCreatedAt      string = "createdAt"
+	sFrom           string = "from"
+	sInvoice        string = "invoice"
+	sInvoiceID      string = "invoiceID"
+	sInvoiceNumber  string = "invoiceNumber"
+	sInvoices       string = "invoices"
+	sLines          string = "lines"
+	sNumber         string = "number"
+	sTo             string = "to"
+	sTotal          string = "total"
+)
+
+// createInvoiceScript creates an invoice and locks its achievements in a single step, so that no
+// achievement is invoiced twice and numbers have no gaps.
+// KEYS are the invoice, its user's invoice number and invoices, and then its achievements.
+// ARGV are the invoice ID, its fields, and then the IDs of its achievements.
+// It replies the number given to the invoice, or 0 and the reason why it wasn't created.
+const createInvoiceScript = `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return {0, "Key " .. KEYS[1] .. " does already exist"}
+end
+for i = 4, #KEYS do
+	local aID = ARGV[i + 6]
+	if redis.call("EXISTS", KEYS[i]) == 0 then
+		return {0, "Key " .. KEYS[i] .. " does not exist"}
+	end
+	if redis.call("HEXISTS", KEYS[i], "invoiceID") == 1 then
+		return {0, "achievement " .. aID .. " is already invoiced"}
+	end
+end
+-- Numbers are consecutive per user, and never reused
+local number = redis.call("INCR", KEYS[2])
+redis.call("HSET", KEYS[1], "userID", ARGV[2], "number", number, "from", ARGV[3], "to", ARGV[4],
+	"createdAt", ARGV[5], "currency", ARGV[6], "total", ARGV[7], "lines", ARGV[8], "achievementIDs", ARGV[9])
+redis.call("ZADD", KEYS[3], number, ARGV[1])
+-- Invoiced achievements are locked
+for i = 4, #KEYS do
+	redis.call("HSET", KEYS[i], "invoiceID", ARGV[1])
+end
+return {number, ""}
+`
+
+func (s redisStore) CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error) {
+	lines, err := json.Marshal(inv.Lines)
+	if err != nil {
+		return inv, err
+	}
+	achievementIDs, err := json.Marshal(inv.AchievementIDs)
+	if err != nil {
+		return inv, err
+	}
+
+	keys := []interface{}{
+		fmt.Sprintf("%s:%s", sInvoice, inv.ID),
+		fmt.Sprintf("%s:%s", sInvoiceNumber, inv.UserID),
+		fmt.Sprintf("%s:%s", sInvoices, inv.UserID),
+	}
+	for _, aID := range inv.AchievementIDs {
+		keys = append(keys, fmt.Sprintf("%s:%s", sAchievement, aID))
+	}
+	args := []interface{}{createInvoiceScript, len(keys)}
+	args = append(args, keys...)
+	args = append(args, inv.ID, inv.UserID, inv.From, inv.To, inv.CreatedAt, inv.Currency, inv.Total,
+		lines, achievementIDs)
+	for _, aID := range inv.AchievementIDs {
+		args = append(args, aID)
+	}
+
+	reply, err := redis.Values(do(ctx, s.pool, "EVAL", args...))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return inv, err
+	}
+	var reason string
+	if _, err := redis.Scan(reply, &inv.Number, &reason); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return inv, err
+	}
+	if inv.Number == 0 {
+		s.log(ctx).WithField("invoiceID", inv.ID).Debug(reason)
+		return inv, errors.New(reason)
+	}
+	return inv, nil
+}
+
+func (s redisStore) GetInvoice(ctx context.Context, iID string) (model.Invoice, error) {
+	key := fmt.Sprintf("%s:%s", sInvoice, iID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return model.Invoice{}, err
+	}
+
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return model.Invoice{}, err
+	}
+
+	inv := model.Invoice{ID: iID, UserID: fields[sUserID], Currency: fields[sCurrency]}
+	inv.Number, _ = strconv.Atoi(fields[sNumber])
+	inv.From, _ = strconv.Atoi(fields[sFrom])
+	inv.To, _ = strconv.Atoi(fields[sTo])
+	inv.CreatedAt, _ = strconv.Atoi(fields[sCreatedAt])
+	inv.Total, _ = strconv.Atoi(fields[sTotal])
+	if err := json.Unmarshal([]byte(fields[sLines]), &inv.Lines); err != nil {
+		s.log(ctx).WithError(err).WithField("invoiceID", iID).Error("Corrupted invoice")
+		return inv, err
+	}
+	if err := json.Unmarshal([]byte(fields[sAchievementIDs]), &inv.AchievementIDs); err != nil {
+		s.log(ctx).WithError(err).WithField("invoiceID", iID).Error("Corrupted invoice")
+		return inv, err
+	}
+	return inv, nil
+}
+
+func (s redisStore) GetUserInvoices(ctx context.Context, uID string) ([]model.Invoice, error) {
+	invoiceIDs, err := redis.Strings(do(ctx, s.pool, "ZRANGE", fmt.Sprintf("%s:%s", sInvoices, uID), 0, -1))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	invs := make([]model.Invoice, len(invoiceIDs))
+	for i, iID := range invoiceIDs {
+		inv, err := s.GetInvoice(ctx, iID)
+		if err != nil {
+			return invs, err
+		}
+		invs[i] = inv
+	}
+	return invs, nil
+}
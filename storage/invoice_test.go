@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateInvoice(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	a := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: "3b054f50-9d3d-4114-bfc4-000000000001"}
+	require.NoError(t, s.CreateAchievement(ctx, a))
+
+	inv := model.Invoice{
+		ID:             "f47ac10b-58cc-4372-a567-000000000001",
+		UserID:         uID,
+		From:           1598918400,
+		To:             1601510400,
+		CreatedAt:      1601514000,
+		Currency:       "EUR",
+		Lines:          []*model.InvoiceLine{{Description: "glow", Seconds: 3600, Amount: 6000}},
+		Total:          6000,
+		AchievementIDs: []string{a.ID},
+	}
+	created, err := s.CreateInvoice(ctx, inv)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.Number)
+
+	actual, err := s.GetInvoice(ctx, inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created, actual)
+
+	// Its achievements are locked, and can't be invoiced again
+	locked, err := s.GetAchievement(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &inv.ID, locked.InvoiceID)
+
+	again := inv
+	again.ID = "f47ac10b-58cc-4372-a567-000000000002"
+	_, err = s.CreateInvoice(ctx, again)
+	assert.EqualError(t, err, "achievement "+a.ID+" is already invoiced")
+}
+
+func TestCreateInvoiceIsAtomic(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	a1 := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: "3b054f50-9d3d-4114-bfc4-000000000001"}
+	a2 := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000002", UserID: uID, ProjectID: "3b054f50-9d3d-4114-bfc4-000000000001"}
+	require.NoError(t, s.CreateAchievement(ctx, a1))
+	require.NoError(t, s.CreateAchievement(ctx, a2))
+	_, err := s.CreateInvoice(ctx, model.Invoice{ID: "f47ac10b-58cc-4372-a567-000000000001", UserID: uID,
+		Lines: []*model.InvoiceLine{}, AchievementIDs: []string{a2.ID}})
+	require.NoError(t, err)
+
+	_, err = s.CreateInvoice(ctx, model.Invoice{ID: "f47ac10b-58cc-4372-a567-000000000002", UserID: uID,
+		Lines: []*model.InvoiceLine{}, AchievementIDs: []string{a1.ID, a2.ID}})
+	assert.EqualError(t, err, "achievement "+a2.ID+" is already invoiced")
+
+	// Neither the number nor the other achievement were taken
+	unlocked, err := s.GetAchievement(ctx, a1.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, unlocked.InvoiceID)
+	next, err := s.CreateInvoice(ctx, model.Invoice{ID: "f47ac10b-58cc-4372-a567-000000000003", UserID: uID,
+		Lines: []*model.InvoiceLine{}, AchievementIDs: []string{a1.ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.Number)
+}
+
+func TestInvoiceNumbers(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, iID := range []string{"f47ac10b-58cc-4372-a567-000000000001", "f47ac10b-58cc-4372-a567-000000000002"} {
+		_, err := s.CreateInvoice(ctx, model.Invoice{ID: iID, UserID: uID, Lines: []*model.InvoiceLine{}, AchievementIDs: []string{}})
+		require.NoError(t, err)
+	}
+	other, err := s.CreateInvoice(ctx, model.Invoice{ID: "f47ac10b-58cc-4372-a567-000000000003", UserID: "1",
+		Lines: []*model.InvoiceLine{}, AchievementIDs: []string{}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.Number)
+
+	invs, err := s.GetUserInvoices(ctx, uID)
+	assert.NoError(t, err)
+	assert.Len(t, invs, 2)
+	for i, inv := range invs {
+		assert.Equal(t, i+1, inv.Number)
+	}
+}
+
+func TestPurgeInvoiced(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, _, backend := createTree(t, s)
+	a := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: backend.ID}
+	require.NoError(t, s.CreateAchievement(ctx, a))
+	inv := model.Invoice{ID: "f47ac10b-58cc-4372-a567-000000000001", UserID: uID, AchievementIDs: []string{a.ID}}
+	_, err := s.CreateInvoice(ctx, inv)
+	require.NoError(t, err)
+
+	// Invoiced achievements of subprojects lock the whole project
+	require.NoError(t, s.DeleteProject(ctx, x.ID, uID))
+	assert.EqualError(t, s.PurgeProject(ctx, x.ID, uID), "achievement "+a.ID+" is locked by invoice "+inv.ID)
+	items, err := s.GetTrash(ctx, uID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	_, err = s.RestoreProject(ctx, x.ID, uID)
+	assert.NoError(t, err)
+
+	require.NoError(t, s.DeleteAchievement(ctx, a.ID, backend.ID))
+	assert.EqualError(t, s.PurgeAchievement(ctx, a.ID, uID), "achievement "+a.ID+" is locked by invoice "+inv.ID)
+	items, err = s.GetTrash(ctx, uID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+const sMigrations string = "migrations"
+
+// Uncategorized is the category given to the projects that had an empty category, and to the ones
+// created without a category
+const Uncategorized string = "Uncategorized"
+
+// migration updates the data written by previous versions
+type migration struct {
+	name string
+	run  func(s redisStore, ctx context.Context) error
+}
+
+var migrations = []migration{
+	{"categories", redisStore.migrateCategories},
+	{"runningTimers", redisStore.migrateRunningTimers},
+}
+
+// Migrate applies the migrations that haven't been applied to the Redis database yet, in order.
+// Applied migrations are recorded so that they run only once.
+func Migrate(ctx context.Context, pool *redis.Pool, logger logrus.FieldLogger) error {
+	s := redisStore{pool: pool, logger: logger}
+	for _, m := range migrations {
+		applied, err := redis.Bool(do(ctx, s.pool, "SISMEMBER", sMigrations, m.name))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		s.log(ctx).WithField("migration", m.name).Info("Applying migration")
+		if err := m.run(s, ctx); err != nil {
+			return fmt.Errorf("migration %s failed: %s", m.name, err)
+		}
+		if _, err := do(ctx, s.pool, "SADD", sMigrations, m.name); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrated fails if any migration hasn't been applied to the Redis database, like while another
+// instance is still migrating it
+func Migrated(ctx context.Context, pool *redis.Pool, logger logrus.FieldLogger) error {
+	s := redisStore{pool: pool, logger: logger}
+	applied, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", sMigrations))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[name] = true
+	}
+	var pending []string
+	for _, m := range migrations {
+		if !done[m.name] {
+			pending = append(pending, m.name)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrations not applied: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// scanKeys returns the keys matching pattern
+func (s redisStore) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	cursor := 0
+	for {
+		values, err := redis.Values(do(ctx, s.pool, "SCAN", cursor, "MATCH", pattern, "COUNT", 100))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return keys, err
+		}
+		var batch []string
+		if _, err := redis.Scan(values, &cursor, &batch); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return keys, err
+		}
+		keys = append(keys, batch...)
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// migrateCategories turns the category names of projects, deleted ones included, into category records,
+// one for every distinct name of each user, and makes projects reference them by ID
+func (s redisStore) migrateCategories(ctx context.Context) error {
+	var keys []string
+	for _, pattern := range []string{sProject + ":*", sDeleted + ":" + sProject + ":*"} {
+		ks, err := s.scanKeys(ctx, pattern)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, ks...)
+	}
+
+	for _, key := range keys {
+		fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		name, ok := fields[sCategory]
+		if !ok {
+			continue
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			name = Uncategorized
+		}
+
+		uID := fields[sUserID]
+		cID, err := redis.String(do(ctx, s.pool, "HGET", fmt.Sprintf("%s:%s", sCategoryNames, uID), NameKey(name)))
+		if err == redis.ErrNil {
+			n, err := redis.Int(do(ctx, s.pool, "SCARD", fmt.Sprintf("%s:%s", sCategories, uID)))
+			if err != nil {
+				s.log(ctx).WithError(err).Error("Database error")
+				return err
+			}
+			c := model.Category{ID: uuid.New().String(), UserID: uID, Name: name, Order: n}
+			if err := s.CreateCategory(ctx, c); err != nil {
+				return err
+			}
+			cID = c.ID
+		} else if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+
+		if _, err := do(ctx, s.pool, "HSET", key, sCategoryID, cID); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if _, err := do(ctx, s.pool, "HDEL", key, sCategory); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateRunningTimers adds the achievements whose timer is running to the running timers
+func (s redisStore) migrateRunningTimers(ctx context.Context) error {
+	keys, err := s.scanKeys(ctx, sAchievement+":*")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		end, err := redis.Int(do(ctx, s.pool, "HGET", key, sEndDateTime))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if end == 0 {
+			if err := s.setRunning(ctx, strings.TrimPrefix(key, sAchievement+":"), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrated(t *testing.T) {
+	_, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	ctx := context.Background()
+
+	assert.EqualError(t, Migrated(ctx, pool, logger), "migrations not applied: categories, runningTimers")
+
+	assert.NoError(t, Migrate(ctx, pool, logger))
+	assert.NoError(t, Migrated(ctx, pool, logger))
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	model "github.com/smeruelo/glow/graph/model"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,13 +14,13 @@ type Store struct {
 	mock.Mock
 }
 
-// CreateProject provides a mock function with given fields: p
-func (_m *Store) CreateProject(p model.Project) error {
-	ret := _m.Called(p)
+// AppendAuditEntry provides a mock function with given fields: ctx, e
+func (_m *Store) AppendAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	ret := _m.Called(ctx, e)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(model.Project) error); ok {
-		r0 = rf(p)
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditEntry) error); ok {
+		r0 = rf(ctx, e)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -26,13 +28,34 @@ func (_m *Store) CreateProject(p model.Project) error {
 	return r0
 }
 
-// DeleteProject provides a mock function with given fields: pID, uID
-func (_m *Store) DeleteProject(pID string, uID string) error {
-	ret := _m.Called(pID, uID)
+// ArchiveProject provides a mock function with given fields: ctx, pID, uID
+func (_m *Store) ArchiveProject(ctx context.Context, pID string, uID string) (model.Project, error) {
+	ret := _m.Called(ctx, pID, uID)
+
+	var r0 model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Project); ok {
+		r0 = rf(ctx, pID, uID)
+	} else {
+		r0 = ret.Get(0).(model.Project)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, pID, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAchievement provides a mock function with given fields: ctx, a
+func (_m *Store) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	ret := _m.Called(ctx, a)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(pID, uID)
+	if rf, ok := ret.Get(0).(func(context.Context, model.Achievement) error); ok {
+		r0 = rf(ctx, a)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -40,20 +63,243 @@ func (_m *Store) DeleteProject(pID string, uID string) error {
 	return r0
 }
 
-// GetProject provides a mock function with given fields: pID
-func (_m *Store) GetProject(pID string) (model.Project, error) {
-	ret := _m.Called(pID)
+// CreateCategory provides a mock function with given fields: ctx, c
+func (_m *Store) CreateCategory(ctx context.Context, c model.Category) error {
+	ret := _m.Called(ctx, c)
 
-	var r0 model.Project
-	if rf, ok := ret.Get(0).(func(string) model.Project); ok {
-		r0 = rf(pID)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Category) error); ok {
+		r0 = rf(ctx, c)
 	} else {
-		r0 = ret.Get(0).(model.Project)
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateExportToken provides a mock function with given fields: ctx, t
+func (_m *Store) CreateExportToken(ctx context.Context, t model.ExportToken) error {
+	ret := _m.Called(ctx, t)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.ExportToken) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateInvoice provides a mock function with given fields: ctx, inv
+func (_m *Store) CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error) {
+	ret := _m.Called(ctx, inv)
+
+	var r0 model.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, model.Invoice) model.Invoice); ok {
+		r0 = rf(ctx, inv)
+	} else {
+		r0 = ret.Get(0).(model.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Invoice) error); ok {
+		r1 = rf(ctx, inv)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateProject provides a mock function with given fields: ctx, p
+func (_m *Store) CreateProject(ctx context.Context, p model.Project) error {
+	ret := _m.Called(ctx, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Project) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTag provides a mock function with given fields: ctx, t
+func (_m *Store) CreateTag(ctx context.Context, t model.Tag) error {
+	ret := _m.Called(ctx, t)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Tag) error); ok {
+		r0 = rf(ctx, t)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAchievement provides a mock function with given fields: ctx, aID, pID
+func (_m *Store) DeleteAchievement(ctx context.Context, aID string, pID string) error {
+	ret := _m.Called(ctx, aID, pID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, aID, pID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCategory provides a mock function with given fields: ctx, cID, uID
+func (_m *Store) DeleteCategory(ctx context.Context, cID string, uID string) error {
+	ret := _m.Called(ctx, cID, uID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, cID, uID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProject provides a mock function with given fields: ctx, pID, uID
+func (_m *Store) DeleteProject(ctx context.Context, pID string, uID string) error {
+	ret := _m.Called(ctx, pID, uID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, pID, uID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTag provides a mock function with given fields: ctx, tID, uID
+func (_m *Store) DeleteTag(ctx context.Context, tID string, uID string) error {
+	ret := _m.Called(ctx, tID, uID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tID, uID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAchievement provides a mock function with given fields: ctx, aID
+func (_m *Store) GetAchievement(ctx context.Context, aID string) (model.Achievement, error) {
+	ret := _m.Called(ctx, aID)
+
+	var r0 model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Achievement); ok {
+		r0 = rf(ctx, aID)
+	} else {
+		r0 = ret.Get(0).(model.Achievement)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, aID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAchievementTags provides a mock function with given fields: ctx, aID
+func (_m *Store) GetAchievementTags(ctx context.Context, aID string) ([]model.Tag, error) {
+	ret := _m.Called(ctx, aID)
+
+	var r0 []model.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Tag); ok {
+		r0 = rf(ctx, aID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, aID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetArchivedProjects provides a mock function with given fields: ctx, uID
+func (_m *Store) GetArchivedProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Project); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Project)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditLog provides a mock function with given fields: ctx, entityID, from, to
+func (_m *Store) GetAuditLog(ctx context.Context, entityID string, from int, to int) ([]model.AuditEntry, error) {
+	ret := _m.Called(ctx, entityID, from, to)
+
+	var r0 []model.AuditEntry
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []model.AuditEntry); ok {
+		r0 = rf(ctx, entityID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AuditEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, entityID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategory provides a mock function with given fields: ctx, cID
+func (_m *Store) GetCategory(ctx context.Context, cID string) (model.Category, error) {
+	ret := _m.Called(ctx, cID)
+
+	var r0 model.Category
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Category); ok {
+		r0 = rf(ctx, cID)
+	} else {
+		r0 = ret.Get(0).(model.Category)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(pID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, cID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -61,13 +307,13 @@ func (_m *Store) GetProject(pID string) (model.Project, error) {
 	return r0, r1
 }
 
-// GetUserProjects provides a mock function with given fields: uID
-func (_m *Store) GetUserProjects(uID string) ([]model.Project, error) {
-	ret := _m.Called(uID)
+// GetChildProjects provides a mock function with given fields: ctx, pID
+func (_m *Store) GetChildProjects(ctx context.Context, pID string) ([]model.Project, error) {
+	ret := _m.Called(ctx, pID)
 
 	var r0 []model.Project
-	if rf, ok := ret.Get(0).(func(string) []model.Project); ok {
-		r0 = rf(uID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Project); ok {
+		r0 = rf(ctx, pID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.Project)
@@ -75,8 +321,73 @@ func (_m *Store) GetUserProjects(uID string) ([]model.Project, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(uID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExpiredTrash provides a mock function with given fields: ctx, before
+func (_m *Store) GetExpiredTrash(ctx context.Context, before int) ([]model.TrashItem, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 []model.TrashItem
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.TrashItem); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TrashItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExportToken provides a mock function with given fields: ctx, token
+func (_m *Store) GetExportToken(ctx context.Context, token string) (model.ExportToken, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 model.ExportToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.ExportToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(model.ExportToken)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoice provides a mock function with given fields: ctx, iID
+func (_m *Store) GetInvoice(ctx context.Context, iID string) (model.Invoice, error) {
+	ret := _m.Called(ctx, iID)
+
+	var r0 model.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Invoice); ok {
+		r0 = rf(ctx, iID)
+	} else {
+		r0 = ret.Get(0).(model.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, iID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -84,20 +395,662 @@ func (_m *Store) GetUserProjects(uID string) ([]model.Project, error) {
 	return r0, r1
 }
 
-// UpdateProject provides a mock function with given fields: pID, np
-func (_m *Store) UpdateProject(pID string, np model.NewProject) (model.Project, error) {
-	ret := _m.Called(pID, np)
+// GetProject provides a mock function with given fields: ctx, pID
+func (_m *Store) GetProject(ctx context.Context, pID string) (model.Project, error) {
+	ret := _m.Called(ctx, pID)
 
 	var r0 model.Project
-	if rf, ok := ret.Get(0).(func(string, model.NewProject) model.Project); ok {
-		r0 = rf(pID, np)
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Project); ok {
+		r0 = rf(ctx, pID)
 	} else {
 		r0 = ret.Get(0).(model.Project)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, model.NewProject) error); ok {
-		r1 = rf(pID, np)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProjectAchievements provides a mock function with given fields: ctx, pID
+func (_m *Store) GetProjectAchievements(ctx context.Context, pID string) ([]model.Achievement, error) {
+	ret := _m.Called(ctx, pID)
+
+	var r0 []model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Achievement); ok {
+		r0 = rf(ctx, pID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Achievement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProjectTags provides a mock function with given fields: ctx, pID
+func (_m *Store) GetProjectTags(ctx context.Context, pID string) ([]model.Tag, error) {
+	ret := _m.Called(ctx, pID)
+
+	var r0 []model.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Tag); ok {
+		r0 = rf(ctx, pID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessionUser provides a mock function with given fields: ctx, token
+func (_m *Store) GetSessionUser(ctx context.Context, token string) (string, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTag provides a mock function with given fields: ctx, tID
+func (_m *Store) GetTag(ctx context.Context, tID string) (model.Tag, error) {
+	ret := _m.Called(ctx, tID)
+
+	var r0 model.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Tag); ok {
+		r0 = rf(ctx, tID)
+	} else {
+		r0 = ret.Get(0).(model.Tag)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTaggedAchievements provides a mock function with given fields: ctx, tagIDs
+func (_m *Store) GetTaggedAchievements(ctx context.Context, tagIDs []string) ([]model.Achievement, error) {
+	ret := _m.Called(ctx, tagIDs)
+
+	var r0 []model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []model.Achievement); ok {
+		r0 = rf(ctx, tagIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Achievement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, tagIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTrash provides a mock function with given fields: ctx, uID
+func (_m *Store) GetTrash(ctx context.Context, uID string) ([]model.TrashItem, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.TrashItem
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.TrashItem); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TrashItem)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserAchievements provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserAchievements(ctx context.Context, uID string) ([]model.Achievement, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Achievement); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Achievement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserCategories provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserCategories(ctx context.Context, uID string) ([]model.Category, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Category
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Category); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Category)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserInvoices provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserInvoices(ctx context.Context, uID string) ([]model.Invoice, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Invoice); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Invoice)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserProjects provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Project); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Project)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserRate provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserRate(ctx context.Context, uID string) (*model.Money, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 *model.Money
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Money); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Money)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserRounding provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 *model.Rounding
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Rounding); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Rounding)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserTags provides a mock function with given fields: ctx, uID
+func (_m *Store) GetUserTags(ctx context.Context, uID string) ([]model.Tag, error) {
+	ret := _m.Called(ctx, uID)
+
+	var r0 []model.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Tag); ok {
+		r0 = rf(ctx, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUsers provides a mock function with given fields: ctx
+func (_m *Store) GetUsers(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Store) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeAchievement provides a mock function with given fields: ctx, aID, uID
+func (_m *Store) PurgeAchievement(ctx context.Context, aID string, uID string) error {
+	ret := _m.Called(ctx, aID, uID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, aID, uID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeProject provides a mock function with given fields: ctx, pID, uID
+func (_m *Store) PurgeProject(ctx context.Context, pID string, uID string) error {
+	ret := _m.Called(ctx, pID, uID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, pID, uID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreAchievement provides a mock function with given fields: ctx, aID, uID
+func (_m *Store) RestoreAchievement(ctx context.Context, aID string, uID string) (model.Achievement, error) {
+	ret := _m.Called(ctx, aID, uID)
+
+	var r0 model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Achievement); ok {
+		r0 = rf(ctx, aID, uID)
+	} else {
+		r0 = ret.Get(0).(model.Achievement)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, aID, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreProject provides a mock function with given fields: ctx, pID, uID
+func (_m *Store) RestoreProject(ctx context.Context, pID string, uID string) (model.Project, error) {
+	ret := _m.Called(ctx, pID, uID)
+
+	var r0 model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Project); ok {
+		r0 = rf(ctx, pID, uID)
+	} else {
+		r0 = ret.Get(0).(model.Project)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, pID, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchAchievements provides a mock function with given fields: ctx, uID, text
+func (_m *Store) SearchAchievements(ctx context.Context, uID string, text string) ([]model.Achievement, error) {
+	ret := _m.Called(ctx, uID, text)
+
+	var r0 []model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.Achievement); ok {
+		r0 = rf(ctx, uID, text)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Achievement)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, uID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAchievementBillable provides a mock function with given fields: ctx, aID, billable
+func (_m *Store) SetAchievementBillable(ctx context.Context, aID string, billable *bool) error {
+	ret := _m.Called(ctx, aID, billable)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *bool) error); ok {
+		r0 = rf(ctx, aID, billable)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAchievementNote provides a mock function with given fields: ctx, aID, note
+func (_m *Store) SetAchievementNote(ctx context.Context, aID string, note string) error {
+	ret := _m.Called(ctx, aID, note)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, aID, note)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRate provides a mock function with given fields: ctx, uID, rate
+func (_m *Store) SetUserRate(ctx context.Context, uID string, rate *model.Money) error {
+	ret := _m.Called(ctx, uID, rate)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.Money) error); ok {
+		r0 = rf(ctx, uID, rate)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRounding provides a mock function with given fields: ctx, uID, r
+func (_m *Store) SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error {
+	ret := _m.Called(ctx, uID, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.Rounding) error); ok {
+		r0 = rf(ctx, uID, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TagAchievement provides a mock function with given fields: ctx, aID, tID
+func (_m *Store) TagAchievement(ctx context.Context, aID string, tID string) error {
+	ret := _m.Called(ctx, aID, tID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, aID, tID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TagProject provides a mock function with given fields: ctx, pID, tID
+func (_m *Store) TagProject(ctx context.Context, pID string, tID string) error {
+	ret := _m.Called(ctx, pID, tID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, pID, tID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnarchiveProject provides a mock function with given fields: ctx, pID, uID
+func (_m *Store) UnarchiveProject(ctx context.Context, pID string, uID string) (model.Project, error) {
+	ret := _m.Called(ctx, pID, uID)
+
+	var r0 model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Project); ok {
+		r0 = rf(ctx, pID, uID)
+	} else {
+		r0 = ret.Get(0).(model.Project)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, pID, uID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UntagAchievement provides a mock function with given fields: ctx, aID, tID
+func (_m *Store) UntagAchievement(ctx context.Context, aID string, tID string) error {
+	ret := _m.Called(ctx, aID, tID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, aID, tID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UntagProject provides a mock function with given fields: ctx, pID, tID
+func (_m *Store) UntagProject(ctx context.Context, pID string, tID string) error {
+	ret := _m.Called(ctx, pID, tID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, pID, tID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAchievement provides a mock function with given fields: ctx, aID, newData
+func (_m *Store) UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error) {
+	ret := _m.Called(ctx, aID, newData)
+
+	var r0 model.Achievement
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.AchievementData) model.Achievement); ok {
+		r0 = rf(ctx, aID, newData)
+	} else {
+		r0 = ret.Get(0).(model.Achievement)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.AchievementData) error); ok {
+		r1 = rf(ctx, aID, newData)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCategory provides a mock function with given fields: ctx, cID, nc
+func (_m *Store) UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error) {
+	ret := _m.Called(ctx, cID, nc)
+
+	var r0 model.Category
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.NewCategory) model.Category); ok {
+		r0 = rf(ctx, cID, nc)
+	} else {
+		r0 = ret.Get(0).(model.Category)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.NewCategory) error); ok {
+		r1 = rf(ctx, cID, nc)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProject provides a mock function with given fields: ctx, pID, np
+func (_m *Store) UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error) {
+	ret := _m.Called(ctx, pID, np)
+
+	var r0 model.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.NewProject) model.Project); ok {
+		r0 = rf(ctx, pID, np)
+	} else {
+		r0 = ret.Get(0).(model.Project)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.NewProject) error); ok {
+		r1 = rf(ctx, pID, np)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTag provides a mock function with given fields: ctx, tID, nt
+func (_m *Store) UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error) {
+	ret := _m.Called(ctx, tID, nt)
+
+	var r0 model.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.NewTag) model.Tag); ok {
+		r0 = rf(ctx, tID, nt)
+	} else {
+		r0 = ret.Get(0).(model.Tag)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.NewTag) error); ok {
+		r1 = rf(ctx, tID, nt)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// NewPool creates a pool of up to size connections to the Redis server at address,
+// which the store, the query cache and the rate limiter share
+func NewPool(address, password string, db, size int) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     size,
+		MaxActive:   size,
+		IdleTimeout: 5 * time.Minute,
+		// Operations wait for a free connection instead of failing
+		Wait: true,
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(ctx, "tcp", address, redis.DialPassword(password), redis.DialDatabase(db))
+		},
+		TestOnBorrow: func(conn redis.Conn, idle time.Time) error {
+			if time.Since(idle) < time.Minute {
+				return nil
+			}
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+}
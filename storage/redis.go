@@ -1,15 +1,22 @@
 package storage
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"strconv"
+	"strings"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/billing"
 	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/logging"
+	"github.com/smeruelo/glow/rounding"
 )
 
 type redisStore struct {
-	conn redis.Conn
+	pool   *redis.Pool
+	logger logrus.FieldLogger
 }
 
 // NewRedisStore creates a Store that implements the interface for a Redis storage
@@ -22,118 +29,213 @@ type redisStore struct {
 // | Key name                     | Redis type | Fields                                               |
 // |------------------------------|------------|------------------------------------------------------|
 // | users                        | hash       | email, userID                                        |
-// | user:<userID>                | hash       | name, email, pass                                    |
+// | user:<userID>                | hash       | name, email, pass, rate, currency, roundingIncrement,|
+// |                              |            | roundingDirection, roundingScope                     |
 // | sessions:<userID>            | set        | token                                                |
 // | session:<token>              | hash       | userID                                               |
 // | projects:<userID>            | set        | projectID                                            |
-// | project:<projectID>          | hash       | userID, name, category                               |
+// | archivedProjects:<userID>    | set        | projectID                                            |
+// | project:<projectID>          | hash       | userID, name, categoryID, parentID, archived, rate,  |
+// |                              |            | currency, billable, roundingIncrement,               |
+// |                              |            | roundingDirection, roundingScope                     |
+// | children:<projectID>         | set        | projectID                                            |
+// | projectTags:<projectID>      | set        | tagID                                                |
+// | categories:<userID>          | set        | categoryID                                           |
+// | categoryNames:<userID>       | hash       | <normalized name>: categoryID                        |
+// | category:<categoryID>        | hash       | userID, name, color, icon, order                     |
 // | achievements:<projectID>     | set        | achievementID                                        |
-// | achievement:<achievementID>  | hash       | userID, projectID, startDateTime, endDateTime        |
+// | achievement:<achievementID>  | hash       | userID, projectID, startDateTime, endDateTime, note, |
+// |                              |            | billable, invoiceID                                  |
+// | achievementTags:<achID>      | set        | tagID                                                |
+// | runningTimers                | set        | achievementID of every timer still running           |
+// | tags:<userID>                | set        | tagID                                                |
+// | tagNames:<userID>            | hash       | <normalized name>: tagID                             |
+// | tag:<tagID>                  | hash       | userID, name, color                                  |
+// | tagged:<entityType>:<tagID>  | set        | ID of every <entityType> with the tag                |
+// | invoices:<userID>            | sorted set | invoiceID scored by number                           |
+// | invoiceNumber:<userID>       | string     | number of the last invoice of the user               |
+// | invoice:<invoiceID>          | hash       | userID, number, from, to, createdAt, currency,       |
+// |                              |            | total, lines, achievementIDs                         |
+// | exportToken:<token>          | hash       | userID, from, to, format, expiresAt                  |
 // | goals:<projectID>            | set        | goalID                                               |
 // | goal:<goalID>                | hash       | userID, projectID, type, minutes, startDate, endDate |
+// | migrations                   | set        | name of every migration applied                      |
+// | apq:<queryHash>              | string     | query                                                |
+// | ratelimit:<bucket>:<client>  | hash       | tokens, last                                         |
+// | audit:<entityID>             | stream     | actor, operation, entityType, entityID, before,      |
+// |                              |            | after, timestamp                                     |
+// | trash                        | sorted set | <entityType>:<entityID> scored by deletion time      |
+// | trash:<userID>               | sorted set | <entityType>:<entityID> scored by deletion time      |
+// | deleted:<entityType>:<id>    | hash       | fields of the deleted <entityType>:<id>              |
 // |------------------------------|------------|------------------------------------------------------|
 //
-func NewRedisStore(conn redis.Conn) Store {
-	return redisStore{conn: conn}
+func NewRedisStore(pool *redis.Pool, logger logrus.FieldLogger) Store {
+	return redisStore{pool: pool, logger: logger}
 }
 
 // Redis keys and hashes' fields
 const (
-	sCategory  string = "category"
-	sName      string = "name"
-	sProject   string = "project"
-	sProjectID string = "projectID"
-	sProjects  string = "projects"
-	sUserID    string = "userID"
+	sAchievement      string = "achievement"
+	sAchievements     string = "achievements"
+	sAPQ              string = "apq"
+	sArchived         string = "archived"
+	sArchivedProjects string = "archivedProjects"
+	sBillable         string = "billable"
+	sCategoryID       string = "categoryID"
+	sChildren         string = "children"
+	sCurrency         string = "currency"
+	sEndDateTime      string = "endDateTime"
+	sName             string = "name"
+	sNote             string = "note"
+	sParentID         string = "parentID"
+	sProject          string = "project"
+	sProjectID        string = "projectID"
+	sProjects         string = "projects"
+	sRate             string = "rate"
+	sRunningTimers    string = "runningTimers"
+	sSession          string = "session"
+	sStartDateTime    string = "startDateTime"
+	sUser             string = "user"
+	sUserID           string = "userID"
 )
 
-func (s redisStore) errIfDoesntExist(key string) error {
-	n, err := redis.Int64(s.conn.Do("EXISTS", key))
+// log returns the logger for the request ctx belongs to
+func (s redisStore) log(ctx context.Context) logrus.FieldLogger {
+	return logging.WithContext(ctx, s.logger)
+}
+
+func (s redisStore) errIfDoesntExist(ctx context.Context, key string) error {
+	n, err := redis.Int64(do(ctx, s.pool, "EXISTS", key))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return err
 	}
 	if n != 1 {
-		log.Printf("Key %s does not exist", key)
+		s.log(ctx).WithField("key", key).Debug("Key does not exist")
 		return fmt.Errorf("Key %s does not exist", key)
 	}
 	return nil
 }
 
-func (s redisStore) errIfExists(key string) error {
-	n, err := redis.Int64(s.conn.Do("EXISTS", key))
+func (s redisStore) errIfExists(ctx context.Context, key string) error {
+	n, err := redis.Int64(do(ctx, s.pool, "EXISTS", key))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return err
 	}
 	if n == 1 {
-		log.Printf("Key %s does already exist", key)
+		s.log(ctx).WithField("key", key).Debug("Key does already exist")
 		return fmt.Errorf("Key %s does already exist", key)
 	}
 	return nil
 }
 
-func (s redisStore) CreateProject(p model.Project) error {
+// NameKey returns the key that identifies a name among others, like the names of a user's categories
+// and tags, so that "Work", "work" and "Work " are the same
+func NameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (s redisStore) CreateProject(ctx context.Context, p model.Project) error {
 	// Check if project exists
 	key := fmt.Sprintf("%s:%s", sProject, p.ID)
-	if err := errIfExists(key); err != nil {
+	if err := s.errIfExists(ctx, key); err != nil {
 		return err
 	}
 
 	// Create project
-	_, err = redis.Int64(s.conn.Do("HSET", key, sUserID, p.UserID, sName, p.Name, sCategory, p.Category))
+	args := []interface{}{key, sUserID, p.UserID, sName, p.Name, sCategoryID, p.CategoryID, sBillable, boolField(p.Billable)}
+	if p.ParentID != nil {
+		args = append(args, sParentID, *p.ParentID)
+	}
+	if p.Rate != nil {
+		args = append(args, sRate, p.Rate.Amount, sCurrency, p.Rate.Currency)
+	}
+	if p.Rounding != nil {
+		args = append(args, sRoundingIncrement, p.Rounding.Increment,
+			sRoundingDirection, p.Rounding.Direction.String(), sRoundingScope, p.Rounding.Scope.String())
+	}
+	_, err := redis.Int64(do(ctx, s.pool, "HSET", args...))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return err
 	}
 
 	// Add it to user's projects
 	key = fmt.Sprintf("%s:%s", sProjects, p.UserID)
-	_, err = redis.Int64(s.conn.Do("SADD", key, p.ID))
+	_, err = redis.Int64(do(ctx, s.pool, "SADD", key, p.ID))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return err
 	}
 
+	// And to its parent's children
+	if p.ParentID != nil {
+		return s.addChild(ctx, *p.ParentID, p.ID)
+	}
+
 	return nil
 }
 
-func (s redisStore) getProject(pID string) (model.Project, error) {
+func (s redisStore) getProject(ctx context.Context, pID string) (model.Project, error) {
 	var p model.Project
 	key := fmt.Sprintf("%s:%s", sProject, pID)
-	if err := s.errIfDoesntExist(key); err != nil {
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
 		return p, err
 	}
 
-	fields, err := redis.StringMap(s.conn.Do("HGETALL", key))
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return p, err
 	}
 
-	p.ID = pID
-	p.UserID = fields[sUserID]
-	p.Name = fields[sName]
-	p.Category = fields[sCategory]
+	return projectFromFields(pID, fields), nil
+}
+
+// projectFromFields builds the project pID from the fields of its hash
+func projectFromFields(pID string, fields map[string]string) model.Project {
+	var parentID *string
+	if id, ok := fields[sParentID]; ok {
+		parentID = &id
+	}
+	return model.Project{
+		ID:         pID,
+		UserID:     fields[sUserID],
+		Name:       fields[sName],
+		CategoryID: fields[sCategoryID],
+		ParentID:   parentID,
+		Archived:   fields[sArchived] == "1",
+		Rate:       rateFromFields(fields),
+		Rounding:   roundingFromFields(fields),
+		// Projects created before billing existed are billable
+		Billable: fields[sBillable] != "0",
+	}
+}
+
+func (s redisStore) GetProject(ctx context.Context, pID string) (model.Project, error) {
+	return s.getProject(ctx, pID)
+}
 
-	return p, nil
+func (s redisStore) GetUserProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	return s.getProjects(ctx, fmt.Sprintf("%s:%s", sProjects, uID))
 }
 
-func (s redisStore) GetProject(pID string) (model.Project, error) {
-	return s.getProject(pID)
+func (s redisStore) GetArchivedProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	return s.getProjects(ctx, fmt.Sprintf("%s:%s", sArchivedProjects, uID))
 }
 
-func (s redisStore) GetUserProjects(uID string) ([]model.Project, error) {
-	key := fmt.Sprintf("%s:%s", sProjects, uID)
-	projectIDs, err := redis.Strings(s.conn.Do("SMEMBERS", key))
+// getProjects returns the projects whose IDs are in the set key
+func (s redisStore) getProjects(ctx context.Context, key string) ([]model.Project, error) {
+	projectIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
 		return nil, err
 	}
 
 	ps := make([]model.Project, len(projectIDs))
 	for i, pID := range projectIDs {
-		p, err := s.getProject(pID)
+		p, err := s.getProject(ctx, pID)
 		if err != nil {
 			return ps, err
 		}
@@ -142,40 +244,350 @@ func (s redisStore) GetUserProjects(uID string) ([]model.Project, error) {
 	return ps, nil
 }
 
-func (s redisStore) UpdateProject(pID string, np model.NewProject) (model.Project, error) {
-	p, err := s.getProject(pID)
+func (s redisStore) UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error) {
+	p, err := s.getProject(ctx, pID)
 	if err != nil {
 		return p, err
 	}
 
+	if !sameID(p.ParentID, np.ParentID) {
+		if err := s.setParent(ctx, p, np.ParentID); err != nil {
+			return p, err
+		}
+	}
+
+	// Projects keep their category unless a new one is given
+	if np.CategoryID != nil {
+		p.CategoryID = *np.CategoryID
+	}
 	key := fmt.Sprintf("%s:%s", sProject, pID)
-	_, err = redis.Int64(s.conn.Do("HSET", key, sName, np.Name, sCategory, np.Category))
+	_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sName, np.Name, sCategoryID, p.CategoryID,
+		sBillable, boolField(np.Billable)))
 	if err != nil {
-		log.Printf("Database error: %s", err)
+		s.log(ctx).WithError(err).Error("Database error")
+		return p, err
+	}
+	rate := billing.RateFromData(np.Rate)
+	if err := s.setRate(ctx, key, rate); err != nil {
+		return p, err
+	}
+	r := rounding.FromData(np.Rounding)
+	if err := s.setRounding(ctx, key, r); err != nil {
 		return p, err
 	}
 
 	p.Name = np.Name
-	p.Category = np.Category
+	p.ParentID = np.ParentID
+	p.Rate = rate
+	p.Rounding = r
+	p.Billable = np.Billable
 	return p, nil
 }
 
-func (s redisStore) DeleteProject(pID, uID string) error {
-	key := fmt.Sprintf("%s:%s", sProject, pID)
-	if err := s.errIfDoesntExist(key); err != nil {
+func (s redisStore) ArchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	return s.setArchived(ctx, pID, uID, true)
+}
+
+func (s redisStore) UnarchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	return s.setArchived(ctx, pID, uID, false)
+}
+
+// setArchived flags a project and all its descendants as archived, or active, and moves them
+// to the corresponding set of user's projects.
+// A project can't be active while its parent is archived.
+func (s redisStore) setArchived(ctx context.Context, pID, uID string, archived bool) (model.Project, error) {
+	p, err := s.getProject(ctx, pID)
+	if err != nil {
+		return p, err
+	}
+	if !archived && p.ParentID != nil {
+		parent, err := s.getProject(ctx, *p.ParentID)
+		if err != nil {
+			return p, err
+		}
+		if parent.Archived {
+			return p, fmt.Errorf("parent project %s is archived", parent.ID)
+		}
+	}
+
+	descendants, err := s.descendants(ctx, pID)
+	if err != nil {
+		return p, err
+	}
+
+	from, to, flag := sProjects, sArchivedProjects, 1
+	if !archived {
+		from, to, flag = sArchivedProjects, sProjects, 0
+	}
+	from, to = fmt.Sprintf("%s:%s", from, uID), fmt.Sprintf("%s:%s", to, uID)
+
+	for _, d := range append([]model.Project{p}, descendants...) {
+		key := fmt.Sprintf("%s:%s", sProject, d.ID)
+		if _, err := redis.Int64(do(ctx, s.pool, "HSET", key, sArchived, flag)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return p, err
+		}
+		if _, err := redis.Int64(do(ctx, s.pool, "SMOVE", from, to, d.ID)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return p, err
+		}
+	}
+
+	p.Archived = archived
+	return p, nil
+}
+
+func (s redisStore) DeleteProject(ctx context.Context, pID, uID string) error {
+	p, err := s.getProject(ctx, pID)
+	if err != nil {
+		return err
+	}
+	descendants, err := s.descendants(ctx, pID)
+	if err != nil {
 		return err
 	}
 
-	if _, err := redis.Int64(s.conn.Do("DEL", key)); err != nil {
-		log.Printf("Database error: %s", err)
+	// Descendants go to the trash too, each on its own, so that they can be restored one by one.
+	// Achievements are kept until their project is purged.
+	for _, d := range append([]model.Project{p}, descendants...) {
+		if err := s.moveToTrash(ctx, sProject, d.ID, uID); err != nil {
+			return err
+		}
+		if err := s.unindexTags(ctx, sProject, d.ID); err != nil {
+			return err
+		}
+
+		for _, set := range []string{sProjects, sArchivedProjects} {
+			key := fmt.Sprintf("%s:%s", set, uID)
+			if _, err := redis.Int64(do(ctx, s.pool, "SREM", key, d.ID)); err != nil {
+				s.log(ctx).WithError(err).Error("Database error")
+				return err
+			}
+		}
+		if d.ParentID != nil {
+			if err := s.removeChild(ctx, *d.ParentID, d.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s redisStore) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	key := fmt.Sprintf("%s:%s", sAchievement, a.ID)
+	if err := s.errIfExists(ctx, key); err != nil {
 		return err
 	}
 
-	key = fmt.Sprintf("%s:%s", sProjects, uID)
-	if _, err := redis.Int64(s.conn.Do("SREM", key, pID)); err != nil {
-		log.Printf("Database error: %s", err)
+	_, err := redis.Int64(do(ctx, s.pool, "HSET", key, sUserID, a.UserID, sProjectID, a.ProjectID,
+		sStartDateTime, a.Start, sEndDateTime, a.End))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
 		return err
 	}
 
+	// Add it to project's achievements
+	key = fmt.Sprintf("%s:%s", sAchievements, a.ProjectID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, a.ID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return s.setRunning(ctx, a.ID, a.End == 0)
+}
+
+func (s redisStore) GetAchievement(ctx context.Context, aID string) (model.Achievement, error) {
+	var a model.Achievement
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return a, err
+	}
+
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return a, err
+	}
+	return achievementFromFields(aID, fields), nil
+}
+
+func (s redisStore) GetProjectAchievements(ctx context.Context, pID string) ([]model.Achievement, error) {
+	key := fmt.Sprintf("%s:%s", sAchievements, pID)
+	aIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	as := make([]model.Achievement, len(aIDs))
+	for i, aID := range aIDs {
+		a, err := s.GetAchievement(ctx, aID)
+		if err != nil {
+			return as, err
+		}
+		as[i] = a
+	}
+	return as, nil
+}
+
+func (s redisStore) GetUserAchievements(ctx context.Context, uID string) ([]model.Achievement, error) {
+	ps, err := s.GetUserProjects(ctx, uID)
+	if err != nil {
+		return nil, err
+	}
+	// Archived projects' time still counts
+	archived, err := s.GetArchivedProjects(ctx, uID)
+	if err != nil {
+		return nil, err
+	}
+
+	as := []model.Achievement{}
+	for _, p := range append(ps, archived...) {
+		pAs, err := s.GetProjectAchievements(ctx, p.ID)
+		if err != nil {
+			return as, err
+		}
+		as = append(as, pAs...)
+	}
+	return as, nil
+}
+
+func (s redisStore) UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error) {
+	a, err := s.GetAchievement(ctx, aID)
+	if err != nil {
+		return a, err
+	}
+
+	if newData.ProjectID != a.ProjectID {
+		from := fmt.Sprintf("%s:%s", sAchievements, a.ProjectID)
+		to := fmt.Sprintf("%s:%s", sAchievements, newData.ProjectID)
+		if _, err := redis.Int64(do(ctx, s.pool, "SMOVE", from, to, aID)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return a, err
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	_, err = redis.Int64(do(ctx, s.pool, "HSET", key, sProjectID, newData.ProjectID,
+		sStartDateTime, newData.Start, sEndDateTime, newData.End))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return a, err
+	}
+	if err := s.setRunning(ctx, aID, newData.End == 0); err != nil {
+		return a, err
+	}
+
+	a.ProjectID = newData.ProjectID
+	a.Start = newData.Start
+	a.End = newData.End
+	return a, nil
+}
+
+func (s redisStore) DeleteAchievement(ctx context.Context, aID, pID string) error {
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return err
+	}
+
+	uID, err := redis.String(do(ctx, s.pool, "HGET", key, sUserID))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if err := s.moveToTrash(ctx, sAchievement, aID, uID); err != nil {
+		return err
+	}
+	if err := s.unindexTags(ctx, sAchievement, aID); err != nil {
+		return err
+	}
+	if err := s.setRunning(ctx, aID, false); err != nil {
+		return err
+	}
+
+	key = fmt.Sprintf("%s:%s", sAchievements, pID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", key, aID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return nil
+}
+
+func (s redisStore) SetAchievementNote(ctx context.Context, aID, note string) error {
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return err
+	}
+	if _, err := redis.Int64(do(ctx, s.pool, "HSET", key, sNote, note)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+// liveAchievements returns the achievements aIDs, leaving out the ones whose project has been deleted,
+// which are kept until their project is purged
+func (s redisStore) liveAchievements(ctx context.Context, aIDs []string) ([]model.Achievement, error) {
+	as := make([]model.Achievement, 0, len(aIDs))
+	for _, aID := range aIDs {
+		fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", fmt.Sprintf("%s:%s", sAchievement, aID)))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return as, err
+		}
+		a := achievementFromFields(aID, fields)
+
+		exists, err := redis.Bool(do(ctx, s.pool, "EXISTS", fmt.Sprintf("%s:%s", sProject, a.ProjectID)))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return as, err
+		}
+		if exists {
+			as = append(as, a)
+		}
+	}
+	return as, nil
+}
+
+// achievementFromFields builds the achievement aID from the fields of its hash
+func achievementFromFields(aID string, fields map[string]string) model.Achievement {
+	a := model.Achievement{
+		ID:        aID,
+		UserID:    fields[sUserID],
+		ProjectID: fields[sProjectID],
+		Note:      fields[sNote],
+	}
+	if billable, ok := fields[sBillable]; ok {
+		b := billable == "1"
+		a.Billable = &b
+	}
+	if iID, ok := fields[sInvoiceID]; ok {
+		a.InvoiceID = &iID
+	}
+	a.Start, _ = strconv.Atoi(fields[sStartDateTime])
+	a.End, _ = strconv.Atoi(fields[sEndDateTime])
+	return a
+}
+
+func (s redisStore) GetSessionUser(ctx context.Context, token string) (string, error) {
+	key := fmt.Sprintf("%s:%s", sSession, token)
+	uID, err := redis.String(do(ctx, s.pool, "HGET", key, sUserID))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return "", err
+	}
+	return uID, nil
+}
+
+func (s redisStore) Ping(ctx context.Context) error {
+	if _, err := do(ctx, s.pool, "PING"); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// sSearchIndex is the RediSearch index of achievements' notes
+const sSearchIndex string = "achievementNotes"
+
+// searchLimit is the maximum number of achievements returned by a search
+const searchLimit = 1000
+
+// SearchTerms splits text into the lowercase words that notes are indexed and searched by
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// EnsureSearchIndex creates the RediSearch index of achievements' notes, unless it does already exist.
+// RediSearch keeps it up to date by itself. It returns false if the RediSearch module is not loaded.
+func EnsureSearchIndex(ctx context.Context, pool *redis.Pool, logger logrus.FieldLogger) (bool, error) {
+	s := redisStore{pool: pool, logger: logger}
+	_, err := do(ctx, s.pool, "FT.INFO", sSearchIndex)
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		return false, nil
+	}
+
+	s.log(ctx).WithField("index", sSearchIndex).Info("Creating search index")
+	_, err = do(ctx, s.pool, "FT.CREATE", sSearchIndex, "ON", "HASH", "PREFIX", 1, sAchievement+":",
+		"SCHEMA", sNote, "TEXT", sUserID, "TAG")
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return false, err
+	}
+	return true, nil
+}
+
+// escapeTag escapes the punctuation of a RediSearch tag value, like the dashes of UUIDs
+func escapeTag(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s redisStore) SearchAchievements(ctx context.Context, uID, text string) ([]model.Achievement, error) {
+	terms := SearchTerms(text)
+	if len(terms) == 0 {
+		return []model.Achievement{}, nil
+	}
+
+	query := fmt.Sprintf("@%s:{%s} @%s:(%s)", sUserID, escapeTag(uID), sNote, strings.Join(terms, " "))
+	values, err := redis.Values(do(ctx, s.pool, "FT.SEARCH", sSearchIndex, query, "NOCONTENT", "LIMIT", 0, searchLimit))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	// The total number of results followed by their keys
+	if len(values) == 0 {
+		return []model.Achievement{}, nil
+	}
+	keys, err := redis.Strings(values[1:], nil)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	aIDs := make([]string, len(keys))
+	for i, key := range keys {
+		aIDs[i] = strings.TrimPrefix(key, sAchievement+":")
+	}
+	return s.liveAchievements(ctx, aIDs)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"fixed", "the", "1", "1", "ci", "build", "señal"}, SearchTerms("Fixed the 1:1 *CI* build — señal"))
+	assert.Empty(t, SearchTerms(" ¡! "))
+}
+
+func TestEnsureSearchIndexWithoutRediSearch(t *testing.T) {
+	_, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+
+	available, err := EnsureSearchIndex(context.Background(), pool, logger)
+
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestSetAchievementNote(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	a := model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: "3b054f50-9d3d-4114-bfc4-000000000001"}
+	require.NoError(t, s.CreateAchievement(ctx, a))
+
+	assert.NoError(t, s.SetAchievementNote(ctx, a.ID, "Sprint planning"))
+	assert.Error(t, s.SetAchievementNote(ctx, "9a6f1c1e-4b1e-4d0e-8d9b-000000000002", "Sprint planning"))
+
+	actual, err := s.GetAchievement(ctx, a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sprint planning", actual.Note)
+}
@@ -2,20 +2,119 @@
 
 package storage
 
-import "github.com/smeruelo/glow/graph/model"
+import (
+	"context"
+
+	"github.com/smeruelo/glow/graph/model"
+)
 
 // Store defines the interface for projects storage
 type Store interface {
-	CreateProject(p model.Project) error
-	GetProject(pID string) (model.Project, error)
-	GetUserProjects(uID string) ([]model.Project, error)
-	UpdateProject(pID string, np model.NewProject) (model.Project, error)
-	DeleteProject(pID, uID string) error
-
-	CreateAchievement(a model.Achievement) error
-	GetAchievement(aID string) (model.Achievement, error)
-	GetProjectAchievements(pID string) ([]model.Achievement, error)
-	GetUserAchievements(uID string) ([]model.Achievement, error)
-	UpdateAchievement(aID, newData model.AchievementData) (model.Achievement, error)
-	DeleteAchievement(aID, pID string) error
+	CreateProject(ctx context.Context, p model.Project) error
+	GetProject(ctx context.Context, pID string) (model.Project, error)
+	// GetUserProjects returns the active projects of a user
+	GetUserProjects(ctx context.Context, uID string) ([]model.Project, error)
+	GetArchivedProjects(ctx context.Context, uID string) ([]model.Project, error)
+	// GetChildProjects returns the subprojects of a project, archived ones included
+	GetChildProjects(ctx context.Context, pID string) ([]model.Project, error)
+	// UpdateProject fails if the new parent is the project itself or one of its descendants
+	UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error)
+	// ArchiveProject archives a project and all its descendants
+	ArchiveProject(ctx context.Context, pID, uID string) (model.Project, error)
+	// UnarchiveProject unarchives a project and all its descendants. It fails if the parent is archived.
+	UnarchiveProject(ctx context.Context, pID, uID string) (model.Project, error)
+	// DeleteProject moves a project and all its descendants to the trash, from where they can be restored
+	// until they're purged
+	DeleteProject(ctx context.Context, pID, uID string) error
+
+	// CreateCategory fails if the user has another category with the same name, ignoring case and surrounding spaces
+	CreateCategory(ctx context.Context, c model.Category) error
+	GetCategory(ctx context.Context, cID string) (model.Category, error)
+	// GetUserCategories returns the categories of a user sorted by their order
+	GetUserCategories(ctx context.Context, uID string) ([]model.Category, error)
+	UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error)
+	// DeleteCategory fails if any project of the user, archived and deleted ones included, belongs to the category
+	DeleteCategory(ctx context.Context, cID, uID string) error
+
+	// CreateTag fails if the user has another tag with the same name, ignoring case and surrounding spaces
+	CreateTag(ctx context.Context, t model.Tag) error
+	GetTag(ctx context.Context, tID string) (model.Tag, error)
+	// GetUserTags returns the tags of a user sorted by name
+	GetUserTags(ctx context.Context, uID string) ([]model.Tag, error)
+	UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error)
+	// DeleteTag removes the tag from every project and achievement
+	DeleteTag(ctx context.Context, tID, uID string) error
+	TagProject(ctx context.Context, pID, tID string) error
+	UntagProject(ctx context.Context, pID, tID string) error
+	// GetProjectTags returns the tags of a project sorted by name
+	GetProjectTags(ctx context.Context, pID string) ([]model.Tag, error)
+	TagAchievement(ctx context.Context, aID, tID string) error
+	UntagAchievement(ctx context.Context, aID, tID string) error
+	// GetAchievementTags returns the tags of an achievement sorted by name
+	GetAchievementTags(ctx context.Context, aID string) ([]model.Tag, error)
+	// GetTaggedAchievements returns the achievements that have all the given tags, without going through
+	// the achievements that don't
+	GetTaggedAchievements(ctx context.Context, tagIDs []string) ([]model.Achievement, error)
+
+	CreateAchievement(ctx context.Context, a model.Achievement) error
+	GetAchievement(ctx context.Context, aID string) (model.Achievement, error)
+	GetProjectAchievements(ctx context.Context, pID string) ([]model.Achievement, error)
+	// GetUserAchievements returns the achievements of all the projects of a user, archived ones included
+	GetUserAchievements(ctx context.Context, uID string) ([]model.Achievement, error)
+	UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error)
+	SetAchievementNote(ctx context.Context, aID, note string) error
+	// SetAchievementBillable overrides whether an achievement is billable. A nil billable makes it follow its project.
+	SetAchievementBillable(ctx context.Context, aID string, billable *bool) error
+	// SearchAchievements returns the achievements of a user whose note has all the words in text
+	SearchAchievements(ctx context.Context, uID, text string) ([]model.Achievement, error)
+	// DeleteAchievement moves an achievement to the trash, from where it can be restored until it's purged
+	DeleteAchievement(ctx context.Context, aID, pID string) error
+
+	// GetTrash returns the projects and achievements deleted by a user, oldest first
+	GetTrash(ctx context.Context, uID string) ([]model.TrashItem, error)
+	// GetExpiredTrash returns the projects and achievements of every user deleted before the given Unix timestamp
+	GetExpiredTrash(ctx context.Context, before int) ([]model.TrashItem, error)
+	// RestoreProject fails if the project's parent is not restored first. Subprojects are restored one by one.
+	RestoreProject(ctx context.Context, pID, uID string) (model.Project, error)
+	// RestoreAchievement fails if the achievement's project is not restored first
+	RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error)
+	// PurgeProject permanently removes a deleted project, all its achievements and its deleted subprojects.
+	// It fails, removing nothing, if any of those achievements has been invoiced.
+	PurgeProject(ctx context.Context, pID, uID string) error
+	// PurgeAchievement permanently removes a deleted achievement, unless it has been invoiced
+	PurgeAchievement(ctx context.Context, aID, uID string) error
+
+	// GetUsers returns the IDs of every user with projects, categories, tags, invoices or settings, sorted
+	GetUsers(ctx context.Context) ([]string, error)
+	// GetUserRate returns the hourly rate of the projects of a user that have none, nil if there's none
+	GetUserRate(ctx context.Context, uID string) (*model.Money, error)
+	// SetUserRate sets the hourly rate of a user, or removes it if it's nil
+	SetUserRate(ctx context.Context, uID string, rate *model.Money) error
+	// GetUserRounding returns the rounding rule of the projects of a user that have none, nil if there's none
+	GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error)
+	// SetUserRounding sets the rounding rule of a user, or removes it if it's nil
+	SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error
+
+	// CreateInvoice gives the invoice the next number of its user, and locks its achievements.
+	// It fails if any of them is already invoiced.
+	CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error)
+	GetInvoice(ctx context.Context, iID string) (model.Invoice, error)
+	// GetUserInvoices returns the invoices of a user sorted by number
+	GetUserInvoices(ctx context.Context, uID string) ([]model.Invoice, error)
+
+	// CreateExportToken stores a token that grants the download of an export until it expires
+	CreateExportToken(ctx context.Context, t model.ExportToken) error
+	// GetExportToken fails if the token doesn't exist or has expired
+	GetExportToken(ctx context.Context, token string) (model.ExportToken, error)
+
+	// AppendAuditEntry adds e to the audit log of its entity. Entries are never modified nor deleted.
+	AppendAuditEntry(ctx context.Context, e model.AuditEntry) error
+	// GetAuditLog returns the audit log of an entity between the from and to Unix timestamps, both included,
+	// in chronological order. A to of 0 means up to now.
+	GetAuditLog(ctx context.Context, entityID string, from, to int) ([]model.AuditEntry, error)
+
+	// GetSessionUser returns the user logged in with token, or "" if there's no such session
+	GetSessionUser(ctx context.Context, token string) (string, error)
+
+	Ping(ctx context.Context) error
 }
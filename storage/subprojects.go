@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// sameID tells whether two optional IDs are the same, both nil included
+func sameID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s redisStore) addChild(ctx context.Context, parentID, pID string) error {
+	key := fmt.Sprintf("%s:%s", sChildren, parentID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, pID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) removeChild(ctx context.Context, parentID, pID string) error {
+	key := fmt.Sprintf("%s:%s", sChildren, parentID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", key, pID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) GetChildProjects(ctx context.Context, pID string) ([]model.Project, error) {
+	return s.getProjects(ctx, fmt.Sprintf("%s:%s", sChildren, pID))
+}
+
+// descendants returns the children of a project, the children of its children and so on, parents first
+func (s redisStore) descendants(ctx context.Context, pID string) ([]model.Project, error) {
+	var ds []model.Project
+	for pending := []string{pID}; len(pending) > 0; pending = pending[1:] {
+		children, err := s.GetChildProjects(ctx, pending[0])
+		if err != nil {
+			return ds, err
+		}
+		for _, c := range children {
+			ds = append(ds, c)
+			pending = append(pending, c.ID)
+		}
+	}
+	return ds, nil
+}
+
+// setParent moves project p under parentID, or to the top level if it's nil.
+// It fails if parentID is p itself or one of its descendants.
+func (s redisStore) setParent(ctx context.Context, p model.Project, parentID *string) error {
+	key := fmt.Sprintf("%s:%s", sProject, p.ID)
+	if parentID == nil {
+		if _, err := redis.Int64(do(ctx, s.pool, "HDEL", key, sParentID)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	} else {
+		// Walk up from the new parent looking for p
+		for id := parentID; id != nil; {
+			if *id == p.ID {
+				return fmt.Errorf("project %s can't be a subproject of itself or of its subprojects", p.ID)
+			}
+			ancestor, err := s.getProject(ctx, *id)
+			if err != nil {
+				return err
+			}
+			id = ancestor.ParentID
+		}
+
+		if _, err := redis.Int64(do(ctx, s.pool, "HSET", key, sParentID, *parentID)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if err := s.addChild(ctx, *parentID, p.ID); err != nil {
+			return err
+		}
+	}
+
+	if p.ParentID != nil {
+		return s.removeChild(ctx, *p.ParentID, p.ID)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	uID = "e0b4e4b5-6d5f-4a3c-9b3a-1f6d3c2e9a10"
+	cID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+)
+
+func newTestStore(t *testing.T) Store {
+	_, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	return NewRedisStore(pool, logger)
+}
+
+// newTestRedis starts an in-memory Redis for tests that set up data or call functions without a store
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Pool) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	pool := NewPool(mr.Addr(), "", 0, 10)
+	t.Cleanup(func() { pool.Close() })
+	return mr, pool
+}
+
+// createTree creates the projects Client X > Website > Backend
+func createTree(t *testing.T, s Store) (x, website, backend model.Project) {
+	ctx := context.Background()
+	x = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "Client X", CategoryID: cID}
+	website = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000002", UserID: uID, Name: "Website", CategoryID: cID, ParentID: &x.ID}
+	backend = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000003", UserID: uID, Name: "Backend", CategoryID: cID, ParentID: &website.ID}
+	for _, p := range []model.Project{x, website, backend} {
+		require.NoError(t, s.CreateProject(ctx, p))
+	}
+	return x, website, backend
+}
+
+func projectIDs(ps []model.Project) []string {
+	ids := make([]string, len(ps))
+	for i, p := range ps {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestChildProjects(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, backend := createTree(t, s)
+
+	children, err := s.GetChildProjects(ctx, x.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Project{website}, children)
+
+	p, err := s.GetProject(ctx, backend.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, backend, p)
+
+	p, err = s.GetProject(ctx, x.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, p.ParentID)
+}
+
+func TestUpdateProjectRejectsCycles(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, backend := createTree(t, s)
+
+	_, err := s.UpdateProject(ctx, x.ID, model.NewProject{Name: x.Name, ParentID: &backend.ID})
+	assert.Error(t, err)
+	_, err = s.UpdateProject(ctx, x.ID, model.NewProject{Name: x.Name, ParentID: &x.ID})
+	assert.Error(t, err)
+
+	p, err := s.GetProject(ctx, x.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, p.ParentID)
+	children, err := s.GetChildProjects(ctx, backend.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, children)
+
+	// Moving Backend to the top level
+	p, err = s.UpdateProject(ctx, backend.ID, model.NewProject{Name: backend.Name})
+	assert.NoError(t, err)
+	assert.Nil(t, p.ParentID)
+	children, err = s.GetChildProjects(ctx, website.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, children)
+}
+
+func TestArchiveProjectCascades(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, backend := createTree(t, s)
+
+	_, err := s.ArchiveProject(ctx, x.ID, uID)
+	assert.NoError(t, err)
+
+	active, err := s.GetUserProjects(ctx, uID)
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+	archived, err := s.GetArchivedProjects(ctx, uID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{x.ID, website.ID, backend.ID}, projectIDs(archived))
+
+	// A subproject can't be active while its parent is archived
+	_, err = s.UnarchiveProject(ctx, website.ID, uID)
+	assert.EqualError(t, err, "parent project "+x.ID+" is archived")
+
+	_, err = s.UnarchiveProject(ctx, x.ID, uID)
+	assert.NoError(t, err)
+	active, err = s.GetUserProjects(ctx, uID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{x.ID, website.ID, backend.ID}, projectIDs(active))
+}
+
+func TestDeleteProjectCascades(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, backend := createTree(t, s)
+
+	assert.NoError(t, s.DeleteProject(ctx, website.ID, uID))
+
+	active, err := s.GetUserProjects(ctx, uID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{x.ID}, projectIDs(active))
+	children, err := s.GetChildProjects(ctx, x.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, children)
+	items, err := s.GetTrash(ctx, uID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	// Subprojects are restored after their parent
+	_, err = s.RestoreProject(ctx, backend.ID, uID)
+	assert.EqualError(t, err, "project "+website.ID+" must be restored first")
+
+	_, err = s.RestoreProject(ctx, website.ID, uID)
+	assert.NoError(t, err)
+	_, err = s.RestoreProject(ctx, backend.ID, uID)
+	assert.NoError(t, err)
+
+	children, err = s.GetChildProjects(ctx, website.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Project{backend}, children)
+	items, err = s.GetTrash(ctx, uID)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestPurgeProjectCascades(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, _ := createTree(t, s)
+
+	assert.NoError(t, s.DeleteProject(ctx, x.ID, uID))
+	assert.NoError(t, s.PurgeProject(ctx, x.ID, uID))
+
+	items, err := s.GetTrash(ctx, uID)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	_, err = s.RestoreProject(ctx, website.ID, uID)
+	assert.Error(t, err)
+}
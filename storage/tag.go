@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Tags' keys and fields
+const (
+	sTag      string = "tag"
+	sTagged   string = "tagged"
+	sTagNames string = "tagNames"
+	sTags     string = "tags"
+)
+
+// tagsKey returns the key of the set of tags of an entity, e.g. achievementTags:<achievementID>
+func tagsKey(prefix, id string) string {
+	return fmt.Sprintf("%sTags:%s", prefix, id)
+}
+
+// taggedKey returns the key of the reverse index of a tag, e.g. tagged:achievement:<tagID>
+func taggedKey(prefix, tID string) string {
+	return fmt.Sprintf("%s:%s:%s", sTagged, prefix, tID)
+}
+
+// taggable are the prefixes of the entities that can be tagged
+var taggable = []string{sProject, sAchievement}
+
+// tagFromFields builds the tag tID from the fields of its hash
+func tagFromFields(tID string, fields map[string]string) model.Tag {
+	return model.Tag{
+		ID:     tID,
+		UserID: fields[sUserID],
+		Name:   fields[sName],
+		Color:  fields[sColor],
+	}
+}
+
+func (s redisStore) CreateTag(ctx context.Context, t model.Tag) error {
+	key := fmt.Sprintf("%s:%s", sTag, t.ID)
+	if err := s.errIfExists(ctx, key); err != nil {
+		return err
+	}
+	if err := s.reserveName(ctx, fmt.Sprintf("%s:%s", sTagNames, t.UserID), "tag", t.ID, t.Name); err != nil {
+		return err
+	}
+
+	_, err := redis.Int64(do(ctx, s.pool, "HSET", key, sUserID, t.UserID, sName, t.Name, sColor, t.Color))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	key = fmt.Sprintf("%s:%s", sTags, t.UserID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, t.ID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return nil
+}
+
+func (s redisStore) GetTag(ctx context.Context, tID string) (model.Tag, error) {
+	key := fmt.Sprintf("%s:%s", sTag, tID)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return model.Tag{}, err
+	}
+
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return model.Tag{}, err
+	}
+	return tagFromFields(tID, fields), nil
+}
+
+// getTags returns the tags whose IDs are in the set key, sorted by name
+func (s redisStore) getTags(ctx context.Context, key string) ([]model.Tag, error) {
+	tagIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+
+	ts := make([]model.Tag, len(tagIDs))
+	for i, tID := range tagIDs {
+		t, err := s.GetTag(ctx, tID)
+		if err != nil {
+			return ts, err
+		}
+		ts[i] = t
+	}
+	sort.Slice(ts, func(i, j int) bool { return NameKey(ts[i].Name) < NameKey(ts[j].Name) })
+	return ts, nil
+}
+
+func (s redisStore) GetUserTags(ctx context.Context, uID string) ([]model.Tag, error) {
+	return s.getTags(ctx, fmt.Sprintf("%s:%s", sTags, uID))
+}
+
+func (s redisStore) UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error) {
+	t, err := s.GetTag(ctx, tID)
+	if err != nil {
+		return t, err
+	}
+
+	if NameKey(nt.Name) != NameKey(t.Name) {
+		key := fmt.Sprintf("%s:%s", sTagNames, t.UserID)
+		if err := s.reserveName(ctx, key, "tag", tID, nt.Name); err != nil {
+			return t, err
+		}
+		if _, err := redis.Int64(do(ctx, s.pool, "HDEL", key, NameKey(t.Name))); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return t, err
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sTag, tID)
+	if _, err := redis.Int64(do(ctx, s.pool, "HSET", key, sName, nt.Name, sColor, nt.Color)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return t, err
+	}
+
+	t.Name, t.Color = nt.Name, nt.Color
+	return t, nil
+}
+
+func (s redisStore) DeleteTag(ctx context.Context, tID, uID string) error {
+	t, err := s.GetTag(ctx, tID)
+	if err != nil {
+		return err
+	}
+	if t.UserID != uID {
+		return fmt.Errorf("tag %s does not exist", tID)
+	}
+
+	// Untag every entity. Deleted ones lose the tag when they're restored.
+	for _, prefix := range taggable {
+		key := taggedKey(prefix, tID)
+		ids, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		for _, id := range ids {
+			if _, err := redis.Int64(do(ctx, s.pool, "SREM", tagsKey(prefix, id), tID)); err != nil {
+				s.log(ctx).WithError(err).Error("Database error")
+				return err
+			}
+		}
+		if _, err := redis.Int64(do(ctx, s.pool, "DEL", key)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sTag, tID)
+	if _, err := redis.Int64(do(ctx, s.pool, "DEL", key)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	key = fmt.Sprintf("%s:%s", sTags, uID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", key, tID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	key = fmt.Sprintf("%s:%s", sTagNames, uID)
+	if _, err := redis.Int64(do(ctx, s.pool, "HDEL", key, NameKey(t.Name))); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	return nil
+}
+
+// tag adds the tag tID to the entity prefix:id, and the entity to the tag's reverse index
+func (s redisStore) tag(ctx context.Context, prefix, id, tID string) error {
+	if err := s.errIfDoesntExist(ctx, fmt.Sprintf("%s:%s", prefix, id)); err != nil {
+		return err
+	}
+	if err := s.errIfDoesntExist(ctx, fmt.Sprintf("%s:%s", sTag, tID)); err != nil {
+		return err
+	}
+
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", tagsKey(prefix, id), tID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", taggedKey(prefix, tID), id)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+// untag removes the tag tID from the entity prefix:id, and the entity from the tag's reverse index
+func (s redisStore) untag(ctx context.Context, prefix, id, tID string) error {
+	if err := s.errIfDoesntExist(ctx, fmt.Sprintf("%s:%s", prefix, id)); err != nil {
+		return err
+	}
+
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", tagsKey(prefix, id), tID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if _, err := redis.Int64(do(ctx, s.pool, "SREM", taggedKey(prefix, tID), id)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+// unindexTags removes a deleted entity from the reverse indexes of its tags, which it keeps
+// in case it's restored
+func (s redisStore) unindexTags(ctx context.Context, prefix, id string) error {
+	tagIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", tagsKey(prefix, id)))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	for _, tID := range tagIDs {
+		if _, err := redis.Int64(do(ctx, s.pool, "SREM", taggedKey(prefix, tID), id)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// reindexTags adds a restored entity back to the reverse indexes of its tags,
+// and drops the tags deleted in the meantime
+func (s redisStore) reindexTags(ctx context.Context, prefix, id string) error {
+	tagIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", tagsKey(prefix, id)))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	for _, tID := range tagIDs {
+		exists, err := redis.Bool(do(ctx, s.pool, "EXISTS", fmt.Sprintf("%s:%s", sTag, tID)))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		if exists {
+			_, err = redis.Int64(do(ctx, s.pool, "SADD", taggedKey(prefix, tID), id))
+		} else {
+			_, err = redis.Int64(do(ctx, s.pool, "SREM", tagsKey(prefix, id), tID))
+		}
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeTags removes the tags of an entity that is being permanently removed
+func (s redisStore) purgeTags(ctx context.Context, prefix, id string) error {
+	if err := s.unindexTags(ctx, prefix, id); err != nil {
+		return err
+	}
+	if _, err := redis.Int64(do(ctx, s.pool, "DEL", tagsKey(prefix, id))); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
+
+func (s redisStore) TagAchievement(ctx context.Context, aID, tID string) error {
+	return s.tag(ctx, sAchievement, aID, tID)
+}
+
+func (s redisStore) UntagAchievement(ctx context.Context, aID, tID string) error {
+	return s.untag(ctx, sAchievement, aID, tID)
+}
+
+func (s redisStore) GetAchievementTags(ctx context.Context, aID string) ([]model.Tag, error) {
+	return s.getTags(ctx, tagsKey(sAchievement, aID))
+}
+
+func (s redisStore) GetTaggedAchievements(ctx context.Context, tagIDs []string) ([]model.Achievement, error) {
+	if len(tagIDs) == 0 {
+		return []model.Achievement{}, nil
+	}
+	keys := make([]interface{}, len(tagIDs))
+	for i, tID := range tagIDs {
+		keys[i] = taggedKey(sAchievement, tID)
+	}
+	achievementIDs, err := redis.Strings(do(ctx, s.pool, "SINTER", keys...))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	return s.liveAchievements(ctx, achievementIDs)
+}
+
+func (s redisStore) TagProject(ctx context.Context, pID, tID string) error {
+	return s.tag(ctx, sProject, pID, tID)
+}
+
+func (s redisStore) UntagProject(ctx context.Context, pID, tID string) error {
+	return s.untag(ctx, sProject, pID, tID)
+}
+
+func (s redisStore) GetProjectTags(ctx context.Context, pID string) ([]model.Tag, error) {
+	return s.getTags(ctx, tagsKey(sProject, pID))
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	meetingID = "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5"
+	reviewID  = "0f8b2b9e-3c1d-4e5f-a6b7-c8d9e0f1a2b3"
+)
+
+// createTagged creates a project with two achievements, the first one tagged meeting and review,
+// and the second one tagged meeting
+func createTagged(t *testing.T, s Store) (p model.Project, a1, a2 model.Achievement) {
+	ctx := context.Background()
+	p = model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "glow", CategoryID: cID}
+	a1 = model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000001", UserID: uID, ProjectID: p.ID, Start: 1600000000, End: 1600003600}
+	a2 = model.Achievement{ID: "9a6f1c1e-4b1e-4d0e-8d9b-000000000002", UserID: uID, ProjectID: p.ID, Start: 1600010000, End: 1600011800}
+	require.NoError(t, s.CreateProject(ctx, p))
+	require.NoError(t, s.CreateAchievement(ctx, a1))
+	require.NoError(t, s.CreateAchievement(ctx, a2))
+
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: meetingID, UserID: uID, Name: "meeting"}))
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: reviewID, UserID: uID, Name: "review"}))
+	require.NoError(t, s.TagAchievement(ctx, a1.ID, meetingID))
+	require.NoError(t, s.TagAchievement(ctx, a1.ID, reviewID))
+	require.NoError(t, s.TagAchievement(ctx, a2.ID, meetingID))
+	return p, a1, a2
+}
+
+func achievementIDs(as []model.Achievement) []string {
+	ids := make([]string, len(as))
+	for i, a := range as {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func TestTagNamesAreUnique(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: meetingID, UserID: uID, Name: "Meeting"}))
+	err := s.CreateTag(ctx, model.Tag{ID: reviewID, UserID: uID, Name: " meeting"})
+	assert.EqualError(t, err, `tag "meeting" does already exist`)
+
+	_, err = s.UpdateTag(ctx, meetingID, model.NewTag{Name: "Meetings"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.CreateTag(ctx, model.Tag{ID: reviewID, UserID: uID, Name: "meeting"}))
+
+	ts, err := s.GetUserTags(ctx, uID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{
+		{ID: reviewID, UserID: uID, Name: "meeting"},
+		{ID: meetingID, UserID: uID, Name: "Meetings"},
+	}, ts)
+}
+
+func TestTaggedAchievements(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	_, a1, a2 := createTagged(t, s)
+
+	as, err := s.GetTaggedAchievements(ctx, []string{meetingID})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{a1.ID, a2.ID}, achievementIDs(as))
+
+	as, err = s.GetTaggedAchievements(ctx, []string{meetingID, reviewID})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Achievement{a1}, as)
+
+	assert.NoError(t, s.UntagAchievement(ctx, a1.ID, reviewID))
+	as, err = s.GetTaggedAchievements(ctx, []string{meetingID, reviewID})
+	assert.NoError(t, err)
+	assert.Empty(t, as)
+
+	ts, err := s.GetAchievementTags(ctx, a1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{{ID: meetingID, UserID: uID, Name: "meeting"}}, ts)
+}
+
+func TestTagsOfDeletedAchievements(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	p, a1, a2 := createTagged(t, s)
+	require.NoError(t, s.TagAchievement(ctx, a2.ID, reviewID))
+
+	assert.NoError(t, s.DeleteAchievement(ctx, a2.ID, p.ID))
+	as, err := s.GetTaggedAchievements(ctx, []string{meetingID})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Achievement{a1}, as)
+
+	// Tags deleted while the achievement is in the trash are dropped when it's restored
+	assert.NoError(t, s.DeleteTag(ctx, reviewID, uID))
+	_, err = s.RestoreAchievement(ctx, a2.ID, uID)
+	assert.NoError(t, err)
+	as, err = s.GetTaggedAchievements(ctx, []string{meetingID})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{a1.ID, a2.ID}, achievementIDs(as))
+
+	ts, err := s.GetAchievementTags(ctx, a2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{{ID: meetingID, UserID: uID, Name: "meeting"}}, ts)
+
+	// Achievements of deleted projects are left out
+	assert.NoError(t, s.DeleteProject(ctx, p.ID, uID))
+	as, err = s.GetTaggedAchievements(ctx, []string{meetingID})
+	assert.NoError(t, err)
+	assert.Empty(t, as)
+}
+
+func TestProjectTags(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	x, website, _ := createTree(t, s)
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: reviewID, UserID: uID, Name: "review"}))
+
+	assert.NoError(t, s.TagProject(ctx, x.ID, reviewID))
+	assert.NoError(t, s.TagProject(ctx, website.ID, reviewID))
+	assert.Error(t, s.TagProject(ctx, website.ID, meetingID))
+
+	assert.NoError(t, s.DeleteTag(ctx, reviewID, uID))
+	for _, pID := range []string{x.ID, website.ID} {
+		ts, err := s.GetProjectTags(ctx, pID)
+		assert.NoError(t, err)
+		assert.Empty(t, ts)
+	}
+}
+
+func TestDeleteOtherUsersTag(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: meetingID, UserID: uID, Name: "meeting"}))
+
+	assert.EqualError(t, s.DeleteTag(ctx, meetingID, "1"), "tag "+meetingID+" does not exist")
+	_, err := s.GetTag(ctx, meetingID)
+	assert.NoError(t, err)
+}
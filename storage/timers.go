@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// RunningTimers returns the number of achievements whose timer is still running, of every user.
+// It's a single call to Redis, so that it's cheap enough to run on every metrics scrape.
+func RunningTimers(ctx context.Context, pool *redis.Pool, logger logrus.FieldLogger) (int, error) {
+	s := redisStore{pool: pool, logger: logger}
+	n, err := redis.Int(do(ctx, s.pool, "SCARD", sRunningTimers))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return 0, err
+	}
+	return n, nil
+}
+
+// setRunning adds the achievement aID to the running timers, or removes it if it's not running
+func (s redisStore) setRunning(ctx context.Context, aID string, running bool) error {
+	cmd := "SREM"
+	if running {
+		cmd = "SADD"
+	}
+	if _, err := do(ctx, s.pool, cmd, sRunningTimers, aID); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningTimers(t *testing.T) {
+	_, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	s := NewRedisStore(pool, logger)
+	ctx := context.Background()
+	p := model.Project{ID: "3b054f50-9d3d-4114-bfc4-000000000001", UserID: uID, Name: "glow", CategoryID: cID}
+	require.NoError(t, s.CreateProject(ctx, p))
+	running := model.Achievement{ID: "3b054f50-9d3d-4114-bfc4-395f70a00001", UserID: uID, ProjectID: p.ID, Start: 1598341158}
+	stopped := model.Achievement{ID: "3b054f50-9d3d-4114-bfc4-395f70a00002", UserID: uID, ProjectID: p.ID, Start: 1598341158, End: 1598342861}
+	require.NoError(t, s.CreateAchievement(ctx, running))
+	require.NoError(t, s.CreateAchievement(ctx, stopped))
+
+	n, err := RunningTimers(ctx, pool, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Stopped
+	_, err = s.UpdateAchievement(ctx, running.ID, model.AchievementData{ProjectID: p.ID, Start: running.Start, End: 1598342900})
+	require.NoError(t, err)
+	n, err = RunningTimers(ctx, pool, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// Restarted, deleted and restored
+	_, err = s.UpdateAchievement(ctx, running.ID, model.AchievementData{ProjectID: p.ID, Start: running.Start})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteAchievement(ctx, running.ID, p.ID))
+	n, err = RunningTimers(ctx, pool, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	_, err = s.RestoreAchievement(ctx, running.ID, uID)
+	require.NoError(t, err)
+	n, err = RunningTimers(ctx, pool, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestMigrateRunningTimers(t *testing.T) {
+	mr, pool := newTestRedis(t)
+	logger, _ := test.NewNullLogger()
+	ctx := context.Background()
+	// As stored before running timers were kept
+	mr.HSet("achievement:3b054f50-9d3d-4114-bfc4-395f70a00001", "userID", uID, "startDateTime", "1598341158", "endDateTime", "0")
+	mr.HSet("achievement:3b054f50-9d3d-4114-bfc4-395f70a00002", "userID", uID, "startDateTime", "1598341158", "endDateTime", "1598342861")
+
+	require.NoError(t, Migrate(ctx, pool, logger))
+	n, err := RunningTimers(ctx, pool, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// keyPatternKey is the span attribute holding the pattern of the key accessed by a command
+const keyPatternKey = label.Key("db.redis.key_pattern")
+
+// do sends a command to Redis within a span, child of the span in ctx, on a connection of pool.
+// Commands sent outside of a trace get no span.
+// The span records the command and the pattern of the key it accesses, e.g. project:*,
+// never the IDs nor the values.
+func do(ctx context.Context, pool *redis.Pool, cmd string, args ...interface{}) (interface{}, error) {
+	attrs := []label.KeyValue{semconv.DBSystemRedis, semconv.DBOperationKey.String(cmd)}
+	// Scripts' first key comes after the script and the number of keys
+	keyArg := 0
+	if cmd == "EVAL" || cmd == "EVALSHA" {
+		keyArg = 2
+	}
+	if len(args) > keyArg {
+		if key, ok := args[keyArg].(string); ok {
+			attrs = append(attrs, keyPatternKey.String(keyPattern(key)))
+		}
+	}
+
+	ctx, span := trace.SpanFromContext(ctx).Tracer().Start(ctx, "redis "+cmd,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	conn, err := pool.GetContext(ctx)
+	if err != nil {
+		span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+		return nil, err
+	}
+	defer conn.Close()
+
+	reply, err := conn.Do(cmd, args...)
+	if err != nil && err != redis.ErrNil {
+		span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+	}
+	return reply, err
+}
+
+// keyPattern replaces the ID in key with *
+func keyPattern(key string) string {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i+1] + "*"
+	}
+	return key
+}
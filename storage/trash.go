@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/smeruelo/glow/graph/model"
+)
+
+// Trash keys
+const (
+	sDeleted string = "deleted"
+	sTrash   string = "trash"
+)
+
+// Entity types, as named in the audit log and the trash
+const (
+	EntityProject     string = "Project"
+	EntityAchievement string = "Achievement"
+	EntityCategory    string = "Category"
+	EntityTag         string = "Tag"
+	EntityInvoice     string = "Invoice"
+	EntityUser        string = "User"
+)
+
+// moveToTrash renames the hash of an entity so that it's no longer found, and adds it to the trash
+// of its user and to the global one, used to find the expired items.
+func (s redisStore) moveToTrash(ctx context.Context, prefix, id, uID string) error {
+	member := fmt.Sprintf("%s:%s", prefix, id)
+	if _, err := do(ctx, s.pool, "RENAME", member, fmt.Sprintf("%s:%s", sDeleted, member)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	now := time.Now().Unix()
+	for _, key := range []string{sTrash, fmt.Sprintf("%s:%s", sTrash, uID)} {
+		if _, err := do(ctx, s.pool, "ZADD", key, now, member); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// removeFromTrash removes an entity from the trash of its user and from the global one
+func (s redisStore) removeFromTrash(ctx context.Context, prefix, id, uID string) error {
+	member := fmt.Sprintf("%s:%s", prefix, id)
+	for _, key := range []string{sTrash, fmt.Sprintf("%s:%s", sTrash, uID)} {
+		if _, err := do(ctx, s.pool, "ZREM", key, member); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+	}
+	return nil
+}
+
+// getDeleted returns the fields of an entity in the trash of user uID
+func (s redisStore) getDeleted(ctx context.Context, prefix, id, uID string) (map[string]string, error) {
+	key := fmt.Sprintf("%s:%s:%s", sDeleted, prefix, id)
+	if err := s.errIfDoesntExist(ctx, key); err != nil {
+		return nil, err
+	}
+
+	fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	if fields[sUserID] != uID {
+		s.log(ctx).WithField("key", key).Debug("Key belongs to another user")
+		return nil, fmt.Errorf("Key %s does not exist", key)
+	}
+	return fields, nil
+}
+
+// trashItems builds the items of a trash from the members and scores of its sorted set
+func (s redisStore) trashItems(ctx context.Context, values []string) ([]model.TrashItem, error) {
+	items := make([]model.TrashItem, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		parts := strings.SplitN(values[i], ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		prefix, id := parts[0], parts[1]
+
+		fields, err := redis.StringMap(do(ctx, s.pool, "HGETALL", fmt.Sprintf("%s:%s", sDeleted, values[i])))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return items, err
+		}
+
+		item := model.TrashItem{ID: id}
+		item.DeletedAt, _ = strconv.Atoi(values[i+1])
+		switch prefix {
+		case sProject:
+			item.EntityType = EntityProject
+			p := projectFromFields(id, fields)
+			item.Project = &p
+		case sAchievement:
+			item.EntityType = EntityAchievement
+			a := achievementFromFields(id, fields)
+			item.Achievement = &a
+		default:
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s redisStore) GetTrash(ctx context.Context, uID string) ([]model.TrashItem, error) {
+	key := fmt.Sprintf("%s:%s", sTrash, uID)
+	values, err := redis.Strings(do(ctx, s.pool, "ZRANGE", key, 0, -1, "WITHSCORES"))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	return s.trashItems(ctx, values)
+}
+
+func (s redisStore) GetExpiredTrash(ctx context.Context, before int) ([]model.TrashItem, error) {
+	values, err := redis.Strings(do(ctx, s.pool, "ZRANGEBYSCORE", sTrash, "-inf", fmt.Sprintf("(%d", before), "WITHSCORES"))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return nil, err
+	}
+	return s.trashItems(ctx, values)
+}
+
+func (s redisStore) RestoreProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	fields, err := s.getDeleted(ctx, sProject, pID, uID)
+	if err != nil {
+		return model.Project{}, err
+	}
+	p := projectFromFields(pID, fields)
+
+	// Subprojects can't be restored into a deleted project, and are archived if their parent is
+	var parent model.Project
+	if p.ParentID != nil {
+		if parent, err = s.getProject(ctx, *p.ParentID); err != nil {
+			return p, fmt.Errorf("project %s must be restored first", *p.ParentID)
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", sProject, pID)
+	n, err := redis.Int64(do(ctx, s.pool, "RENAMENX", fmt.Sprintf("%s:%s", sDeleted, key), key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return model.Project{}, err
+	}
+	if n == 0 {
+		s.log(ctx).WithField("key", key).Debug("Key does already exist")
+		return model.Project{}, fmt.Errorf("Key %s does already exist", key)
+	}
+
+	if parent.Archived && !p.Archived {
+		if _, err := redis.Int64(do(ctx, s.pool, "HSET", key, sArchived, 1)); err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return model.Project{}, err
+		}
+		p.Archived = true
+	}
+
+	// Archived projects are restored as archived
+	key = fmt.Sprintf("%s:%s", sProjects, uID)
+	if p.Archived {
+		key = fmt.Sprintf("%s:%s", sArchivedProjects, uID)
+	}
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, pID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return model.Project{}, err
+	}
+	if p.ParentID != nil {
+		if err := s.addChild(ctx, *p.ParentID, pID); err != nil {
+			return model.Project{}, err
+		}
+	}
+	if err := s.reindexTags(ctx, sProject, pID); err != nil {
+		return model.Project{}, err
+	}
+	if err := s.removeFromTrash(ctx, sProject, pID, uID); err != nil {
+		return model.Project{}, err
+	}
+
+	return s.getProject(ctx, pID)
+}
+
+func (s redisStore) RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error) {
+	fields, err := s.getDeleted(ctx, sAchievement, aID, uID)
+	if err != nil {
+		return model.Achievement{}, err
+	}
+	a := achievementFromFields(aID, fields)
+
+	// Achievements can't be restored into a deleted project
+	if err := s.errIfDoesntExist(ctx, fmt.Sprintf("%s:%s", sProject, a.ProjectID)); err != nil {
+		return a, fmt.Errorf("project %s must be restored first", a.ProjectID)
+	}
+
+	key := fmt.Sprintf("%s:%s", sAchievement, aID)
+	n, err := redis.Int64(do(ctx, s.pool, "RENAMENX", fmt.Sprintf("%s:%s", sDeleted, key), key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return a, err
+	}
+	if n == 0 {
+		s.log(ctx).WithField("key", key).Debug("Key does already exist")
+		return a, fmt.Errorf("Key %s does already exist", key)
+	}
+
+	key = fmt.Sprintf("%s:%s", sAchievements, a.ProjectID)
+	if _, err := redis.Int64(do(ctx, s.pool, "SADD", key, aID)); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return a, err
+	}
+	if err := s.reindexTags(ctx, sAchievement, aID); err != nil {
+		return a, err
+	}
+	if err := s.setRunning(ctx, aID, a.End == 0); err != nil {
+		return a, err
+	}
+	if err := s.removeFromTrash(ctx, sAchievement, aID, uID); err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// errIfInvoiced fails if an achievement of project pID, deleted or not, or of its deleted subprojects among
+// the trash items, has been invoiced, which locks it
+func (s redisStore) errIfInvoiced(ctx context.Context, pID string, items []model.TrashItem) error {
+	aIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", fmt.Sprintf("%s:%s", sAchievements, pID)))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	for _, aID := range aIDs {
+		iID, err := redis.String(do(ctx, s.pool, "HGET", fmt.Sprintf("%s:%s", sAchievement, aID), sInvoiceID))
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Database error")
+			return err
+		}
+		return fmt.Errorf("achievement %s is locked by invoice %s", aID, iID)
+	}
+
+	for _, item := range items {
+		if a := item.Achievement; a != nil && a.ProjectID == pID && a.InvoiceID != nil {
+			return fmt.Errorf("achievement %s is locked by invoice %s", a.ID, *a.InvoiceID)
+		}
+		if p := item.Project; p != nil && p.ParentID != nil && *p.ParentID == pID {
+			if err := s.errIfInvoiced(ctx, item.ID, items); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s redisStore) PurgeProject(ctx context.Context, pID, uID string) error {
+	if _, err := s.getDeleted(ctx, sProject, pID, uID); err != nil {
+		return err
+	}
+	// Invoiced achievements are locked, so nothing is removed if any of them would be
+	items, err := s.GetTrash(ctx, uID)
+	if err != nil {
+		return err
+	}
+	if err := s.errIfInvoiced(ctx, pID, items); err != nil {
+		return err
+	}
+
+	// Achievements still in the project
+	key := fmt.Sprintf("%s:%s", sAchievements, pID)
+	aIDs, err := redis.Strings(do(ctx, s.pool, "SMEMBERS", key))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	keys := []interface{}{key}
+	for _, aID := range aIDs {
+		keys = append(keys, fmt.Sprintf("%s:%s", sAchievement, aID))
+		if err := s.purgeTags(ctx, sAchievement, aID); err != nil {
+			return err
+		}
+		if err := s.setRunning(ctx, aID, false); err != nil {
+			return err
+		}
+	}
+	if _, err := do(ctx, s.pool, "DEL", keys...); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+
+	// Achievements of the project deleted on their own, and its subprojects
+	for _, item := range items {
+		if item.Achievement != nil && item.Achievement.ProjectID == pID {
+			if err := s.PurgeAchievement(ctx, item.ID, uID); err != nil {
+				return err
+			}
+		}
+		if item.Project != nil && item.Project.ParentID != nil && *item.Project.ParentID == pID {
+			if err := s.PurgeProject(ctx, item.ID, uID); err != nil {
+				return err
+			}
+		}
+	}
+
+	key = fmt.Sprintf("%s:%s:%s", sDeleted, sProject, pID)
+	if _, err := do(ctx, s.pool, "DEL", key); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if err := s.purgeTags(ctx, sProject, pID); err != nil {
+		return err
+	}
+	return s.removeFromTrash(ctx, sProject, pID, uID)
+}
+
+func (s redisStore) PurgeAchievement(ctx context.Context, aID, uID string) error {
+	fields, err := s.getDeleted(ctx, sAchievement, aID, uID)
+	if err != nil {
+		return err
+	}
+	if iID, ok := fields[sInvoiceID]; ok {
+		return fmt.Errorf("achievement %s is locked by invoice %s", aID, iID)
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", sDeleted, sAchievement, aID)
+	if _, err := do(ctx, s.pool, "DEL", key); err != nil {
+		s.log(ctx).WithError(err).Error("Database error")
+		return err
+	}
+	if err := s.purgeTags(ctx, sAchievement, aID); err != nil {
+		return err
+	}
+	return s.removeFromTrash(ctx, sAchievement, aID, uID)
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+func (s redisStore) GetUsers(ctx context.Context) ([]string, error) {
+	// Users are only known by the keys of their data
+	seen := make(map[string]bool)
+	for _, prefix := range []string{sUser, sProjects, sArchivedProjects, sCategories, sTags, sInvoices} {
+		keys, err := s.scanKeys(ctx, prefix+":*")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			seen[strings.TrimPrefix(key, prefix+":")] = true
+		}
+	}
+	users := make([]string, 0, len(seen))
+	for uID := range seen {
+		users = append(users, uID)
+	}
+	sort.Strings(users)
+	return users, nil
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUsers(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	users, err := s.GetUsers(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	createTree(t, s)
+	require.NoError(t, s.SetUserRate(ctx, "2", &model.Money{Amount: 6000, Currency: "EUR"}))
+	require.NoError(t, s.CreateTag(ctx, model.Tag{ID: "9b2e5c1a-7f1d-4c3e-a0a4-5b6c7d8e9f00", UserID: "1", Name: "urgent"}))
+
+	users, err = s.GetUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", uID}, users)
+}
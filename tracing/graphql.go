@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+)
+
+// Span attributes of GraphQL operations and fields
+const (
+	operationTypeKey = label.Key("graphql.operation.type")
+	operationNameKey = label.Key("graphql.operation.name")
+	fieldObjectKey   = label.Key("graphql.field.object")
+	fieldNameKey     = label.Key("graphql.field.name")
+	fieldPathKey     = label.Key("graphql.field.path")
+)
+
+// Extension returns a handler extension that creates a span for every GraphQL operation
+// and, as its children, a span for every resolver
+func (t *Tracing) Extension() graphql.HandlerExtension {
+	return extension{t}
+}
+
+type extension struct {
+	t *Tracing
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = extension{}
+
+func (e extension) ExtensionName() string {
+	return "Tracing"
+}
+
+func (e extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	opType, opName := "operation", "anonymous"
+	if rc.Operation != nil {
+		opType = string(rc.Operation.Operation)
+		if rc.Operation.Name != "" {
+			opName = rc.Operation.Name
+		}
+	}
+
+	ctx, span := e.t.tracer.Start(ctx, fmt.Sprintf("%s %s", opType, opName),
+		trace.WithAttributes(operationTypeKey.String(opType), operationNameKey.String(opName)),
+	)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+	return resp
+}
+
+func (e extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsMethod {
+		return next(ctx)
+	}
+
+	ctx, span := e.t.tracer.Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			fieldObjectKey.String(fc.Object),
+			fieldNameKey.String(fc.Field.Name),
+			fieldPathKey.String(fc.Path().String()),
+		),
+	)
+	res, err := next(ctx)
+	end(ctx, span, err)
+
+	return res, err
+}
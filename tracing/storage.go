@@ -0,0 +1,408 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+// Store returns a Store that creates a span for every call to s
+func (t *Tracing) Store(s storage.Store) storage.Store {
+	return store{s: s, t: t}
+}
+
+type store struct {
+	s storage.Store
+	t *Tracing
+}
+
+func (s store) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return s.t.tracer.Start(ctx, "Store."+method)
+}
+
+func (s store) CreateProject(ctx context.Context, p model.Project) error {
+	ctx, span := s.start(ctx, "CreateProject")
+	err := s.s.CreateProject(ctx, p)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetProject(ctx context.Context, pID string) (model.Project, error) {
+	ctx, span := s.start(ctx, "GetProject")
+	p, err := s.s.GetProject(ctx, pID)
+	end(ctx, span, err)
+	return p, err
+}
+
+func (s store) GetUserProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	ctx, span := s.start(ctx, "GetUserProjects")
+	ps, err := s.s.GetUserProjects(ctx, uID)
+	end(ctx, span, err)
+	return ps, err
+}
+
+func (s store) UpdateProject(ctx context.Context, pID string, np model.NewProject) (model.Project, error) {
+	ctx, span := s.start(ctx, "UpdateProject")
+	p, err := s.s.UpdateProject(ctx, pID, np)
+	end(ctx, span, err)
+	return p, err
+}
+
+func (s store) GetArchivedProjects(ctx context.Context, uID string) ([]model.Project, error) {
+	ctx, span := s.start(ctx, "GetArchivedProjects")
+	ps, err := s.s.GetArchivedProjects(ctx, uID)
+	end(ctx, span, err)
+	return ps, err
+}
+
+func (s store) GetChildProjects(ctx context.Context, pID string) ([]model.Project, error) {
+	ctx, span := s.start(ctx, "GetChildProjects")
+	ps, err := s.s.GetChildProjects(ctx, pID)
+	end(ctx, span, err)
+	return ps, err
+}
+
+func (s store) ArchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	ctx, span := s.start(ctx, "ArchiveProject")
+	p, err := s.s.ArchiveProject(ctx, pID, uID)
+	end(ctx, span, err)
+	return p, err
+}
+
+func (s store) UnarchiveProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	ctx, span := s.start(ctx, "UnarchiveProject")
+	p, err := s.s.UnarchiveProject(ctx, pID, uID)
+	end(ctx, span, err)
+	return p, err
+}
+
+func (s store) DeleteProject(ctx context.Context, pID, uID string) error {
+	ctx, span := s.start(ctx, "DeleteProject")
+	err := s.s.DeleteProject(ctx, pID, uID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) CreateCategory(ctx context.Context, c model.Category) error {
+	ctx, span := s.start(ctx, "CreateCategory")
+	err := s.s.CreateCategory(ctx, c)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetCategory(ctx context.Context, cID string) (model.Category, error) {
+	ctx, span := s.start(ctx, "GetCategory")
+	c, err := s.s.GetCategory(ctx, cID)
+	end(ctx, span, err)
+	return c, err
+}
+
+func (s store) GetUserCategories(ctx context.Context, uID string) ([]model.Category, error) {
+	ctx, span := s.start(ctx, "GetUserCategories")
+	cs, err := s.s.GetUserCategories(ctx, uID)
+	end(ctx, span, err)
+	return cs, err
+}
+
+func (s store) UpdateCategory(ctx context.Context, cID string, nc model.NewCategory) (model.Category, error) {
+	ctx, span := s.start(ctx, "UpdateCategory")
+	c, err := s.s.UpdateCategory(ctx, cID, nc)
+	end(ctx, span, err)
+	return c, err
+}
+
+func (s store) DeleteCategory(ctx context.Context, cID, uID string) error {
+	ctx, span := s.start(ctx, "DeleteCategory")
+	err := s.s.DeleteCategory(ctx, cID, uID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) CreateTag(ctx context.Context, t model.Tag) error {
+	ctx, span := s.start(ctx, "CreateTag")
+	err := s.s.CreateTag(ctx, t)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetTag(ctx context.Context, tID string) (model.Tag, error) {
+	ctx, span := s.start(ctx, "GetTag")
+	t, err := s.s.GetTag(ctx, tID)
+	end(ctx, span, err)
+	return t, err
+}
+
+func (s store) GetUserTags(ctx context.Context, uID string) ([]model.Tag, error) {
+	ctx, span := s.start(ctx, "GetUserTags")
+	ts, err := s.s.GetUserTags(ctx, uID)
+	end(ctx, span, err)
+	return ts, err
+}
+
+func (s store) UpdateTag(ctx context.Context, tID string, nt model.NewTag) (model.Tag, error) {
+	ctx, span := s.start(ctx, "UpdateTag")
+	t, err := s.s.UpdateTag(ctx, tID, nt)
+	end(ctx, span, err)
+	return t, err
+}
+
+func (s store) DeleteTag(ctx context.Context, tID, uID string) error {
+	ctx, span := s.start(ctx, "DeleteTag")
+	err := s.s.DeleteTag(ctx, tID, uID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) TagProject(ctx context.Context, pID, tID string) error {
+	ctx, span := s.start(ctx, "TagProject")
+	err := s.s.TagProject(ctx, pID, tID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) UntagProject(ctx context.Context, pID, tID string) error {
+	ctx, span := s.start(ctx, "UntagProject")
+	err := s.s.UntagProject(ctx, pID, tID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetProjectTags(ctx context.Context, pID string) ([]model.Tag, error) {
+	ctx, span := s.start(ctx, "GetProjectTags")
+	ts, err := s.s.GetProjectTags(ctx, pID)
+	end(ctx, span, err)
+	return ts, err
+}
+
+func (s store) TagAchievement(ctx context.Context, aID, tID string) error {
+	ctx, span := s.start(ctx, "TagAchievement")
+	err := s.s.TagAchievement(ctx, aID, tID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) UntagAchievement(ctx context.Context, aID, tID string) error {
+	ctx, span := s.start(ctx, "UntagAchievement")
+	err := s.s.UntagAchievement(ctx, aID, tID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetAchievementTags(ctx context.Context, aID string) ([]model.Tag, error) {
+	ctx, span := s.start(ctx, "GetAchievementTags")
+	ts, err := s.s.GetAchievementTags(ctx, aID)
+	end(ctx, span, err)
+	return ts, err
+}
+
+func (s store) GetTaggedAchievements(ctx context.Context, tagIDs []string) ([]model.Achievement, error) {
+	ctx, span := s.start(ctx, "GetTaggedAchievements")
+	as, err := s.s.GetTaggedAchievements(ctx, tagIDs)
+	end(ctx, span, err)
+	return as, err
+}
+
+func (s store) CreateAchievement(ctx context.Context, a model.Achievement) error {
+	ctx, span := s.start(ctx, "CreateAchievement")
+	err := s.s.CreateAchievement(ctx, a)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetAchievement(ctx context.Context, aID string) (model.Achievement, error) {
+	ctx, span := s.start(ctx, "GetAchievement")
+	a, err := s.s.GetAchievement(ctx, aID)
+	end(ctx, span, err)
+	return a, err
+}
+
+func (s store) GetProjectAchievements(ctx context.Context, pID string) ([]model.Achievement, error) {
+	ctx, span := s.start(ctx, "GetProjectAchievements")
+	as, err := s.s.GetProjectAchievements(ctx, pID)
+	end(ctx, span, err)
+	return as, err
+}
+
+func (s store) GetUserAchievements(ctx context.Context, uID string) ([]model.Achievement, error) {
+	ctx, span := s.start(ctx, "GetUserAchievements")
+	as, err := s.s.GetUserAchievements(ctx, uID)
+	end(ctx, span, err)
+	return as, err
+}
+
+func (s store) UpdateAchievement(ctx context.Context, aID string, newData model.AchievementData) (model.Achievement, error) {
+	ctx, span := s.start(ctx, "UpdateAchievement")
+	a, err := s.s.UpdateAchievement(ctx, aID, newData)
+	end(ctx, span, err)
+	return a, err
+}
+
+func (s store) SetAchievementNote(ctx context.Context, aID, note string) error {
+	ctx, span := s.start(ctx, "SetAchievementNote")
+	err := s.s.SetAchievementNote(ctx, aID, note)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) SetAchievementBillable(ctx context.Context, aID string, billable *bool) error {
+	ctx, span := s.start(ctx, "SetAchievementBillable")
+	err := s.s.SetAchievementBillable(ctx, aID, billable)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) SearchAchievements(ctx context.Context, uID, text string) ([]model.Achievement, error) {
+	ctx, span := s.start(ctx, "SearchAchievements")
+	as, err := s.s.SearchAchievements(ctx, uID, text)
+	end(ctx, span, err)
+	return as, err
+}
+
+func (s store) DeleteAchievement(ctx context.Context, aID, pID string) error {
+	ctx, span := s.start(ctx, "DeleteAchievement")
+	err := s.s.DeleteAchievement(ctx, aID, pID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetTrash(ctx context.Context, uID string) ([]model.TrashItem, error) {
+	ctx, span := s.start(ctx, "GetTrash")
+	items, err := s.s.GetTrash(ctx, uID)
+	end(ctx, span, err)
+	return items, err
+}
+
+func (s store) GetExpiredTrash(ctx context.Context, before int) ([]model.TrashItem, error) {
+	ctx, span := s.start(ctx, "GetExpiredTrash")
+	items, err := s.s.GetExpiredTrash(ctx, before)
+	end(ctx, span, err)
+	return items, err
+}
+
+func (s store) RestoreProject(ctx context.Context, pID, uID string) (model.Project, error) {
+	ctx, span := s.start(ctx, "RestoreProject")
+	p, err := s.s.RestoreProject(ctx, pID, uID)
+	end(ctx, span, err)
+	return p, err
+}
+
+func (s store) RestoreAchievement(ctx context.Context, aID, uID string) (model.Achievement, error) {
+	ctx, span := s.start(ctx, "RestoreAchievement")
+	a, err := s.s.RestoreAchievement(ctx, aID, uID)
+	end(ctx, span, err)
+	return a, err
+}
+
+func (s store) PurgeProject(ctx context.Context, pID, uID string) error {
+	ctx, span := s.start(ctx, "PurgeProject")
+	err := s.s.PurgeProject(ctx, pID, uID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) PurgeAchievement(ctx context.Context, aID, uID string) error {
+	ctx, span := s.start(ctx, "PurgeAchievement")
+	err := s.s.PurgeAchievement(ctx, aID, uID)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetUsers(ctx context.Context) ([]string, error) {
+	ctx, span := s.start(ctx, "GetUsers")
+	users, err := s.s.GetUsers(ctx)
+	end(ctx, span, err)
+	return users, err
+}
+
+func (s store) GetUserRate(ctx context.Context, uID string) (*model.Money, error) {
+	ctx, span := s.start(ctx, "GetUserRate")
+	rate, err := s.s.GetUserRate(ctx, uID)
+	end(ctx, span, err)
+	return rate, err
+}
+
+func (s store) SetUserRate(ctx context.Context, uID string, rate *model.Money) error {
+	ctx, span := s.start(ctx, "SetUserRate")
+	err := s.s.SetUserRate(ctx, uID, rate)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) CreateInvoice(ctx context.Context, inv model.Invoice) (model.Invoice, error) {
+	ctx, span := s.start(ctx, "CreateInvoice")
+	inv, err := s.s.CreateInvoice(ctx, inv)
+	end(ctx, span, err)
+	return inv, err
+}
+
+func (s store) GetInvoice(ctx context.Context, iID string) (model.Invoice, error) {
+	ctx, span := s.start(ctx, "GetInvoice")
+	inv, err := s.s.GetInvoice(ctx, iID)
+	end(ctx, span, err)
+	return inv, err
+}
+
+func (s store) GetUserInvoices(ctx context.Context, uID string) ([]model.Invoice, error) {
+	ctx, span := s.start(ctx, "GetUserInvoices")
+	invs, err := s.s.GetUserInvoices(ctx, uID)
+	end(ctx, span, err)
+	return invs, err
+}
+
+func (s store) GetUserRounding(ctx context.Context, uID string) (*model.Rounding, error) {
+	ctx, span := s.start(ctx, "GetUserRounding")
+	r, err := s.s.GetUserRounding(ctx, uID)
+	end(ctx, span, err)
+	return r, err
+}
+
+func (s store) SetUserRounding(ctx context.Context, uID string, r *model.Rounding) error {
+	ctx, span := s.start(ctx, "SetUserRounding")
+	err := s.s.SetUserRounding(ctx, uID, r)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) CreateExportToken(ctx context.Context, t model.ExportToken) error {
+	ctx, span := s.start(ctx, "CreateExportToken")
+	err := s.s.CreateExportToken(ctx, t)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetExportToken(ctx context.Context, token string) (model.ExportToken, error) {
+	ctx, span := s.start(ctx, "GetExportToken")
+	t, err := s.s.GetExportToken(ctx, token)
+	end(ctx, span, err)
+	return t, err
+}
+
+func (s store) AppendAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	ctx, span := s.start(ctx, "AppendAuditEntry")
+	err := s.s.AppendAuditEntry(ctx, e)
+	end(ctx, span, err)
+	return err
+}
+
+func (s store) GetAuditLog(ctx context.Context, entityID string, from, to int) ([]model.AuditEntry, error) {
+	ctx, span := s.start(ctx, "GetAuditLog")
+	es, err := s.s.GetAuditLog(ctx, entityID, from, to)
+	end(ctx, span, err)
+	return es, err
+}
+
+func (s store) GetSessionUser(ctx context.Context, token string) (string, error) {
+	ctx, span := s.start(ctx, "GetSessionUser")
+	uID, err := s.s.GetSessionUser(ctx, token)
+	end(ctx, span, err)
+	return uID, err
+}
+
+func (s store) Ping(ctx context.Context) error {
+	ctx, span := s.start(ctx, "Ping")
+	err := s.s.Ping(ctx)
+	end(ctx, span, err)
+	return err
+}
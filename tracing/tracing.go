@@ -0,0 +1,130 @@
+// Package tracing exports OpenTelemetry traces of HTTP requests, GraphQL operations and storage calls
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/smeruelo/glow/config"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/propagators"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	serviceName         = "glow"
+	instrumentationName = "github.com/smeruelo/glow/tracing"
+)
+
+// Tracing creates the spans of the server
+type Tracing struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New creates spans with the tracers of provider
+func New(provider trace.TracerProvider) *Tracing {
+	return &Tracing{
+		provider: provider,
+		tracer:   provider.Tracer(instrumentationName),
+	}
+}
+
+// NewProvider creates the tracer provider exporting spans as set in cfg.
+// Pending spans are exported when the returned Closer is closed.
+func NewProvider(cfg config.Tracing, version string) (trace.TracerProvider, io.Closer, error) {
+	var exporter exporttrace.SpanExporter
+	switch cfg.Exporter {
+	case config.ExporterNone:
+		return trace.NoopTracerProvider(), nopCloser{}, nil
+	case config.ExporterStdout:
+		e, err := stdout.NewExporter(stdout.WithWriter(os.Stdout), stdout.WithoutMetricExport())
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter = e
+	case config.ExporterOTLP:
+		opts := []otlp.ExporterOption{otlp.WithAddress(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlp.WithInsecure())
+		} else {
+			opts = append(opts, otlp.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, "")))
+		}
+		e, err := otlp.NewExporter(opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter = e
+	default:
+		return nil, nil, fmt.Errorf("unknown traces exporter %q", cfg.Exporter)
+	}
+
+	// Traces started by picard are sampled as picard decided
+	batcher := sdktrace.NewBatchSpanProcessor(exporter)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{
+			DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio)),
+		}),
+		sdktrace.WithResource(resource.New(
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(version),
+		)),
+		sdktrace.WithSpanProcessor(batcher),
+	)
+	return provider, flusher{batcher: batcher, exporter: exporter}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// flusher exports the pending spans and stops the exporter
+type flusher struct {
+	batcher  *sdktrace.BatchSpanProcessor
+	exporter exporttrace.SpanExporter
+}
+
+func (f flusher) Close() error {
+	f.batcher.Shutdown()
+	return f.exporter.Shutdown(context.Background())
+}
+
+// Handler returns h wrapped so that every request gets a span, but the ones to untracedPaths.
+// The W3C trace context sent by the client, if any, is continued.
+func (t *Tracing) Handler(h http.Handler, untracedPaths ...string) http.Handler {
+	untraced := make(map[string]bool, len(untracedPaths))
+	for _, path := range untracedPaths {
+		untraced[path] = true
+	}
+	return otelhttp.NewHandler(h, serviceName,
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithPropagators(propagators.TraceContext{}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untraced[r.URL.Path]
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
+
+// end ends span, marking it as failed if err is not nil
+func end(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+	}
+	span.End()
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/config"
+	"github.com/smeruelo/glow/graph"
+	"github.com/smeruelo/glow/graph/generated"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/api/trace/tracetest"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func newTracing() (*Tracing, *tracetest.StandardSpanRecorder) {
+	var sr tracetest.StandardSpanRecorder
+	return New(tracetest.NewTracerProvider(tracetest.WithSpanRecorder(&sr))), &sr
+}
+
+// spans returns the completed spans by name
+func spans(sr *tracetest.StandardSpanRecorder) map[string]*tracetest.Span {
+	m := map[string]*tracetest.Span{}
+	for _, s := range sr.Completed() {
+		m[s.Name()] = s
+	}
+	return m
+}
+
+func TestStore(t *testing.T) {
+	var s mocks.Store
+	tr, sr := newTracing()
+	store := tr.Store(&s)
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	s.On("GetProject", mock.Anything, pID).Return(model.Project{}, errors.New("not found"))
+
+	_, err := store.GetProject(context.Background(), pID)
+
+	assert.Error(t, err)
+	span := spans(sr)["Store.GetProject"]
+	if assert.NotNil(t, span) {
+		assert.Equal(t, codes.Error, span.StatusCode())
+	}
+	s.AssertExpectations(t)
+}
+
+func TestExtension(t *testing.T) {
+	var s mocks.Store
+	logger, _ := test.NewNullLogger()
+	tr, sr := newTracing()
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: graph.NewResolver(tr.Store(&s), logger),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tr.Extension())
+
+	pID := "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	s.On("GetProject", mock.Anything, pID).Return(model.Project{ID: pID}, nil)
+
+	body := `{"query":"query P { project(id: \"` + pID + `\") { name } }"}`
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	all := spans(sr)
+	op, field, call := all["query P"], all["Query.project"], all["Store.GetProject"]
+	if assert.NotNil(t, op) && assert.NotNil(t, field) && assert.NotNil(t, call) {
+		assert.Equal(t, op.SpanContext().SpanID, field.ParentSpanID())
+		assert.Equal(t, field.SpanContext().SpanID, call.ParentSpanID())
+		assert.Equal(t, "project", field.Attributes()[fieldNameKey].AsString())
+	}
+	s.AssertExpectations(t)
+}
+
+func TestHandlerContinuesTrace(t *testing.T) {
+	tr, sr := newTracing()
+	h := tr.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("POST", "/query", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := spans(sr)["POST /query"]
+	if assert.NotNil(t, span) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID().String())
+	}
+}
+
+func TestHandlerUntracedPaths(t *testing.T) {
+	tr, sr := newTracing()
+	h := tr.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "/healthz")
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/export", nil))
+
+	assert.NotContains(t, spans(sr), "GET /healthz")
+	assert.Contains(t, spans(sr), "GET /export")
+}
+
+func TestNewProviderNone(t *testing.T) {
+	cfg := config.Default().Tracing
+
+	_, closer, err := NewProvider(cfg, "v1.0.0")
+
+	assert.NoError(t, err)
+	assert.NoError(t, closer.Close())
+}
@@ -0,0 +1,98 @@
+// Package trash permanently removes the projects and achievements that have been in the trash for too long
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/smeruelo/glow/storage"
+)
+
+// Purger removes the items deleted more than a retention period ago
+type Purger struct {
+	store     storage.Store
+	retention time.Duration
+	logger    logrus.FieldLogger
+	now       func() time.Time
+}
+
+// NewPurger creates a Purger that removes the expired items of s
+func NewPurger(s storage.Store, retention time.Duration, logger logrus.FieldLogger) *Purger {
+	return &Purger{store: s, retention: retention, logger: logger, now: time.Now}
+}
+
+// Run purges the expired items every interval until ctx is done
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := p.Purge(ctx); err != nil {
+			p.logger.WithError(err).Error("Unable to purge trash")
+		} else if n > 0 {
+			p.logger.WithField("items", n).Info("Trash purged")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the expired items once, and returns how many were removed.
+// Projects are removed along with all their achievements and subprojects.
+// Items that can't be removed are left for the next purge.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	before := int(p.now().Add(-p.retention).Unix())
+	items, err := p.store.GetExpiredTrash(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	parents := map[string]string{}
+	for _, item := range items {
+		if item.Project != nil && item.Project.ParentID != nil {
+			parents[item.ID] = *item.Project.ParentID
+		}
+	}
+	purged := map[string]bool{}
+	// removed tells whether project pID, or one of its ancestors, has already been purged
+	removed := func(pID string) bool {
+		for id, ok := pID, true; ok; id, ok = parents[id] {
+			if purged[id] {
+				return true
+			}
+		}
+		return false
+	}
+
+	n := 0
+	for _, item := range items {
+		switch {
+		case item.Project != nil:
+			if removed(item.ID) {
+				// Already removed along with its parent
+				purged[item.ID] = true
+				continue
+			}
+			err = p.store.PurgeProject(ctx, item.ID, item.Project.UserID)
+			purged[item.ID] = err == nil
+		case item.Achievement != nil:
+			if removed(item.Achievement.ProjectID) {
+				// Already removed along with its project
+				continue
+			}
+			err = p.store.PurgeAchievement(ctx, item.ID, item.Achievement.UserID)
+		default:
+			continue
+		}
+		if err != nil {
+			p.logger.WithError(err).WithField("entityID", item.ID).Error("Unable to purge item")
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
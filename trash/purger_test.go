@@ -0,0 +1,133 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/smeruelo/glow/graph/model"
+	"github.com/smeruelo/glow/storage"
+	"github.com/smeruelo/glow/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	uID  = "e0b4e4b5-6d5f-4a3c-9b3a-1f6d3c2e9a10"
+	pID  = "3b054f50-9d3d-4114-bfc4-395f70a59d26"
+	aID1 = "9a6f1c1e-4b1e-4d0e-8d9b-2a3c4f5e6d7c"
+	aID2 = "b1265627-d9f2-4a0b-b60d-322273b7df83"
+)
+
+func newTestPurger(s storage.Store) *Purger {
+	logger, _ := test.NewNullLogger()
+	p := NewPurger(s, 24*time.Hour, logger)
+	p.now = func() time.Time { return time.Unix(1600086400, 0) }
+	return p
+}
+
+func TestPurge(t *testing.T) {
+	var s mocks.Store
+	p := newTestPurger(&s)
+	ctx := context.Background()
+
+	otherPID := "0f8b2b9e-3c1d-4e5f-a6b7-c8d9e0f1a2b3"
+	s.On("GetExpiredTrash", ctx, 1600000000).Return([]model.TrashItem{
+		{ID: aID1, EntityType: storage.EntityAchievement, Achievement: &model.Achievement{ID: aID1, UserID: uID, ProjectID: otherPID}},
+		{ID: pID, EntityType: storage.EntityProject, Project: &model.Project{ID: pID, UserID: uID}},
+		{ID: aID2, EntityType: storage.EntityAchievement, Achievement: &model.Achievement{ID: aID2, UserID: uID, ProjectID: pID}},
+	}, nil)
+	s.On("PurgeAchievement", ctx, aID1, uID).Return(nil)
+	s.On("PurgeProject", ctx, pID, uID).Return(nil)
+
+	n, err := p.Purge(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	s.AssertExpectations(t)
+	s.AssertNotCalled(t, "PurgeAchievement", ctx, aID2, uID)
+}
+
+func TestPurgeSubprojects(t *testing.T) {
+	var s mocks.Store
+	p := newTestPurger(&s)
+	ctx := context.Background()
+
+	childID := "0f8b2b9e-3c1d-4e5f-a6b7-c8d9e0f1a2b3"
+	grandchildID := "5d1a7c3e-8f2b-4a6d-9e0c-b7f4a2d1c8e5"
+	s.On("GetExpiredTrash", ctx, 1600000000).Return([]model.TrashItem{
+		{ID: pID, EntityType: storage.EntityProject, Project: &model.Project{ID: pID, UserID: uID}},
+		{ID: grandchildID, EntityType: storage.EntityProject, Project: &model.Project{ID: grandchildID, UserID: uID, ParentID: str(childID)}},
+		{ID: childID, EntityType: storage.EntityProject, Project: &model.Project{ID: childID, UserID: uID, ParentID: str(pID)}},
+		{ID: aID1, EntityType: storage.EntityAchievement, Achievement: &model.Achievement{ID: aID1, UserID: uID, ProjectID: grandchildID}},
+	}, nil)
+	s.On("PurgeProject", ctx, pID, uID).Return(nil)
+
+	n, err := p.Purge(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	s.AssertExpectations(t)
+	s.AssertNumberOfCalls(t, "PurgeProject", 1)
+	s.AssertNotCalled(t, "PurgeAchievement", ctx, aID1, uID)
+}
+
+func TestPurgeItemFails(t *testing.T) {
+	var s mocks.Store
+	p := newTestPurger(&s)
+	ctx := context.Background()
+
+	s.On("GetExpiredTrash", ctx, 1600000000).Return([]model.TrashItem{
+		{ID: pID, EntityType: storage.EntityProject, Project: &model.Project{ID: pID, UserID: uID}},
+		{ID: aID2, EntityType: storage.EntityAchievement, Achievement: &model.Achievement{ID: aID2, UserID: uID, ProjectID: pID}},
+	}, nil)
+	s.On("PurgeProject", ctx, pID, uID).Return(errors.New("database error"))
+	s.On("PurgeAchievement", ctx, aID2, uID).Return(nil)
+
+	n, err := p.Purge(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	s.AssertExpectations(t)
+}
+
+func TestPurgeFails(t *testing.T) {
+	var s mocks.Store
+	p := newTestPurger(&s)
+	ctx := context.Background()
+
+	s.On("GetExpiredTrash", ctx, mock.Anything).Return(nil, errors.New("database error"))
+
+	_, err := p.Purge(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestRunStops(t *testing.T) {
+	var s mocks.Store
+	p := newTestPurger(&s)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.On("GetExpiredTrash", ctx, mock.Anything).Return([]model.TrashItem{}, nil).Run(func(mock.Arguments) {
+		cancel()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't stop")
+	}
+	s.AssertExpectations(t)
+}
+
+func str(s string) *string {
+	return &s
+}